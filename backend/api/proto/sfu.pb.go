@@ -70,43 +70,111 @@ func (SdpType) EnumDescriptor() ([]byte, []int) {
 type ActionType int32
 
 const (
-	ActionType_START_ROOM  ActionType = 0
-	ActionType_END_ROOM    ActionType = 1
-	ActionType_JOIN        ActionType = 2
-	ActionType_LEAVE       ActionType = 3
-	ActionType_AUDIO_ON    ActionType = 4
-	ActionType_AUDIO_OFF   ActionType = 5
-	ActionType_VIDEO_ON    ActionType = 6
-	ActionType_VIDEO_OFF   ActionType = 7
-	ActionType_DUBBING_ON  ActionType = 8
-	ActionType_DUBBING_OFF ActionType = 9
+	ActionType_START_ROOM       ActionType = 0
+	ActionType_END_ROOM         ActionType = 1
+	ActionType_JOIN             ActionType = 2
+	ActionType_LEAVE            ActionType = 3
+	ActionType_AUDIO_ON         ActionType = 4
+	ActionType_AUDIO_OFF        ActionType = 5
+	ActionType_VIDEO_ON         ActionType = 6
+	ActionType_VIDEO_OFF        ActionType = 7
+	ActionType_DUBBING_ON       ActionType = 8
+	ActionType_DUBBING_OFF      ActionType = 9
+	ActionType_PAUSE_VIDEO      ActionType = 10
+	ActionType_RESUME_VIDEO     ActionType = 11
+	ActionType_SET_MAX_LAYER    ActionType = 12
+	ActionType_AUDIO_ONLY_ON    ActionType = 13
+	ActionType_AUDIO_ONLY_OFF   ActionType = 14
+	ActionType_SCREEN_SHARE_ON  ActionType = 15
+	ActionType_SCREEN_SHARE_OFF ActionType = 16
+	// ask for a ROOM_STATE snapshot after missing an event
+	ActionType_SYNC_STATE ActionType = 17
+	ActionType_RAISE_HAND ActionType = 18
+	ActionType_LOWER_HAND ActionType = 19
+	ActionType_REACT      ActionType = 20
+	// host only
+	ActionType_CALL_NEXT      ActionType = 21
+	ActionType_GET_HAND_QUEUE ActionType = 22
+	// host only, breakout rooms
+	ActionType_BREAKOUT_OPEN      ActionType = 23
+	ActionType_BREAKOUT_ASSIGN    ActionType = 24
+	ActionType_BREAKOUT_BROADCAST ActionType = 25
+	ActionType_BREAKOUT_RECALL    ActionType = 26
+	// host only, live stream of the room
+	ActionType_EGRESS_START ActionType = 27
+	ActionType_EGRESS_STOP  ActionType = 28
+	// host only, live captions of the room
+	ActionType_TRANSCRIPTION_START ActionType = 29
+	ActionType_TRANSCRIPTION_STOP  ActionType = 30
 )
 
 // Enum value maps for ActionType.
 var (
 	ActionType_name = map[int32]string{
-		0: "START_ROOM",
-		1: "END_ROOM",
-		2: "JOIN",
-		3: "LEAVE",
-		4: "AUDIO_ON",
-		5: "AUDIO_OFF",
-		6: "VIDEO_ON",
-		7: "VIDEO_OFF",
-		8: "DUBBING_ON",
-		9: "DUBBING_OFF",
+		0:  "START_ROOM",
+		1:  "END_ROOM",
+		2:  "JOIN",
+		3:  "LEAVE",
+		4:  "AUDIO_ON",
+		5:  "AUDIO_OFF",
+		6:  "VIDEO_ON",
+		7:  "VIDEO_OFF",
+		8:  "DUBBING_ON",
+		9:  "DUBBING_OFF",
+		10: "PAUSE_VIDEO",
+		11: "RESUME_VIDEO",
+		12: "SET_MAX_LAYER",
+		13: "AUDIO_ONLY_ON",
+		14: "AUDIO_ONLY_OFF",
+		15: "SCREEN_SHARE_ON",
+		16: "SCREEN_SHARE_OFF",
+		17: "SYNC_STATE",
+		18: "RAISE_HAND",
+		19: "LOWER_HAND",
+		20: "REACT",
+		21: "CALL_NEXT",
+		22: "GET_HAND_QUEUE",
+		23: "BREAKOUT_OPEN",
+		24: "BREAKOUT_ASSIGN",
+		25: "BREAKOUT_BROADCAST",
+		26: "BREAKOUT_RECALL",
+		27: "EGRESS_START",
+		28: "EGRESS_STOP",
+		29: "TRANSCRIPTION_START",
+		30: "TRANSCRIPTION_STOP",
 	}
 	ActionType_value = map[string]int32{
-		"START_ROOM":  0,
-		"END_ROOM":    1,
-		"JOIN":        2,
-		"LEAVE":       3,
-		"AUDIO_ON":    4,
-		"AUDIO_OFF":   5,
-		"VIDEO_ON":    6,
-		"VIDEO_OFF":   7,
-		"DUBBING_ON":  8,
-		"DUBBING_OFF": 9,
+		"START_ROOM":          0,
+		"END_ROOM":            1,
+		"JOIN":                2,
+		"LEAVE":               3,
+		"AUDIO_ON":            4,
+		"AUDIO_OFF":           5,
+		"VIDEO_ON":            6,
+		"VIDEO_OFF":           7,
+		"DUBBING_ON":          8,
+		"DUBBING_OFF":         9,
+		"PAUSE_VIDEO":         10,
+		"RESUME_VIDEO":        11,
+		"SET_MAX_LAYER":       12,
+		"AUDIO_ONLY_ON":       13,
+		"AUDIO_ONLY_OFF":      14,
+		"SCREEN_SHARE_ON":     15,
+		"SCREEN_SHARE_OFF":    16,
+		"SYNC_STATE":          17,
+		"RAISE_HAND":          18,
+		"LOWER_HAND":          19,
+		"REACT":               20,
+		"CALL_NEXT":           21,
+		"GET_HAND_QUEUE":      22,
+		"BREAKOUT_OPEN":       23,
+		"BREAKOUT_ASSIGN":     24,
+		"BREAKOUT_BROADCAST":  25,
+		"BREAKOUT_RECALL":     26,
+		"EGRESS_START":        27,
+		"EGRESS_STOP":         28,
+		"TRANSCRIPTION_START": 29,
+		"TRANSCRIPTION_STOP":  30,
 	}
 )
 
@@ -137,44 +205,167 @@ func (ActionType) EnumDescriptor() ([]byte, []int) {
 	return file_sfu_proto_rawDescGZIP(), []int{1}
 }
 
+// Highest video layer a subscriber wants from a publisher
+type VideoLayer int32
+
+const (
+	VideoLayer_LAYER_FULL VideoLayer = 0
+	VideoLayer_LAYER_BASE VideoLayer = 1
+)
+
+// Enum value maps for VideoLayer.
+var (
+	VideoLayer_name = map[int32]string{
+		0: "LAYER_FULL",
+		1: "LAYER_BASE",
+	}
+	VideoLayer_value = map[string]int32{
+		"LAYER_FULL": 0,
+		"LAYER_BASE": 1,
+	}
+)
+
+func (x VideoLayer) Enum() *VideoLayer {
+	p := new(VideoLayer)
+	*p = x
+	return p
+}
+
+func (x VideoLayer) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (VideoLayer) Descriptor() protoreflect.EnumDescriptor {
+	return file_sfu_proto_enumTypes[2].Descriptor()
+}
+
+func (VideoLayer) Type() protoreflect.EnumType {
+	return &file_sfu_proto_enumTypes[2]
+}
+
+func (x VideoLayer) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use VideoLayer.Descriptor instead.
+func (VideoLayer) EnumDescriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{2}
+}
+
 // Event Type
 type EventType int32
 
 const (
-	EventType_ROOM_ACTIVE    EventType = 0
-	EventType_ROOM_INACTIVE  EventType = 1
-	EventType_ROOM_ENDED     EventType = 2
-	EventType_JOIN_EVENT     EventType = 3
-	EventType_LEAVE_EVENT    EventType = 4
-	EventType_AUDIO_ENABLED  EventType = 5
-	EventType_AUDIO_DISABLED EventType = 6
-	EventType_VIDEO_ENABLED  EventType = 7
-	EventType_VIDEO_DISABLED EventType = 8
+	EventType_ROOM_ACTIVE          EventType = 0
+	EventType_ROOM_INACTIVE        EventType = 1
+	EventType_ROOM_ENDED           EventType = 2
+	EventType_JOIN_EVENT           EventType = 3
+	EventType_LEAVE_EVENT          EventType = 4
+	EventType_AUDIO_ENABLED        EventType = 5
+	EventType_AUDIO_DISABLED       EventType = 6
+	EventType_VIDEO_ENABLED        EventType = 7
+	EventType_VIDEO_DISABLED       EventType = 8
+	EventType_SUB_ENABLED          EventType = 9
+	EventType_SUB_DISABLED         EventType = 10
+	EventType_QUALITY              EventType = 11
+	EventType_CODEC_REJECTED       EventType = 12
+	EventType_ROOM_STATE           EventType = 13
+	EventType_SCREEN_SHARE_STARTED EventType = 14
+	EventType_SCREEN_SHARE_STOPPED EventType = 15
+	EventType_HAND_RAISED          EventType = 16
+	EventType_HAND_LOWERED         EventType = 17
+	// the host called on the peer, its hand is lowered
+	EventType_CALLED_ON EventType = 18
+	EventType_REACTION  EventType = 19
+	// raised hands in order, sent to hosts
+	EventType_HAND_QUEUE       EventType = 20
+	EventType_BREAKOUTS_OPENED EventType = 21
+	// the peer was moved to roomID, a ROOM_STATE of that room follows
+	EventType_BREAKOUT_MOVED        EventType = 22
+	EventType_BREAKOUT_MESSAGE      EventType = 23
+	EventType_BREAKOUTS_CLOSED      EventType = 24
+	EventType_EGRESS_STARTED        EventType = 25
+	EventType_EGRESS_STOPPED        EventType = 26
+	EventType_TRANSCRIPTION_STARTED EventType = 27
+	EventType_TRANSCRIPTION_STOPPED EventType = 28
+	EventType_CAPTION               EventType = 29
+	// end-to-end encrypted rooms, a peer joined or left: every sender
+	// distributes a new key for keyEpoch
+	EventType_KEY_ROTATE EventType = 30
+	// the SFU is shutting down, clients reconnect elsewhere before endsAt
+	// when the room is ended
+	EventType_SERVER_DRAINING EventType = 31
 )
 
 // Enum value maps for EventType.
 var (
 	EventType_name = map[int32]string{
-		0: "ROOM_ACTIVE",
-		1: "ROOM_INACTIVE",
-		2: "ROOM_ENDED",
-		3: "JOIN_EVENT",
-		4: "LEAVE_EVENT",
-		5: "AUDIO_ENABLED",
-		6: "AUDIO_DISABLED",
-		7: "VIDEO_ENABLED",
-		8: "VIDEO_DISABLED",
+		0:  "ROOM_ACTIVE",
+		1:  "ROOM_INACTIVE",
+		2:  "ROOM_ENDED",
+		3:  "JOIN_EVENT",
+		4:  "LEAVE_EVENT",
+		5:  "AUDIO_ENABLED",
+		6:  "AUDIO_DISABLED",
+		7:  "VIDEO_ENABLED",
+		8:  "VIDEO_DISABLED",
+		9:  "SUB_ENABLED",
+		10: "SUB_DISABLED",
+		11: "QUALITY",
+		12: "CODEC_REJECTED",
+		13: "ROOM_STATE",
+		14: "SCREEN_SHARE_STARTED",
+		15: "SCREEN_SHARE_STOPPED",
+		16: "HAND_RAISED",
+		17: "HAND_LOWERED",
+		18: "CALLED_ON",
+		19: "REACTION",
+		20: "HAND_QUEUE",
+		21: "BREAKOUTS_OPENED",
+		22: "BREAKOUT_MOVED",
+		23: "BREAKOUT_MESSAGE",
+		24: "BREAKOUTS_CLOSED",
+		25: "EGRESS_STARTED",
+		26: "EGRESS_STOPPED",
+		27: "TRANSCRIPTION_STARTED",
+		28: "TRANSCRIPTION_STOPPED",
+		29: "CAPTION",
+		30: "KEY_ROTATE",
+		31: "SERVER_DRAINING",
 	}
 	EventType_value = map[string]int32{
-		"ROOM_ACTIVE":    0,
-		"ROOM_INACTIVE":  1,
-		"ROOM_ENDED":     2,
-		"JOIN_EVENT":     3,
-		"LEAVE_EVENT":    4,
-		"AUDIO_ENABLED":  5,
-		"AUDIO_DISABLED": 6,
-		"VIDEO_ENABLED":  7,
-		"VIDEO_DISABLED": 8,
+		"ROOM_ACTIVE":           0,
+		"ROOM_INACTIVE":         1,
+		"ROOM_ENDED":            2,
+		"JOIN_EVENT":            3,
+		"LEAVE_EVENT":           4,
+		"AUDIO_ENABLED":         5,
+		"AUDIO_DISABLED":        6,
+		"VIDEO_ENABLED":         7,
+		"VIDEO_DISABLED":        8,
+		"SUB_ENABLED":           9,
+		"SUB_DISABLED":          10,
+		"QUALITY":               11,
+		"CODEC_REJECTED":        12,
+		"ROOM_STATE":            13,
+		"SCREEN_SHARE_STARTED":  14,
+		"SCREEN_SHARE_STOPPED":  15,
+		"HAND_RAISED":           16,
+		"HAND_LOWERED":          17,
+		"CALLED_ON":             18,
+		"REACTION":              19,
+		"HAND_QUEUE":            20,
+		"BREAKOUTS_OPENED":      21,
+		"BREAKOUT_MOVED":        22,
+		"BREAKOUT_MESSAGE":      23,
+		"BREAKOUTS_CLOSED":      24,
+		"EGRESS_STARTED":        25,
+		"EGRESS_STOPPED":        26,
+		"TRANSCRIPTION_STARTED": 27,
+		"TRANSCRIPTION_STOPPED": 28,
+		"CAPTION":               29,
+		"KEY_ROTATE":            30,
+		"SERVER_DRAINING":       31,
 	}
 )
 
@@ -188,148 +379,2113 @@ func (x EventType) String() string {
 	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
 }
 
-func (EventType) Descriptor() protoreflect.EnumDescriptor {
-	return file_sfu_proto_enumTypes[2].Descriptor()
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_sfu_proto_enumTypes[3].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_sfu_proto_enumTypes[3]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{3}
+}
+
+// Key material of end-to-end encrypted rooms
+type KeyType int32
+
+const (
+	KeyType_PUBLIC_KEY KeyType = 0 // to the room, or to toID answering its public key
+	KeyType_SENDER_KEY KeyType = 1 // the sender's frame key, encrypted to toID
+)
+
+// Enum value maps for KeyType.
+var (
+	KeyType_name = map[int32]string{
+		0: "PUBLIC_KEY",
+		1: "SENDER_KEY",
+	}
+	KeyType_value = map[string]int32{
+		"PUBLIC_KEY": 0,
+		"SENDER_KEY": 1,
+	}
+)
+
+func (x KeyType) Enum() *KeyType {
+	p := new(KeyType)
+	*p = x
+	return p
+}
+
+func (x KeyType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KeyType) Descriptor() protoreflect.EnumDescriptor {
+	return file_sfu_proto_enumTypes[4].Descriptor()
+}
+
+func (KeyType) Type() protoreflect.EnumType {
+	return &file_sfu_proto_enumTypes[4]
+}
+
+func (x KeyType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KeyType.Descriptor instead.
+func (KeyType) EnumDescriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{4}
+}
+
+// Picture of a live stream
+type EgressLayout int32
+
+const (
+	EgressLayout_GRID    EgressLayout = 0 // every publisher, up to 9 tiles
+	EgressLayout_SPEAKER EgressLayout = 1 // one publisher, the host unless targetID is set
+)
+
+// Enum value maps for EgressLayout.
+var (
+	EgressLayout_name = map[int32]string{
+		0: "GRID",
+		1: "SPEAKER",
+	}
+	EgressLayout_value = map[string]int32{
+		"GRID":    0,
+		"SPEAKER": 1,
+	}
+)
+
+func (x EgressLayout) Enum() *EgressLayout {
+	p := new(EgressLayout)
+	*p = x
+	return p
+}
+
+func (x EgressLayout) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EgressLayout) Descriptor() protoreflect.EnumDescriptor {
+	return file_sfu_proto_enumTypes[5].Descriptor()
+}
+
+func (EgressLayout) Type() protoreflect.EnumType {
+	return &file_sfu_proto_enumTypes[5]
+}
+
+func (x EgressLayout) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EgressLayout.Descriptor instead.
+func (EgressLayout) EnumDescriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{5}
+}
+
+// Peer Connection Type
+type PcType int32
+
+const (
+	PcType_PC_UNSPECIFIED PcType = 0
+	PcType_PUB            PcType = 1
+	PcType_SUB            PcType = 2
+)
+
+// Enum value maps for PcType.
+var (
+	PcType_name = map[int32]string{
+		0: "PC_UNSPECIFIED",
+		1: "PUB",
+		2: "SUB",
+	}
+	PcType_value = map[string]int32{
+		"PC_UNSPECIFIED": 0,
+		"PUB":            1,
+		"SUB":            2,
+	}
+)
+
+func (x PcType) Enum() *PcType {
+	p := new(PcType)
+	*p = x
+	return p
+}
+
+func (x PcType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PcType) Descriptor() protoreflect.EnumDescriptor {
+	return file_sfu_proto_enumTypes[6].Descriptor()
+}
+
+func (PcType) Type() protoreflect.EnumType {
+	return &file_sfu_proto_enumTypes[6]
+}
+
+func (x PcType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PcType.Descriptor instead.
+func (PcType) EnumDescriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{6}
+}
+
+// Role type
+type RoleType int32
+
+const (
+	RoleType_ROLE_UNSPECIFIED RoleType = 0
+	RoleType_ROLE_HOST        RoleType = 1
+	RoleType_ROLE_GUEST       RoleType = 2
+	RoleType_ROLE_BOT         RoleType = 3
+	RoleType_ROLE_VIEWER      RoleType = 4 // receives media only, not a participant
+)
+
+// Enum value maps for RoleType.
+var (
+	RoleType_name = map[int32]string{
+		0: "ROLE_UNSPECIFIED",
+		1: "ROLE_HOST",
+		2: "ROLE_GUEST",
+		3: "ROLE_BOT",
+		4: "ROLE_VIEWER",
+	}
+	RoleType_value = map[string]int32{
+		"ROLE_UNSPECIFIED": 0,
+		"ROLE_HOST":        1,
+		"ROLE_GUEST":       2,
+		"ROLE_BOT":         3,
+		"ROLE_VIEWER":      4,
+	}
+)
+
+func (x RoleType) Enum() *RoleType {
+	p := new(RoleType)
+	*p = x
+	return p
+}
+
+func (x RoleType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RoleType) Descriptor() protoreflect.EnumDescriptor {
+	return file_sfu_proto_enumTypes[7].Descriptor()
+}
+
+func (RoleType) Type() protoreflect.EnumType {
+	return &file_sfu_proto_enumTypes[7]
+}
+
+func (x RoleType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RoleType.Descriptor instead.
+func (RoleType) EnumDescriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{7}
+}
+
+// Why an action or message was refused
+type ErrorCode int32
+
+const (
+	ErrorCode_ERROR_UNSPECIFIED   ErrorCode = 0
+	ErrorCode_BAD_REQUEST         ErrorCode = 1
+	ErrorCode_UNKNOWN_ACTION      ErrorCode = 2
+	ErrorCode_UNSUPPORTED_VERSION ErrorCode = 3
+	ErrorCode_NOT_ALLOWED         ErrorCode = 4
+	ErrorCode_ROOM_NOT_LIVE       ErrorCode = 5
+	ErrorCode_NOT_SUBSCRIBED      ErrorCode = 6
+	ErrorCode_NOT_IMPLEMENTED     ErrorCode = 7
+	ErrorCode_INTERNAL            ErrorCode = 8
+	ErrorCode_RATE_LIMITED        ErrorCode = 9
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "ERROR_UNSPECIFIED",
+		1: "BAD_REQUEST",
+		2: "UNKNOWN_ACTION",
+		3: "UNSUPPORTED_VERSION",
+		4: "NOT_ALLOWED",
+		5: "ROOM_NOT_LIVE",
+		6: "NOT_SUBSCRIBED",
+		7: "NOT_IMPLEMENTED",
+		8: "INTERNAL",
+		9: "RATE_LIMITED",
+	}
+	ErrorCode_value = map[string]int32{
+		"ERROR_UNSPECIFIED":   0,
+		"BAD_REQUEST":         1,
+		"UNKNOWN_ACTION":      2,
+		"UNSUPPORTED_VERSION": 3,
+		"NOT_ALLOWED":         4,
+		"ROOM_NOT_LIVE":       5,
+		"NOT_SUBSCRIBED":      6,
+		"NOT_IMPLEMENTED":     7,
+		"INTERNAL":            8,
+		"RATE_LIMITED":        9,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_sfu_proto_enumTypes[8].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_sfu_proto_enumTypes[8]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{8}
+}
+
+type Action struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  ActionType             `protobuf:"varint,1,opt,name=type,proto3,enum=SFU.ActionType" json:"type,omitempty"`
+	// remote peer for PAUSE_VIDEO, RESUME_VIDEO and SET_MAX_LAYER
+	TargetID *string     `protobuf:"bytes,2,opt,name=targetID,proto3,oneof" json:"targetID,omitempty"`
+	MaxLayer *VideoLayer `protobuf:"varint,3,opt,name=maxLayer,proto3,enum=SFU.VideoLayer,oneof" json:"maxLayer,omitempty"`
+	// client request ID, echoed in the Ack or Error
+	RequestID string `protobuf:"bytes,4,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	// emoji for REACT
+	Emoji *string `protobuf:"bytes,5,opt,name=emoji,proto3,oneof" json:"emoji,omitempty"`
+	// BREAKOUT_OPEN: number of rooms, timer (0 for none) and random assignment
+	Count       *uint32 `protobuf:"varint,6,opt,name=count,proto3,oneof" json:"count,omitempty"`
+	DurationSec *uint32 `protobuf:"varint,7,opt,name=durationSec,proto3,oneof" json:"durationSec,omitempty"`
+	Random      *bool   `protobuf:"varint,8,opt,name=random,proto3,oneof" json:"random,omitempty"`
+	// BREAKOUT_ASSIGN: breakout or main room for targetID
+	RoomID *string `protobuf:"bytes,9,opt,name=roomID,proto3,oneof" json:"roomID,omitempty"`
+	// BREAKOUT_BROADCAST
+	Message *string `protobuf:"bytes,10,opt,name=message,proto3,oneof" json:"message,omitempty"`
+	// EGRESS_START, targetID picks the speaker
+	Layout        *EgressLayout `protobuf:"varint,11,opt,name=layout,proto3,enum=SFU.EgressLayout,oneof" json:"layout,omitempty"`
+	RtmpURL       *string       `protobuf:"bytes,12,opt,name=rtmpURL,proto3,oneof" json:"rtmpURL,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Action) Reset() {
+	*x = Action{}
+	mi := &file_sfu_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Action) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Action) ProtoMessage() {}
+
+func (x *Action) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Action.ProtoReflect.Descriptor instead.
+func (*Action) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Action) GetType() ActionType {
+	if x != nil {
+		return x.Type
+	}
+	return ActionType_START_ROOM
+}
+
+func (x *Action) GetTargetID() string {
+	if x != nil && x.TargetID != nil {
+		return *x.TargetID
+	}
+	return ""
+}
+
+func (x *Action) GetMaxLayer() VideoLayer {
+	if x != nil && x.MaxLayer != nil {
+		return *x.MaxLayer
+	}
+	return VideoLayer_LAYER_FULL
+}
+
+func (x *Action) GetRequestID() string {
+	if x != nil {
+		return x.RequestID
+	}
+	return ""
+}
+
+func (x *Action) GetEmoji() string {
+	if x != nil && x.Emoji != nil {
+		return *x.Emoji
+	}
+	return ""
+}
+
+func (x *Action) GetCount() uint32 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+func (x *Action) GetDurationSec() uint32 {
+	if x != nil && x.DurationSec != nil {
+		return *x.DurationSec
+	}
+	return 0
+}
+
+func (x *Action) GetRandom() bool {
+	if x != nil && x.Random != nil {
+		return *x.Random
+	}
+	return false
+}
+
+func (x *Action) GetRoomID() string {
+	if x != nil && x.RoomID != nil {
+		return *x.RoomID
+	}
+	return ""
+}
+
+func (x *Action) GetMessage() string {
+	if x != nil && x.Message != nil {
+		return *x.Message
+	}
+	return ""
+}
+
+func (x *Action) GetLayout() EgressLayout {
+	if x != nil && x.Layout != nil {
+		return *x.Layout
+	}
+	return EgressLayout_GRID
+}
+
+func (x *Action) GetRtmpURL() string {
+	if x != nil && x.RtmpURL != nil {
+		return *x.RtmpURL
+	}
+	return ""
+}
+
+// Action done
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestID     string                 `protobuf:"bytes,1,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_sfu_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Ack) GetRequestID() string {
+	if x != nil {
+		return x.RequestID
+	}
+	return ""
+}
+
+// Action or message refused
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestID     string                 `protobuf:"bytes,1,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	Code          ErrorCode              `protobuf:"varint,2,opt,name=code,proto3,enum=SFU.ErrorCode" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_sfu_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Error) GetRequestID() string {
+	if x != nil {
+		return x.RequestID
+	}
+	return ""
+}
+
+func (x *Error) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_ERROR_UNSPECIFIED
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Event struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Type    EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=SFU.EventType" json:"type,omitempty"`
+	Name    string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	PeerID  string                 `protobuf:"bytes,3,opt,name=peerID,proto3" json:"peerID,omitempty"`
+	Quality *Quality               `protobuf:"bytes,4,opt,name=quality,proto3" json:"quality,omitempty"`
+	// video codecs the publisher should offer instead, for CODEC_REJECTED
+	Codecs []string `protobuf:"bytes,5,rep,name=codecs,proto3" json:"codecs,omitempty"`
+	// room sequence number of peer state events, 0 for other events
+	Seq uint64 `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`
+	// full room state, for ROOM_STATE
+	State *RoomState `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"`
+	// state of the peer after the change, for peer state events
+	Peer *PeerState `protobuf:"bytes,8,opt,name=peer,proto3" json:"peer,omitempty"`
+	// peer IDs of the raised hands in order, for HAND_QUEUE
+	HandQueue []string `protobuf:"bytes,9,rep,name=handQueue,proto3" json:"handQueue,omitempty"`
+	// for REACTION
+	Emoji *string `protobuf:"bytes,10,opt,name=emoji,proto3,oneof" json:"emoji,omitempty"`
+	// breakout room IDs, for BREAKOUTS_OPENED
+	Breakouts []string `protobuf:"bytes,11,rep,name=breakouts,proto3" json:"breakouts,omitempty"`
+	// room the peer moved to, for BREAKOUT_MOVED
+	RoomID *string `protobuf:"bytes,12,opt,name=roomID,proto3,oneof" json:"roomID,omitempty"`
+	// for BREAKOUT_MESSAGE
+	Message *string `protobuf:"bytes,13,opt,name=message,proto3,oneof" json:"message,omitempty"`
+	// unix ms the breakouts are recalled, for BREAKOUTS_OPENED with a timer,
+	// or the rooms are ended, for SERVER_DRAINING
+	EndsAt *int64 `protobuf:"varint,14,opt,name=endsAt,proto3,oneof" json:"endsAt,omitempty"`
+	// for CAPTION
+	Caption *Caption `protobuf:"bytes,15,opt,name=caption,proto3" json:"caption,omitempty"`
+	// for KEY_ROTATE
+	KeyEpoch      *uint64 `protobuf:"varint,16,opt,name=keyEpoch,proto3,oneof" json:"keyEpoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_sfu_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Event) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_ROOM_ACTIVE
+}
+
+func (x *Event) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Event) GetPeerID() string {
+	if x != nil {
+		return x.PeerID
+	}
+	return ""
+}
+
+func (x *Event) GetQuality() *Quality {
+	if x != nil {
+		return x.Quality
+	}
+	return nil
+}
+
+func (x *Event) GetCodecs() []string {
+	if x != nil {
+		return x.Codecs
+	}
+	return nil
+}
+
+func (x *Event) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetState() *RoomState {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *Event) GetPeer() *PeerState {
+	if x != nil {
+		return x.Peer
+	}
+	return nil
+}
+
+func (x *Event) GetHandQueue() []string {
+	if x != nil {
+		return x.HandQueue
+	}
+	return nil
+}
+
+func (x *Event) GetEmoji() string {
+	if x != nil && x.Emoji != nil {
+		return *x.Emoji
+	}
+	return ""
+}
+
+func (x *Event) GetBreakouts() []string {
+	if x != nil {
+		return x.Breakouts
+	}
+	return nil
+}
+
+func (x *Event) GetRoomID() string {
+	if x != nil && x.RoomID != nil {
+		return *x.RoomID
+	}
+	return ""
+}
+
+func (x *Event) GetMessage() string {
+	if x != nil && x.Message != nil {
+		return *x.Message
+	}
+	return ""
+}
+
+func (x *Event) GetEndsAt() int64 {
+	if x != nil && x.EndsAt != nil {
+		return *x.EndsAt
+	}
+	return 0
+}
+
+func (x *Event) GetCaption() *Caption {
+	if x != nil {
+		return x.Caption
+	}
+	return nil
+}
+
+func (x *Event) GetKeyEpoch() uint64 {
+	if x != nil && x.KeyEpoch != nil {
+		return *x.KeyEpoch
+	}
+	return 0
+}
+
+// One utterance of a speaker, recognized by the speech to text engine
+type Caption struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PeerID        string                 `protobuf:"bytes,1,opt,name=peerID,proto3" json:"peerID,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	StartAt       int64                  `protobuf:"varint,3,opt,name=startAt,proto3" json:"startAt,omitempty"` // unix ms
+	EndAt         int64                  `protobuf:"varint,4,opt,name=endAt,proto3" json:"endAt,omitempty"`     // unix ms
+	Text          string                 `protobuf:"bytes,5,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Caption) Reset() {
+	*x = Caption{}
+	mi := &file_sfu_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Caption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Caption) ProtoMessage() {}
+
+func (x *Caption) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Caption.ProtoReflect.Descriptor instead.
+func (*Caption) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Caption) GetPeerID() string {
+	if x != nil {
+		return x.PeerID
+	}
+	return ""
+}
+
+func (x *Caption) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Caption) GetStartAt() int64 {
+	if x != nil {
+		return x.StartAt
+	}
+	return 0
+}
+
+func (x *Caption) GetEndAt() int64 {
+	if x != nil {
+		return x.EndAt
+	}
+	return 0
+}
+
+func (x *Caption) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// Peer as seen by the other room members
+type PeerState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PeerID        string                 `protobuf:"bytes,1,opt,name=peerID,proto3" json:"peerID,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Role          RoleType               `protobuf:"varint,3,opt,name=role,proto3,enum=SFU.RoleType" json:"role,omitempty"`
+	Audio         bool                   `protobuf:"varint,4,opt,name=audio,proto3" json:"audio,omitempty"`
+	Video         bool                   `protobuf:"varint,5,opt,name=video,proto3" json:"video,omitempty"`
+	ScreenShare   bool                   `protobuf:"varint,6,opt,name=screenShare,proto3" json:"screenShare,omitempty"`
+	HandRaised    bool                   `protobuf:"varint,7,opt,name=handRaised,proto3" json:"handRaised,omitempty"`
+	JoinedAt      int64                  `protobuf:"varint,8,opt,name=joinedAt,proto3" json:"joinedAt,omitempty"` // unix ms
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerState) Reset() {
+	*x = PeerState{}
+	mi := &file_sfu_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerState) ProtoMessage() {}
+
+func (x *PeerState) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerState.ProtoReflect.Descriptor instead.
+func (*PeerState) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PeerState) GetPeerID() string {
+	if x != nil {
+		return x.PeerID
+	}
+	return ""
+}
+
+func (x *PeerState) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PeerState) GetRole() RoleType {
+	if x != nil {
+		return x.Role
+	}
+	return RoleType_ROLE_UNSPECIFIED
+}
+
+func (x *PeerState) GetAudio() bool {
+	if x != nil {
+		return x.Audio
+	}
+	return false
+}
+
+func (x *PeerState) GetVideo() bool {
+	if x != nil {
+		return x.Video
+	}
+	return false
+}
+
+func (x *PeerState) GetScreenShare() bool {
+	if x != nil {
+		return x.ScreenShare
+	}
+	return false
+}
+
+func (x *PeerState) GetHandRaised() bool {
+	if x != nil {
+		return x.HandRaised
+	}
+	return false
+}
+
+func (x *PeerState) GetJoinedAt() int64 {
+	if x != nil {
+		return x.JoinedAt
+	}
+	return 0
+}
+
+type RoomState struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Peers []*PeerState           `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	// frames are encrypted by the clients, keys go through KeyExchange
+	Encrypted     bool   `protobuf:"varint,2,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	KeyEpoch      uint64 `protobuf:"varint,3,opt,name=keyEpoch,proto3" json:"keyEpoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomState) Reset() {
+	*x = RoomState{}
+	mi := &file_sfu_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomState) ProtoMessage() {}
+
+func (x *RoomState) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomState.ProtoReflect.Descriptor instead.
+func (*RoomState) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RoomState) GetPeers() []*PeerState {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *RoomState) GetEncrypted() bool {
+	if x != nil {
+		return x.Encrypted
+	}
+	return false
+}
+
+func (x *RoomState) GetKeyEpoch() uint64 {
+	if x != nil {
+		return x.KeyEpoch
+	}
+	return 0
+}
+
+// Opaque key blob relayed by the SFU between the peers of an end-to-end
+// encrypted room, it is never read on the way
+type KeyExchange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  KeyType                `protobuf:"varint,1,opt,name=type,proto3,enum=SFU.KeyType" json:"type,omitempty"`
+	// client request ID, echoed in the Ack or Error
+	RequestID     string  `protobuf:"bytes,2,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	FromID        string  `protobuf:"bytes,3,opt,name=fromID,proto3" json:"fromID,omitempty"`      // set by the SFU
+	ToID          *string `protobuf:"bytes,4,opt,name=toID,proto3,oneof" json:"toID,omitempty"`    // required for SENDER_KEY
+	Blob          string  `protobuf:"bytes,5,opt,name=blob,proto3" json:"blob,omitempty"`          // base64
+	Epoch         *uint64 `protobuf:"varint,6,opt,name=epoch,proto3,oneof" json:"epoch,omitempty"` // key epoch of a SENDER_KEY
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyExchange) Reset() {
+	*x = KeyExchange{}
+	mi := &file_sfu_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyExchange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyExchange) ProtoMessage() {}
+
+func (x *KeyExchange) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyExchange.ProtoReflect.Descriptor instead.
+func (*KeyExchange) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *KeyExchange) GetType() KeyType {
+	if x != nil {
+		return x.Type
+	}
+	return KeyType_PUBLIC_KEY
+}
+
+func (x *KeyExchange) GetRequestID() string {
+	if x != nil {
+		return x.RequestID
+	}
+	return ""
+}
+
+func (x *KeyExchange) GetFromID() string {
+	if x != nil {
+		return x.FromID
+	}
+	return ""
+}
+
+func (x *KeyExchange) GetToID() string {
+	if x != nil && x.ToID != nil {
+		return *x.ToID
+	}
+	return ""
+}
+
+func (x *KeyExchange) GetBlob() string {
+	if x != nil {
+		return x.Blob
+	}
+	return ""
+}
+
+func (x *KeyExchange) GetEpoch() uint64 {
+	if x != nil && x.Epoch != nil {
+		return *x.Epoch
+	}
+	return 0
+}
+
+// Connection quality of one forwarded track
+type TrackQuality struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PeerID        string                 `protobuf:"bytes,1,opt,name=peerID,proto3" json:"peerID,omitempty"`                             // publisher of the track
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`                                 // audio or video
+	Pc            PcType                 `protobuf:"varint,3,opt,name=pc,proto3,enum=SFU.PcType" json:"pc,omitempty"`                    // PUB uplink, SUB downlink
+	PacketLoss    float64                `protobuf:"fixed64,4,opt,name=packet_loss,json=packetLoss,proto3" json:"packet_loss,omitempty"` // fraction lost, 0..1
+	JitterMs      float64                `protobuf:"fixed64,5,opt,name=jitter_ms,json=jitterMs,proto3" json:"jitter_ms,omitempty"`
+	RttMs         float64                `protobuf:"fixed64,6,opt,name=rtt_ms,json=rttMs,proto3" json:"rtt_ms,omitempty"`
+	BitrateBps    uint64                 `protobuf:"varint,7,opt,name=bitrate_bps,json=bitrateBps,proto3" json:"bitrate_bps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackQuality) Reset() {
+	*x = TrackQuality{}
+	mi := &file_sfu_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackQuality) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackQuality) ProtoMessage() {}
+
+func (x *TrackQuality) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackQuality.ProtoReflect.Descriptor instead.
+func (*TrackQuality) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TrackQuality) GetPeerID() string {
+	if x != nil {
+		return x.PeerID
+	}
+	return ""
+}
+
+func (x *TrackQuality) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *TrackQuality) GetPc() PcType {
+	if x != nil {
+		return x.Pc
+	}
+	return PcType_PC_UNSPECIFIED
+}
+
+func (x *TrackQuality) GetPacketLoss() float64 {
+	if x != nil {
+		return x.PacketLoss
+	}
+	return 0
+}
+
+func (x *TrackQuality) GetJitterMs() float64 {
+	if x != nil {
+		return x.JitterMs
+	}
+	return 0
+}
+
+func (x *TrackQuality) GetRttMs() float64 {
+	if x != nil {
+		return x.RttMs
+	}
+	return 0
+}
+
+func (x *TrackQuality) GetBitrateBps() uint64 {
+	if x != nil {
+		return x.BitrateBps
+	}
+	return 0
+}
+
+// Connection quality of a peer, score 0 unknown, 1 poor, 2 good, 3 excellent
+type Quality struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PeerID        string                 `protobuf:"bytes,1,opt,name=peerID,proto3" json:"peerID,omitempty"`
+	Score         uint32                 `protobuf:"varint,2,opt,name=score,proto3" json:"score,omitempty"`
+	Tracks        []*TrackQuality        `protobuf:"bytes,3,rep,name=tracks,proto3" json:"tracks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quality) Reset() {
+	*x = Quality{}
+	mi := &file_sfu_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quality) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quality) ProtoMessage() {}
+
+func (x *Quality) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quality.ProtoReflect.Descriptor instead.
+func (*Quality) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Quality) GetPeerID() string {
+	if x != nil {
+		return x.PeerID
+	}
+	return ""
+}
+
+func (x *Quality) GetScore() uint32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Quality) GetTracks() []*TrackQuality {
+	if x != nil {
+		return x.Tracks
+	}
+	return nil
+}
+
+type EgressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomID        string                 `protobuf:"bytes,1,opt,name=roomID,proto3" json:"roomID,omitempty"`
+	Layout        EgressLayout           `protobuf:"varint,2,opt,name=layout,proto3,enum=SFU.EgressLayout" json:"layout,omitempty"`
+	TargetID      string                 `protobuf:"bytes,3,opt,name=targetID,proto3" json:"targetID,omitempty"`
+	RtmpURL       string                 `protobuf:"bytes,4,opt,name=rtmpURL,proto3" json:"rtmpURL,omitempty"` // optional push next to HLS
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EgressRequest) Reset() {
+	*x = EgressRequest{}
+	mi := &file_sfu_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EgressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EgressRequest) ProtoMessage() {}
+
+func (x *EgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EgressRequest.ProtoReflect.Descriptor instead.
+func (*EgressRequest) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EgressRequest) GetRoomID() string {
+	if x != nil {
+		return x.RoomID
+	}
+	return ""
+}
+
+func (x *EgressRequest) GetLayout() EgressLayout {
+	if x != nil {
+		return x.Layout
+	}
+	return EgressLayout_GRID
+}
+
+func (x *EgressRequest) GetTargetID() string {
+	if x != nil {
+		return x.TargetID
+	}
+	return ""
+}
+
+func (x *EgressRequest) GetRtmpURL() string {
+	if x != nil {
+		return x.RtmpURL
+	}
+	return ""
+}
+
+type EgressInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomID        string                 `protobuf:"bytes,1,opt,name=roomID,proto3" json:"roomID,omitempty"`
+	Layout        EgressLayout           `protobuf:"varint,2,opt,name=layout,proto3,enum=SFU.EgressLayout" json:"layout,omitempty"`
+	Active        bool                   `protobuf:"varint,3,opt,name=active,proto3" json:"active,omitempty"`
+	Playlist      string                 `protobuf:"bytes,4,opt,name=playlist,proto3" json:"playlist,omitempty"`    // HLS playlist path on the SFU host
+	StartedAt     int64                  `protobuf:"varint,5,opt,name=startedAt,proto3" json:"startedAt,omitempty"` // unix ms
+	Peers         []string               `protobuf:"bytes,6,rep,name=peers,proto3" json:"peers,omitempty"`          // publishers in the picture
+	Rtmp          bool                   `protobuf:"varint,7,opt,name=rtmp,proto3" json:"rtmp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EgressInfo) Reset() {
+	*x = EgressInfo{}
+	mi := &file_sfu_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EgressInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EgressInfo) ProtoMessage() {}
+
+func (x *EgressInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EgressInfo.ProtoReflect.Descriptor instead.
+func (*EgressInfo) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EgressInfo) GetRoomID() string {
+	if x != nil {
+		return x.RoomID
+	}
+	return ""
+}
+
+func (x *EgressInfo) GetLayout() EgressLayout {
+	if x != nil {
+		return x.Layout
+	}
+	return EgressLayout_GRID
+}
+
+func (x *EgressInfo) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *EgressInfo) GetPlaylist() string {
+	if x != nil {
+		return x.Playlist
+	}
+	return ""
+}
+
+func (x *EgressInfo) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *EgressInfo) GetPeers() []string {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *EgressInfo) GetRtmp() bool {
+	if x != nil {
+		return x.Rtmp
+	}
+	return false
+}
+
+// WHIP offer of the peer in the call metadata, answered in one round trip
+type WhipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sdp           string                 `protobuf:"bytes,1,opt,name=sdp,proto3" json:"sdp,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"` // display name, the token's when empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhipRequest) Reset() {
+	*x = WhipRequest{}
+	mi := &file_sfu_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhipRequest) ProtoMessage() {}
+
+func (x *WhipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhipRequest.ProtoReflect.Descriptor instead.
+func (*WhipRequest) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WhipRequest) GetSdp() string {
+	if x != nil {
+		return x.Sdp
+	}
+	return ""
+}
+
+func (x *WhipRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type WhipResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sdp           string                 `protobuf:"bytes,1,opt,name=sdp,proto3" json:"sdp,omitempty"`
+	SessionID     string                 `protobuf:"bytes,2,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhipResponse) Reset() {
+	*x = WhipResponse{}
+	mi := &file_sfu_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhipResponse) ProtoMessage() {}
+
+func (x *WhipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhipResponse.ProtoReflect.Descriptor instead.
+func (*WhipResponse) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WhipResponse) GetSdp() string {
+	if x != nil {
+		return x.Sdp
+	}
+	return ""
+}
+
+func (x *WhipResponse) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+// WHEP offer of a viewer, the offered receive transceivers carry the
+// participants
+type WhepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sdp           string                 `protobuf:"bytes,1,opt,name=sdp,proto3" json:"sdp,omitempty"`
+	PeerID        string                 `protobuf:"bytes,2,opt,name=peerID,proto3" json:"peerID,omitempty"` // one participant only when set
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhepRequest) Reset() {
+	*x = WhepRequest{}
+	mi := &file_sfu_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhepRequest) ProtoMessage() {}
+
+func (x *WhepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhepRequest.ProtoReflect.Descriptor instead.
+func (*WhepRequest) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WhepRequest) GetSdp() string {
+	if x != nil {
+		return x.Sdp
+	}
+	return ""
+}
+
+func (x *WhepRequest) GetPeerID() string {
+	if x != nil {
+		return x.PeerID
+	}
+	return ""
+}
+
+type WhepResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sdp           string                 `protobuf:"bytes,1,opt,name=sdp,proto3" json:"sdp,omitempty"`
+	SessionID     string                 `protobuf:"bytes,2,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+	Secret        string                 `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"` // ends the session, the view token is shared
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhepResponse) Reset() {
+	*x = WhepResponse{}
+	mi := &file_sfu_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhepResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhepResponse) ProtoMessage() {}
+
+func (x *WhepResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhepResponse.ProtoReflect.Descriptor instead.
+func (*WhepResponse) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WhepResponse) GetSdp() string {
+	if x != nil {
+		return x.Sdp
+	}
+	return ""
+}
+
+func (x *WhepResponse) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+func (x *WhepResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+// end of an HTTP driven session, by its peer or a host
+type SessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionID     string                 `protobuf:"bytes,1,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+	Secret        string                 `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"` // of a view session, from its WhepResponse
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionRequest) Reset() {
+	*x = SessionRequest{}
+	mi := &file_sfu_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionRequest) ProtoMessage() {}
+
+func (x *SessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionRequest.ProtoReflect.Descriptor instead.
+func (*SessionRequest) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SessionRequest) GetSessionID() string {
+	if x != nil {
+		return x.SessionID
+	}
+	return ""
+}
+
+func (x *SessionRequest) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type SessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionResponse) Reset() {
+	*x = SessionResponse{}
+	mi := &file_sfu_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionResponse) ProtoMessage() {}
+
+func (x *SessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionResponse.ProtoReflect.Descriptor instead.
+func (*SessionResponse) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{17}
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomID        string                 `protobuf:"bytes,1,opt,name=roomID,proto3" json:"roomID,omitempty"`
+	PeerID        string                 `protobuf:"bytes,2,opt,name=peerID,proto3" json:"peerID,omitempty"` // empty for every peer of the room
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_sfu_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *StatsRequest) GetRoomID() string {
+	if x != nil {
+		return x.RoomID
+	}
+	return ""
+}
+
+func (x *StatsRequest) GetPeerID() string {
+	if x != nil {
+		return x.PeerID
+	}
+	return ""
+}
+
+type StatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peers         []*Quality             `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_sfu_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StatsResponse) GetPeers() []*Quality {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+// Admin view of the rooms of the SFU
+type ListRoomsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoomsRequest) Reset() {
+	*x = ListRoomsRequest{}
+	mi := &file_sfu_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoomsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoomsRequest) ProtoMessage() {}
+
+func (x *ListRoomsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoomsRequest.ProtoReflect.Descriptor instead.
+func (*ListRoomsRequest) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{20}
+}
+
+type ListRoomsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rooms         []*RoomInfo            `protobuf:"bytes,1,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoomsResponse) Reset() {
+	*x = ListRoomsResponse{}
+	mi := &file_sfu_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoomsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoomsResponse) ProtoMessage() {}
+
+func (x *ListRoomsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoomsResponse.ProtoReflect.Descriptor instead.
+func (*ListRoomsResponse) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListRoomsResponse) GetRooms() []*RoomInfo {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+type RoomInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomID        string                 `protobuf:"bytes,1,opt,name=roomID,proto3" json:"roomID,omitempty"`
+	Live          bool                   `protobuf:"varint,2,opt,name=live,proto3" json:"live,omitempty"`
+	Encrypted     bool                   `protobuf:"varint,3,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	Peers         []*PeerInfo            `protobuf:"bytes,4,rep,name=peers,proto3" json:"peers,omitempty"`
+	Viewers       uint32                 `protobuf:"varint,5,opt,name=viewers,proto3" json:"viewers,omitempty"` // WHEP sessions
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomInfo) Reset() {
+	*x = RoomInfo{}
+	mi := &file_sfu_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomInfo) ProtoMessage() {}
+
+func (x *RoomInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomInfo.ProtoReflect.Descriptor instead.
+func (*RoomInfo) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RoomInfo) GetRoomID() string {
+	if x != nil {
+		return x.RoomID
+	}
+	return ""
+}
+
+func (x *RoomInfo) GetLive() bool {
+	if x != nil {
+		return x.Live
+	}
+	return false
+}
+
+func (x *RoomInfo) GetEncrypted() bool {
+	if x != nil {
+		return x.Encrypted
+	}
+	return false
+}
+
+func (x *RoomInfo) GetPeers() []*PeerInfo {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *RoomInfo) GetViewers() uint32 {
+	if x != nil {
+		return x.Viewers
+	}
+	return 0
+}
+
+type PeerInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	State *PeerState             `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	// WebRTC connection states, e.g. connected
+	PubState      string       `protobuf:"bytes,2,opt,name=pubState,proto3" json:"pubState,omitempty"`
+	SubState      string       `protobuf:"bytes,3,opt,name=subState,proto3" json:"subState,omitempty"`
+	Tracks        []*TrackInfo `protobuf:"bytes,4,rep,name=tracks,proto3" json:"tracks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerInfo) Reset() {
+	*x = PeerInfo{}
+	mi := &file_sfu_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerInfo) ProtoMessage() {}
+
+func (x *PeerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerInfo.ProtoReflect.Descriptor instead.
+func (*PeerInfo) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{23}
 }
 
-func (EventType) Type() protoreflect.EnumType {
-	return &file_sfu_proto_enumTypes[2]
+func (x *PeerInfo) GetState() *PeerState {
+	if x != nil {
+		return x.State
+	}
+	return nil
 }
 
-func (x EventType) Number() protoreflect.EnumNumber {
-	return protoreflect.EnumNumber(x)
+func (x *PeerInfo) GetPubState() string {
+	if x != nil {
+		return x.PubState
+	}
+	return ""
 }
 
-// Deprecated: Use EventType.Descriptor instead.
-func (EventType) EnumDescriptor() ([]byte, []int) {
-	return file_sfu_proto_rawDescGZIP(), []int{2}
+func (x *PeerInfo) GetSubState() string {
+	if x != nil {
+		return x.SubState
+	}
+	return ""
 }
 
-// Peer Connection Type
-type PcType int32
-
-const (
-	PcType_PC_UNSPECIFIED PcType = 0
-	PcType_PUB            PcType = 1
-	PcType_SUB            PcType = 2
-)
-
-// Enum value maps for PcType.
-var (
-	PcType_name = map[int32]string{
-		0: "PC_UNSPECIFIED",
-		1: "PUB",
-		2: "SUB",
-	}
-	PcType_value = map[string]int32{
-		"PC_UNSPECIFIED": 0,
-		"PUB":            1,
-		"SUB":            2,
+func (x *PeerInfo) GetTracks() []*TrackInfo {
+	if x != nil {
+		return x.Tracks
 	}
-)
-
-func (x PcType) Enum() *PcType {
-	p := new(PcType)
-	*p = x
-	return p
+	return nil
 }
 
-func (x PcType) String() string {
-	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+// track published by a peer
+type TrackInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`   // audio or video
+	Codec         string                 `protobuf:"bytes,2,opt,name=codec,proto3" json:"codec,omitempty"` // mime type, e.g. video/VP8
+	Ssrc          uint32                 `protobuf:"varint,3,opt,name=ssrc,proto3" json:"ssrc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (PcType) Descriptor() protoreflect.EnumDescriptor {
-	return file_sfu_proto_enumTypes[3].Descriptor()
+func (x *TrackInfo) Reset() {
+	*x = TrackInfo{}
+	mi := &file_sfu_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (PcType) Type() protoreflect.EnumType {
-	return &file_sfu_proto_enumTypes[3]
+func (x *TrackInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x PcType) Number() protoreflect.EnumNumber {
-	return protoreflect.EnumNumber(x)
-}
+func (*TrackInfo) ProtoMessage() {}
 
-// Deprecated: Use PcType.Descriptor instead.
-func (PcType) EnumDescriptor() ([]byte, []int) {
-	return file_sfu_proto_rawDescGZIP(), []int{3}
+func (x *TrackInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-// Role type
-type RoleType int32
+// Deprecated: Use TrackInfo.ProtoReflect.Descriptor instead.
+func (*TrackInfo) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{24}
+}
 
-const (
-	RoleType_ROLE_UNSPECIFIED RoleType = 0
-	RoleType_ROLE_HOST        RoleType = 1
-	RoleType_ROLE_GUEST       RoleType = 2
-	RoleType_ROLE_BOT         RoleType = 3
-)
+func (x *TrackInfo) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
 
-// Enum value maps for RoleType.
-var (
-	RoleType_name = map[int32]string{
-		0: "ROLE_UNSPECIFIED",
-		1: "ROLE_HOST",
-		2: "ROLE_GUEST",
-		3: "ROLE_BOT",
+func (x *TrackInfo) GetCodec() string {
+	if x != nil {
+		return x.Codec
 	}
-	RoleType_value = map[string]int32{
-		"ROLE_UNSPECIFIED": 0,
-		"ROLE_HOST":        1,
-		"ROLE_GUEST":       2,
-		"ROLE_BOT":         3,
+	return ""
+}
+
+func (x *TrackInfo) GetSsrc() uint32 {
+	if x != nil {
+		return x.Ssrc
 	}
-)
+	return 0
+}
 
-func (x RoleType) Enum() *RoleType {
-	p := new(RoleType)
-	*p = x
-	return p
+// end a room and disconnect its peers
+type CloseRoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomID        string                 `protobuf:"bytes,1,opt,name=roomID,proto3" json:"roomID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x RoleType) String() string {
-	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+func (x *CloseRoomRequest) Reset() {
+	*x = CloseRoomRequest{}
+	mi := &file_sfu_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (RoleType) Descriptor() protoreflect.EnumDescriptor {
-	return file_sfu_proto_enumTypes[4].Descriptor()
+func (x *CloseRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (RoleType) Type() protoreflect.EnumType {
-	return &file_sfu_proto_enumTypes[4]
+func (*CloseRoomRequest) ProtoMessage() {}
+
+func (x *CloseRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (x RoleType) Number() protoreflect.EnumNumber {
-	return protoreflect.EnumNumber(x)
+// Deprecated: Use CloseRoomRequest.ProtoReflect.Descriptor instead.
+func (*CloseRoomRequest) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{25}
 }
 
-// Deprecated: Use RoleType.Descriptor instead.
-func (RoleType) EnumDescriptor() ([]byte, []int) {
-	return file_sfu_proto_rawDescGZIP(), []int{4}
+func (x *CloseRoomRequest) GetRoomID() string {
+	if x != nil {
+		return x.RoomID
+	}
+	return ""
 }
 
-type Action struct {
+type CloseRoomResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          ActionType             `protobuf:"varint,1,opt,name=type,proto3,enum=SFU.ActionType" json:"type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Action) Reset() {
-	*x = Action{}
-	mi := &file_sfu_proto_msgTypes[0]
+func (x *CloseRoomResponse) Reset() {
+	*x = CloseRoomResponse{}
+	mi := &file_sfu_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Action) String() string {
+func (x *CloseRoomResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Action) ProtoMessage() {}
+func (*CloseRoomResponse) ProtoMessage() {}
 
-func (x *Action) ProtoReflect() protoreflect.Message {
-	mi := &file_sfu_proto_msgTypes[0]
+func (x *CloseRoomResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -340,42 +2496,35 @@ func (x *Action) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Action.ProtoReflect.Descriptor instead.
-func (*Action) Descriptor() ([]byte, []int) {
-	return file_sfu_proto_rawDescGZIP(), []int{0}
-}
-
-func (x *Action) GetType() ActionType {
-	if x != nil {
-		return x.Type
-	}
-	return ActionType_START_ROOM
+// Deprecated: Use CloseRoomResponse.ProtoReflect.Descriptor instead.
+func (*CloseRoomResponse) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{26}
 }
 
-type Event struct {
+// take a member out of its room, its stream or session is ended
+type KickPeerRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=SFU.EventType" json:"type,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	PeerID        string                 `protobuf:"bytes,3,opt,name=peerID,proto3" json:"peerID,omitempty"`
+	RoomID        string                 `protobuf:"bytes,1,opt,name=roomID,proto3" json:"roomID,omitempty"`
+	PeerID        string                 `protobuf:"bytes,2,opt,name=peerID,proto3" json:"peerID,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event) Reset() {
-	*x = Event{}
-	mi := &file_sfu_proto_msgTypes[1]
+func (x *KickPeerRequest) Reset() {
+	*x = KickPeerRequest{}
+	mi := &file_sfu_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event) String() string {
+func (x *KickPeerRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event) ProtoMessage() {}
+func (*KickPeerRequest) ProtoMessage() {}
 
-func (x *Event) ProtoReflect() protoreflect.Message {
-	mi := &file_sfu_proto_msgTypes[1]
+func (x *KickPeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -386,30 +2535,59 @@ func (x *Event) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event.ProtoReflect.Descriptor instead.
-func (*Event) Descriptor() ([]byte, []int) {
-	return file_sfu_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use KickPeerRequest.ProtoReflect.Descriptor instead.
+func (*KickPeerRequest) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *Event) GetType() EventType {
+func (x *KickPeerRequest) GetRoomID() string {
 	if x != nil {
-		return x.Type
+		return x.RoomID
 	}
-	return EventType_ROOM_ACTIVE
+	return ""
 }
 
-func (x *Event) GetName() string {
+func (x *KickPeerRequest) GetPeerID() string {
 	if x != nil {
-		return x.Name
+		return x.PeerID
 	}
 	return ""
 }
 
-func (x *Event) GetPeerID() string {
+type KickPeerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KickPeerResponse) Reset() {
+	*x = KickPeerResponse{}
+	mi := &file_sfu_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KickPeerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickPeerResponse) ProtoMessage() {}
+
+func (x *KickPeerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sfu_proto_msgTypes[28]
 	if x != nil {
-		return x.PeerID
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickPeerResponse.ProtoReflect.Descriptor instead.
+func (*KickPeerResponse) Descriptor() ([]byte, []int) {
+	return file_sfu_proto_rawDescGZIP(), []int{28}
 }
 
 // Session Description (SDP)
@@ -424,7 +2602,7 @@ type Sdp struct {
 
 func (x *Sdp) Reset() {
 	*x = Sdp{}
-	mi := &file_sfu_proto_msgTypes[2]
+	mi := &file_sfu_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -436,7 +2614,7 @@ func (x *Sdp) String() string {
 func (*Sdp) ProtoMessage() {}
 
 func (x *Sdp) ProtoReflect() protoreflect.Message {
-	mi := &file_sfu_proto_msgTypes[2]
+	mi := &file_sfu_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -449,7 +2627,7 @@ func (x *Sdp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Sdp.ProtoReflect.Descriptor instead.
 func (*Sdp) Descriptor() ([]byte, []int) {
-	return file_sfu_proto_rawDescGZIP(), []int{2}
+	return file_sfu_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *Sdp) GetPc() PcType {
@@ -479,7 +2657,7 @@ type IceCandidate struct {
 	Pc               PcType                 `protobuf:"varint,1,opt,name=pc,proto3,enum=SFU.PcType" json:"pc,omitempty"`
 	Candidate        string                 `protobuf:"bytes,2,opt,name=candidate,proto3" json:"candidate,omitempty"`
 	SdpMid           string                 `protobuf:"bytes,3,opt,name=sdp_mid,json=sdpMid,proto3" json:"sdp_mid,omitempty"`
-	SdpMlineIndex    uint32                 `protobuf:"varint,4,opt,name=sdp_mline_index,json=sdpMlineIndex,proto3" json:"sdp_mline_index,omitempty"`
+	SdpMlineIndex    uint32                 `protobuf:"varint,4,opt,name=sdp_mline_index,json=sdpMLineIndex,proto3" json:"sdp_mline_index,omitempty"`
 	UsernameFragment string                 `protobuf:"bytes,5,opt,name=username_fragment,json=usernameFragment,proto3" json:"username_fragment,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
@@ -487,7 +2665,7 @@ type IceCandidate struct {
 
 func (x *IceCandidate) Reset() {
 	*x = IceCandidate{}
-	mi := &file_sfu_proto_msgTypes[3]
+	mi := &file_sfu_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -499,7 +2677,7 @@ func (x *IceCandidate) String() string {
 func (*IceCandidate) ProtoMessage() {}
 
 func (x *IceCandidate) ProtoReflect() protoreflect.Message {
-	mi := &file_sfu_proto_msgTypes[3]
+	mi := &file_sfu_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -512,7 +2690,7 @@ func (x *IceCandidate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IceCandidate.ProtoReflect.Descriptor instead.
 func (*IceCandidate) Descriptor() ([]byte, []int) {
-	return file_sfu_proto_rawDescGZIP(), []int{3}
+	return file_sfu_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *IceCandidate) GetPc() PcType {
@@ -558,6 +2736,9 @@ type PeerSignal struct {
 	//	*PeerSignal_Ice
 	//	*PeerSignal_Action
 	//	*PeerSignal_Event
+	//	*PeerSignal_Ack
+	//	*PeerSignal_Error
+	//	*PeerSignal_Key
 	Payload       isPeerSignal_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -565,7 +2746,7 @@ type PeerSignal struct {
 
 func (x *PeerSignal) Reset() {
 	*x = PeerSignal{}
-	mi := &file_sfu_proto_msgTypes[4]
+	mi := &file_sfu_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -577,7 +2758,7 @@ func (x *PeerSignal) String() string {
 func (*PeerSignal) ProtoMessage() {}
 
 func (x *PeerSignal) ProtoReflect() protoreflect.Message {
-	mi := &file_sfu_proto_msgTypes[4]
+	mi := &file_sfu_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -590,7 +2771,7 @@ func (x *PeerSignal) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeerSignal.ProtoReflect.Descriptor instead.
 func (*PeerSignal) Descriptor() ([]byte, []int) {
-	return file_sfu_proto_rawDescGZIP(), []int{4}
+	return file_sfu_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *PeerSignal) GetPayload() isPeerSignal_Payload {
@@ -636,6 +2817,33 @@ func (x *PeerSignal) GetEvent() *Event {
 	return nil
 }
 
+func (x *PeerSignal) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Payload.(*PeerSignal_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *PeerSignal) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Payload.(*PeerSignal_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *PeerSignal) GetKey() *KeyExchange {
+	if x != nil {
+		if x, ok := x.Payload.(*PeerSignal_Key); ok {
+			return x.Key
+		}
+	}
+	return nil
+}
+
 type isPeerSignal_Payload interface {
 	isPeerSignal_Payload()
 }
@@ -656,6 +2864,18 @@ type PeerSignal_Event struct {
 	Event *Event `protobuf:"bytes,4,opt,name=event,proto3,oneof"`
 }
 
+type PeerSignal_Ack struct {
+	Ack *Ack `protobuf:"bytes,5,opt,name=ack,proto3,oneof"`
+}
+
+type PeerSignal_Error struct {
+	Error *Error `protobuf:"bytes,6,opt,name=error,proto3,oneof"`
+}
+
+type PeerSignal_Key struct {
+	Key *KeyExchange `protobuf:"bytes,7,opt,name=key,proto3,oneof"`
+}
+
 func (*PeerSignal_Sdp) isPeerSignal_Payload() {}
 
 func (*PeerSignal_Ice) isPeerSignal_Payload() {}
@@ -664,17 +2884,178 @@ func (*PeerSignal_Action) isPeerSignal_Payload() {}
 
 func (*PeerSignal_Event) isPeerSignal_Payload() {}
 
+func (*PeerSignal_Ack) isPeerSignal_Payload() {}
+
+func (*PeerSignal_Error) isPeerSignal_Payload() {}
+
+func (*PeerSignal_Key) isPeerSignal_Payload() {}
+
 var File_sfu_proto protoreflect.FileDescriptor
 
 const file_sfu_proto_rawDesc = "" +
 	"\n" +
-	"\tsfu.proto\x12\x03SFU\"-\n" +
+	"\tsfu.proto\x12\x03SFU\"\x9a\x04\n" +
 	"\x06Action\x12#\n" +
-	"\x04type\x18\x01 \x01(\x0e2\x0f.SFU.ActionTypeR\x04type\"W\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x0f.SFU.ActionTypeR\x04type\x12\x1f\n" +
+	"\btargetID\x18\x02 \x01(\tH\x00R\btargetID\x88\x01\x01\x120\n" +
+	"\bmaxLayer\x18\x03 \x01(\x0e2\x0f.SFU.VideoLayerH\x01R\bmaxLayer\x88\x01\x01\x12\x1c\n" +
+	"\trequestID\x18\x04 \x01(\tR\trequestID\x12\x19\n" +
+	"\x05emoji\x18\x05 \x01(\tH\x02R\x05emoji\x88\x01\x01\x12\x19\n" +
+	"\x05count\x18\x06 \x01(\rH\x03R\x05count\x88\x01\x01\x12%\n" +
+	"\vdurationSec\x18\a \x01(\rH\x04R\vdurationSec\x88\x01\x01\x12\x1b\n" +
+	"\x06random\x18\b \x01(\bH\x05R\x06random\x88\x01\x01\x12\x1b\n" +
+	"\x06roomID\x18\t \x01(\tH\x06R\x06roomID\x88\x01\x01\x12\x1d\n" +
+	"\amessage\x18\n" +
+	" \x01(\tH\aR\amessage\x88\x01\x01\x12.\n" +
+	"\x06layout\x18\v \x01(\x0e2\x11.SFU.EgressLayoutH\bR\x06layout\x88\x01\x01\x12\x1d\n" +
+	"\artmpURL\x18\f \x01(\tH\tR\artmpURL\x88\x01\x01B\v\n" +
+	"\t_targetIDB\v\n" +
+	"\t_maxLayerB\b\n" +
+	"\x06_emojiB\b\n" +
+	"\x06_countB\x0e\n" +
+	"\f_durationSecB\t\n" +
+	"\a_randomB\t\n" +
+	"\a_roomIDB\n" +
+	"\n" +
+	"\b_messageB\t\n" +
+	"\a_layoutB\n" +
+	"\n" +
+	"\b_rtmpURL\"#\n" +
+	"\x03Ack\x12\x1c\n" +
+	"\trequestID\x18\x01 \x01(\tR\trequestID\"c\n" +
+	"\x05Error\x12\x1c\n" +
+	"\trequestID\x18\x01 \x01(\tR\trequestID\x12\"\n" +
+	"\x04code\x18\x02 \x01(\x0e2\x0e.SFU.ErrorCodeR\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xa5\x04\n" +
 	"\x05Event\x12\"\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x0e.SFU.EventTypeR\x04type\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
-	"\x06peerID\x18\x03 \x01(\tR\x06peerID\"V\n" +
+	"\x06peerID\x18\x03 \x01(\tR\x06peerID\x12&\n" +
+	"\aquality\x18\x04 \x01(\v2\f.SFU.QualityR\aquality\x12\x16\n" +
+	"\x06codecs\x18\x05 \x03(\tR\x06codecs\x12\x10\n" +
+	"\x03seq\x18\x06 \x01(\x04R\x03seq\x12$\n" +
+	"\x05state\x18\a \x01(\v2\x0e.SFU.RoomStateR\x05state\x12\"\n" +
+	"\x04peer\x18\b \x01(\v2\x0e.SFU.PeerStateR\x04peer\x12\x1c\n" +
+	"\thandQueue\x18\t \x03(\tR\thandQueue\x12\x19\n" +
+	"\x05emoji\x18\n" +
+	" \x01(\tH\x00R\x05emoji\x88\x01\x01\x12\x1c\n" +
+	"\tbreakouts\x18\v \x03(\tR\tbreakouts\x12\x1b\n" +
+	"\x06roomID\x18\f \x01(\tH\x01R\x06roomID\x88\x01\x01\x12\x1d\n" +
+	"\amessage\x18\r \x01(\tH\x02R\amessage\x88\x01\x01\x12\x1b\n" +
+	"\x06endsAt\x18\x0e \x01(\x03H\x03R\x06endsAt\x88\x01\x01\x12&\n" +
+	"\acaption\x18\x0f \x01(\v2\f.SFU.CaptionR\acaption\x12\x1f\n" +
+	"\bkeyEpoch\x18\x10 \x01(\x04H\x04R\bkeyEpoch\x88\x01\x01B\b\n" +
+	"\x06_emojiB\t\n" +
+	"\a_roomIDB\n" +
+	"\n" +
+	"\b_messageB\t\n" +
+	"\a_endsAtB\v\n" +
+	"\t_keyEpoch\"y\n" +
+	"\aCaption\x12\x16\n" +
+	"\x06peerID\x18\x01 \x01(\tR\x06peerID\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\astartAt\x18\x03 \x01(\x03R\astartAt\x12\x14\n" +
+	"\x05endAt\x18\x04 \x01(\x03R\x05endAt\x12\x12\n" +
+	"\x04text\x18\x05 \x01(\tR\x04text\"\xe4\x01\n" +
+	"\tPeerState\x12\x16\n" +
+	"\x06peerID\x18\x01 \x01(\tR\x06peerID\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\x04role\x18\x03 \x01(\x0e2\r.SFU.RoleTypeR\x04role\x12\x14\n" +
+	"\x05audio\x18\x04 \x01(\bR\x05audio\x12\x14\n" +
+	"\x05video\x18\x05 \x01(\bR\x05video\x12 \n" +
+	"\vscreenShare\x18\x06 \x01(\bR\vscreenShare\x12\x1e\n" +
+	"\n" +
+	"handRaised\x18\a \x01(\bR\n" +
+	"handRaised\x12\x1a\n" +
+	"\bjoinedAt\x18\b \x01(\x03R\bjoinedAt\"k\n" +
+	"\tRoomState\x12$\n" +
+	"\x05peers\x18\x01 \x03(\v2\x0e.SFU.PeerStateR\x05peers\x12\x1c\n" +
+	"\tencrypted\x18\x02 \x01(\bR\tencrypted\x12\x1a\n" +
+	"\bkeyEpoch\x18\x03 \x01(\x04R\bkeyEpoch\"\xc0\x01\n" +
+	"\vKeyExchange\x12 \n" +
+	"\x04type\x18\x01 \x01(\x0e2\f.SFU.KeyTypeR\x04type\x12\x1c\n" +
+	"\trequestID\x18\x02 \x01(\tR\trequestID\x12\x16\n" +
+	"\x06fromID\x18\x03 \x01(\tR\x06fromID\x12\x17\n" +
+	"\x04toID\x18\x04 \x01(\tH\x00R\x04toID\x88\x01\x01\x12\x12\n" +
+	"\x04blob\x18\x05 \x01(\tR\x04blob\x12\x19\n" +
+	"\x05epoch\x18\x06 \x01(\x04H\x01R\x05epoch\x88\x01\x01B\a\n" +
+	"\x05_toIDB\b\n" +
+	"\x06_epoch\"\xcd\x01\n" +
+	"\fTrackQuality\x12\x16\n" +
+	"\x06peerID\x18\x01 \x01(\tR\x06peerID\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x1b\n" +
+	"\x02pc\x18\x03 \x01(\x0e2\v.SFU.PcTypeR\x02pc\x12\x1f\n" +
+	"\vpacket_loss\x18\x04 \x01(\x01R\n" +
+	"packetLoss\x12\x1b\n" +
+	"\tjitter_ms\x18\x05 \x01(\x01R\bjitterMs\x12\x15\n" +
+	"\x06rtt_ms\x18\x06 \x01(\x01R\x05rttMs\x12\x1f\n" +
+	"\vbitrate_bps\x18\a \x01(\x04R\n" +
+	"bitrateBps\"b\n" +
+	"\aQuality\x12\x16\n" +
+	"\x06peerID\x18\x01 \x01(\tR\x06peerID\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\rR\x05score\x12)\n" +
+	"\x06tracks\x18\x03 \x03(\v2\x11.SFU.TrackQualityR\x06tracks\"\x88\x01\n" +
+	"\rEgressRequest\x12\x16\n" +
+	"\x06roomID\x18\x01 \x01(\tR\x06roomID\x12)\n" +
+	"\x06layout\x18\x02 \x01(\x0e2\x11.SFU.EgressLayoutR\x06layout\x12\x1a\n" +
+	"\btargetID\x18\x03 \x01(\tR\btargetID\x12\x18\n" +
+	"\artmpURL\x18\x04 \x01(\tR\artmpURL\"\xcb\x01\n" +
+	"\n" +
+	"EgressInfo\x12\x16\n" +
+	"\x06roomID\x18\x01 \x01(\tR\x06roomID\x12)\n" +
+	"\x06layout\x18\x02 \x01(\x0e2\x11.SFU.EgressLayoutR\x06layout\x12\x16\n" +
+	"\x06active\x18\x03 \x01(\bR\x06active\x12\x1a\n" +
+	"\bplaylist\x18\x04 \x01(\tR\bplaylist\x12\x1c\n" +
+	"\tstartedAt\x18\x05 \x01(\x03R\tstartedAt\x12\x14\n" +
+	"\x05peers\x18\x06 \x03(\tR\x05peers\x12\x12\n" +
+	"\x04rtmp\x18\a \x01(\bR\x04rtmp\"3\n" +
+	"\vWhipRequest\x12\x10\n" +
+	"\x03sdp\x18\x01 \x01(\tR\x03sdp\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\">\n" +
+	"\fWhipResponse\x12\x10\n" +
+	"\x03sdp\x18\x01 \x01(\tR\x03sdp\x12\x1c\n" +
+	"\tsessionID\x18\x02 \x01(\tR\tsessionID\"7\n" +
+	"\vWhepRequest\x12\x10\n" +
+	"\x03sdp\x18\x01 \x01(\tR\x03sdp\x12\x16\n" +
+	"\x06peerID\x18\x02 \x01(\tR\x06peerID\"V\n" +
+	"\fWhepResponse\x12\x10\n" +
+	"\x03sdp\x18\x01 \x01(\tR\x03sdp\x12\x1c\n" +
+	"\tsessionID\x18\x02 \x01(\tR\tsessionID\x12\x16\n" +
+	"\x06secret\x18\x03 \x01(\tR\x06secret\"F\n" +
+	"\x0eSessionRequest\x12\x1c\n" +
+	"\tsessionID\x18\x01 \x01(\tR\tsessionID\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"\x11\n" +
+	"\x0fSessionResponse\">\n" +
+	"\fStatsRequest\x12\x16\n" +
+	"\x06roomID\x18\x01 \x01(\tR\x06roomID\x12\x16\n" +
+	"\x06peerID\x18\x02 \x01(\tR\x06peerID\"3\n" +
+	"\rStatsResponse\x12\"\n" +
+	"\x05peers\x18\x01 \x03(\v2\f.SFU.QualityR\x05peers\"\x12\n" +
+	"\x10ListRoomsRequest\"8\n" +
+	"\x11ListRoomsResponse\x12#\n" +
+	"\x05rooms\x18\x01 \x03(\v2\r.SFU.RoomInfoR\x05rooms\"\x93\x01\n" +
+	"\bRoomInfo\x12\x16\n" +
+	"\x06roomID\x18\x01 \x01(\tR\x06roomID\x12\x12\n" +
+	"\x04live\x18\x02 \x01(\bR\x04live\x12\x1c\n" +
+	"\tencrypted\x18\x03 \x01(\bR\tencrypted\x12#\n" +
+	"\x05peers\x18\x04 \x03(\v2\r.SFU.PeerInfoR\x05peers\x12\x18\n" +
+	"\aviewers\x18\x05 \x01(\rR\aviewers\"\x90\x01\n" +
+	"\bPeerInfo\x12$\n" +
+	"\x05state\x18\x01 \x01(\v2\x0e.SFU.PeerStateR\x05state\x12\x1a\n" +
+	"\bpubState\x18\x02 \x01(\tR\bpubState\x12\x1a\n" +
+	"\bsubState\x18\x03 \x01(\tR\bsubState\x12&\n" +
+	"\x06tracks\x18\x04 \x03(\v2\x0e.SFU.TrackInfoR\x06tracks\"I\n" +
+	"\tTrackInfo\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05codec\x18\x02 \x01(\tR\x05codec\x12\x12\n" +
+	"\x04ssrc\x18\x03 \x01(\rR\x04ssrc\"*\n" +
+	"\x10CloseRoomRequest\x12\x16\n" +
+	"\x06roomID\x18\x01 \x01(\tR\x06roomID\"\x13\n" +
+	"\x11CloseRoomResponse\"A\n" +
+	"\x0fKickPeerRequest\x12\x16\n" +
+	"\x06roomID\x18\x01 \x01(\tR\x06roomID\x12\x16\n" +
+	"\x06peerID\x18\x02 \x01(\tR\x06peerID\"\x12\n" +
+	"\x10KickPeerResponse\"V\n" +
 	"\x03Sdp\x12\x1b\n" +
 	"\x02pc\x18\x01 \x01(\x0e2\v.SFU.PcTypeR\x02pc\x12 \n" +
 	"\x04type\x18\x02 \x01(\x0e2\f.SFU.SdpTypeR\x04type\x12\x10\n" +
@@ -683,20 +3064,24 @@ const file_sfu_proto_rawDesc = "" +
 	"\x02pc\x18\x01 \x01(\x0e2\v.SFU.PcTypeR\x02pc\x12\x1c\n" +
 	"\tcandidate\x18\x02 \x01(\tR\tcandidate\x12\x17\n" +
 	"\asdp_mid\x18\x03 \x01(\tR\x06sdpMid\x12&\n" +
-	"\x0fsdp_mline_index\x18\x04 \x01(\rR\rsdpMlineIndex\x12+\n" +
-	"\x11username_fragment\x18\x05 \x01(\tR\x10usernameFragment\"\xa7\x01\n" +
+	"\x0fsdp_mline_index\x18\x04 \x01(\rR\rsdpMLineIndex\x12+\n" +
+	"\x11username_fragment\x18\x05 \x01(\tR\x10usernameFragment\"\x8f\x02\n" +
 	"\n" +
 	"PeerSignal\x12\x1c\n" +
 	"\x03sdp\x18\x01 \x01(\v2\b.SFU.SdpH\x00R\x03sdp\x12%\n" +
 	"\x03ice\x18\x02 \x01(\v2\x11.SFU.IceCandidateH\x00R\x03ice\x12%\n" +
 	"\x06action\x18\x03 \x01(\v2\v.SFU.ActionH\x00R\x06action\x12\"\n" +
 	"\x05event\x18\x04 \x01(\v2\n" +
-	".SFU.EventH\x00R\x05eventB\t\n" +
+	".SFU.EventH\x00R\x05event\x12\x1c\n" +
+	"\x03ack\x18\x05 \x01(\v2\b.SFU.AckH\x00R\x03ack\x12\"\n" +
+	"\x05error\x18\x06 \x01(\v2\n" +
+	".SFU.ErrorH\x00R\x05error\x12$\n" +
+	"\x03key\x18\a \x01(\v2\x10.SFU.KeyExchangeH\x00R\x03keyB\t\n" +
 	"\apayload* \n" +
 	"\aSdpType\x12\t\n" +
 	"\x05OFFER\x10\x00\x12\n" +
 	"\n" +
-	"\x06ANSWER\x10\x01*\x9a\x01\n" +
+	"\x06ANSWER\x10\x01*\xa9\x04\n" +
 	"\n" +
 	"ActionType\x12\x0e\n" +
 	"\n" +
@@ -710,7 +3095,38 @@ const file_sfu_proto_rawDesc = "" +
 	"\tVIDEO_OFF\x10\a\x12\x0e\n" +
 	"\n" +
 	"DUBBING_ON\x10\b\x12\x0f\n" +
-	"\vDUBBING_OFF\x10\t*\xae\x01\n" +
+	"\vDUBBING_OFF\x10\t\x12\x0f\n" +
+	"\vPAUSE_VIDEO\x10\n" +
+	"\x12\x10\n" +
+	"\fRESUME_VIDEO\x10\v\x12\x11\n" +
+	"\rSET_MAX_LAYER\x10\f\x12\x11\n" +
+	"\rAUDIO_ONLY_ON\x10\r\x12\x12\n" +
+	"\x0eAUDIO_ONLY_OFF\x10\x0e\x12\x13\n" +
+	"\x0fSCREEN_SHARE_ON\x10\x0f\x12\x14\n" +
+	"\x10SCREEN_SHARE_OFF\x10\x10\x12\x0e\n" +
+	"\n" +
+	"SYNC_STATE\x10\x11\x12\x0e\n" +
+	"\n" +
+	"RAISE_HAND\x10\x12\x12\x0e\n" +
+	"\n" +
+	"LOWER_HAND\x10\x13\x12\t\n" +
+	"\x05REACT\x10\x14\x12\r\n" +
+	"\tCALL_NEXT\x10\x15\x12\x12\n" +
+	"\x0eGET_HAND_QUEUE\x10\x16\x12\x11\n" +
+	"\rBREAKOUT_OPEN\x10\x17\x12\x13\n" +
+	"\x0fBREAKOUT_ASSIGN\x10\x18\x12\x16\n" +
+	"\x12BREAKOUT_BROADCAST\x10\x19\x12\x13\n" +
+	"\x0fBREAKOUT_RECALL\x10\x1a\x12\x10\n" +
+	"\fEGRESS_START\x10\x1b\x12\x0f\n" +
+	"\vEGRESS_STOP\x10\x1c\x12\x17\n" +
+	"\x13TRANSCRIPTION_START\x10\x1d\x12\x16\n" +
+	"\x12TRANSCRIPTION_STOP\x10\x1e*,\n" +
+	"\n" +
+	"VideoLayer\x12\x0e\n" +
+	"\n" +
+	"LAYER_FULL\x10\x00\x12\x0e\n" +
+	"\n" +
+	"LAYER_BASE\x10\x01*\xec\x04\n" +
 	"\tEventType\x12\x0f\n" +
 	"\vROOM_ACTIVE\x10\x00\x12\x11\n" +
 	"\rROOM_INACTIVE\x10\x01\x12\x0e\n" +
@@ -722,19 +3138,78 @@ const file_sfu_proto_rawDesc = "" +
 	"\rAUDIO_ENABLED\x10\x05\x12\x12\n" +
 	"\x0eAUDIO_DISABLED\x10\x06\x12\x11\n" +
 	"\rVIDEO_ENABLED\x10\a\x12\x12\n" +
-	"\x0eVIDEO_DISABLED\x10\b*.\n" +
+	"\x0eVIDEO_DISABLED\x10\b\x12\x0f\n" +
+	"\vSUB_ENABLED\x10\t\x12\x10\n" +
+	"\fSUB_DISABLED\x10\n" +
+	"\x12\v\n" +
+	"\aQUALITY\x10\v\x12\x12\n" +
+	"\x0eCODEC_REJECTED\x10\f\x12\x0e\n" +
+	"\n" +
+	"ROOM_STATE\x10\r\x12\x18\n" +
+	"\x14SCREEN_SHARE_STARTED\x10\x0e\x12\x18\n" +
+	"\x14SCREEN_SHARE_STOPPED\x10\x0f\x12\x0f\n" +
+	"\vHAND_RAISED\x10\x10\x12\x10\n" +
+	"\fHAND_LOWERED\x10\x11\x12\r\n" +
+	"\tCALLED_ON\x10\x12\x12\f\n" +
+	"\bREACTION\x10\x13\x12\x0e\n" +
+	"\n" +
+	"HAND_QUEUE\x10\x14\x12\x14\n" +
+	"\x10BREAKOUTS_OPENED\x10\x15\x12\x12\n" +
+	"\x0eBREAKOUT_MOVED\x10\x16\x12\x14\n" +
+	"\x10BREAKOUT_MESSAGE\x10\x17\x12\x14\n" +
+	"\x10BREAKOUTS_CLOSED\x10\x18\x12\x12\n" +
+	"\x0eEGRESS_STARTED\x10\x19\x12\x12\n" +
+	"\x0eEGRESS_STOPPED\x10\x1a\x12\x19\n" +
+	"\x15TRANSCRIPTION_STARTED\x10\x1b\x12\x19\n" +
+	"\x15TRANSCRIPTION_STOPPED\x10\x1c\x12\v\n" +
+	"\aCAPTION\x10\x1d\x12\x0e\n" +
+	"\n" +
+	"KEY_ROTATE\x10\x1e\x12\x13\n" +
+	"\x0fSERVER_DRAINING\x10\x1f*)\n" +
+	"\aKeyType\x12\x0e\n" +
+	"\n" +
+	"PUBLIC_KEY\x10\x00\x12\x0e\n" +
+	"\n" +
+	"SENDER_KEY\x10\x01*%\n" +
+	"\fEgressLayout\x12\b\n" +
+	"\x04GRID\x10\x00\x12\v\n" +
+	"\aSPEAKER\x10\x01*.\n" +
 	"\x06PcType\x12\x12\n" +
 	"\x0ePC_UNSPECIFIED\x10\x00\x12\a\n" +
 	"\x03PUB\x10\x01\x12\a\n" +
-	"\x03SUB\x10\x02*M\n" +
+	"\x03SUB\x10\x02*^\n" +
 	"\bRoleType\x12\x14\n" +
 	"\x10ROLE_UNSPECIFIED\x10\x00\x12\r\n" +
 	"\tROLE_HOST\x10\x01\x12\x0e\n" +
 	"\n" +
 	"ROLE_GUEST\x10\x02\x12\f\n" +
-	"\bROLE_BOT\x10\x0325\n" +
+	"\bROLE_BOT\x10\x03\x12\x0f\n" +
+	"\vROLE_VIEWER\x10\x04*\xcd\x01\n" +
+	"\tErrorCode\x12\x15\n" +
+	"\x11ERROR_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vBAD_REQUEST\x10\x01\x12\x12\n" +
+	"\x0eUNKNOWN_ACTION\x10\x02\x12\x17\n" +
+	"\x13UNSUPPORTED_VERSION\x10\x03\x12\x0f\n" +
+	"\vNOT_ALLOWED\x10\x04\x12\x11\n" +
+	"\rROOM_NOT_LIVE\x10\x05\x12\x12\n" +
+	"\x0eNOT_SUBSCRIBED\x10\x06\x12\x13\n" +
+	"\x0fNOT_IMPLEMENTED\x10\a\x12\f\n" +
+	"\bINTERNAL\x10\b\x12\x10\n" +
+	"\fRATE_LIMITED\x10\t2\xc5\x04\n" +
 	"\x03SFU\x12.\n" +
-	"\x06Signal\x12\x0f.SFU.PeerSignal\x1a\x0f.SFU.PeerSignal(\x010\x01B\fZ\n" +
+	"\x06Signal\x12\x0f.SFU.PeerSignal\x1a\x0f.SFU.PeerSignal(\x010\x01\x121\n" +
+	"\bGetStats\x12\x11.SFU.StatsRequest\x1a\x12.SFU.StatsResponse\x122\n" +
+	"\vStartEgress\x12\x12.SFU.EgressRequest\x1a\x0f.SFU.EgressInfo\x121\n" +
+	"\n" +
+	"StopEgress\x12\x12.SFU.EgressRequest\x1a\x0f.SFU.EgressInfo\x120\n" +
+	"\tGetEgress\x12\x12.SFU.EgressRequest\x1a\x0f.SFU.EgressInfo\x12+\n" +
+	"\x04Whip\x12\x10.SFU.WhipRequest\x1a\x11.SFU.WhipResponse\x12+\n" +
+	"\x04Whep\x12\x10.SFU.WhepRequest\x1a\x11.SFU.WhepResponse\x127\n" +
+	"\n" +
+	"EndSession\x12\x13.SFU.SessionRequest\x1a\x14.SFU.SessionResponse\x12:\n" +
+	"\tListRooms\x12\x15.SFU.ListRoomsRequest\x1a\x16.SFU.ListRoomsResponse\x12:\n" +
+	"\tCloseRoom\x12\x15.SFU.CloseRoomRequest\x1a\x16.SFU.CloseRoomResponse\x127\n" +
+	"\bKickPeer\x12\x14.SFU.KickPeerRequest\x1a\x15.SFU.KickPeerResponseB\fZ\n" +
 	"api/proto/b\x06proto3"
 
 var (
@@ -749,37 +3224,110 @@ func file_sfu_proto_rawDescGZIP() []byte {
 	return file_sfu_proto_rawDescData
 }
 
-var file_sfu_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
-var file_sfu_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_sfu_proto_enumTypes = make([]protoimpl.EnumInfo, 9)
+var file_sfu_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
 var file_sfu_proto_goTypes = []any{
-	(SdpType)(0),         // 0: SFU.SdpType
-	(ActionType)(0),      // 1: SFU.ActionType
-	(EventType)(0),       // 2: SFU.EventType
-	(PcType)(0),          // 3: SFU.PcType
-	(RoleType)(0),        // 4: SFU.RoleType
-	(*Action)(nil),       // 5: SFU.Action
-	(*Event)(nil),        // 6: SFU.Event
-	(*Sdp)(nil),          // 7: SFU.Sdp
-	(*IceCandidate)(nil), // 8: SFU.IceCandidate
-	(*PeerSignal)(nil),   // 9: SFU.PeerSignal
+	(SdpType)(0),              // 0: SFU.SdpType
+	(ActionType)(0),           // 1: SFU.ActionType
+	(VideoLayer)(0),           // 2: SFU.VideoLayer
+	(EventType)(0),            // 3: SFU.EventType
+	(KeyType)(0),              // 4: SFU.KeyType
+	(EgressLayout)(0),         // 5: SFU.EgressLayout
+	(PcType)(0),               // 6: SFU.PcType
+	(RoleType)(0),             // 7: SFU.RoleType
+	(ErrorCode)(0),            // 8: SFU.ErrorCode
+	(*Action)(nil),            // 9: SFU.Action
+	(*Ack)(nil),               // 10: SFU.Ack
+	(*Error)(nil),             // 11: SFU.Error
+	(*Event)(nil),             // 12: SFU.Event
+	(*Caption)(nil),           // 13: SFU.Caption
+	(*PeerState)(nil),         // 14: SFU.PeerState
+	(*RoomState)(nil),         // 15: SFU.RoomState
+	(*KeyExchange)(nil),       // 16: SFU.KeyExchange
+	(*TrackQuality)(nil),      // 17: SFU.TrackQuality
+	(*Quality)(nil),           // 18: SFU.Quality
+	(*EgressRequest)(nil),     // 19: SFU.EgressRequest
+	(*EgressInfo)(nil),        // 20: SFU.EgressInfo
+	(*WhipRequest)(nil),       // 21: SFU.WhipRequest
+	(*WhipResponse)(nil),      // 22: SFU.WhipResponse
+	(*WhepRequest)(nil),       // 23: SFU.WhepRequest
+	(*WhepResponse)(nil),      // 24: SFU.WhepResponse
+	(*SessionRequest)(nil),    // 25: SFU.SessionRequest
+	(*SessionResponse)(nil),   // 26: SFU.SessionResponse
+	(*StatsRequest)(nil),      // 27: SFU.StatsRequest
+	(*StatsResponse)(nil),     // 28: SFU.StatsResponse
+	(*ListRoomsRequest)(nil),  // 29: SFU.ListRoomsRequest
+	(*ListRoomsResponse)(nil), // 30: SFU.ListRoomsResponse
+	(*RoomInfo)(nil),          // 31: SFU.RoomInfo
+	(*PeerInfo)(nil),          // 32: SFU.PeerInfo
+	(*TrackInfo)(nil),         // 33: SFU.TrackInfo
+	(*CloseRoomRequest)(nil),  // 34: SFU.CloseRoomRequest
+	(*CloseRoomResponse)(nil), // 35: SFU.CloseRoomResponse
+	(*KickPeerRequest)(nil),   // 36: SFU.KickPeerRequest
+	(*KickPeerResponse)(nil),  // 37: SFU.KickPeerResponse
+	(*Sdp)(nil),               // 38: SFU.Sdp
+	(*IceCandidate)(nil),      // 39: SFU.IceCandidate
+	(*PeerSignal)(nil),        // 40: SFU.PeerSignal
 }
 var file_sfu_proto_depIdxs = []int32{
 	1,  // 0: SFU.Action.type:type_name -> SFU.ActionType
-	2,  // 1: SFU.Event.type:type_name -> SFU.EventType
-	3,  // 2: SFU.Sdp.pc:type_name -> SFU.PcType
-	0,  // 3: SFU.Sdp.type:type_name -> SFU.SdpType
-	3,  // 4: SFU.IceCandidate.pc:type_name -> SFU.PcType
-	7,  // 5: SFU.PeerSignal.sdp:type_name -> SFU.Sdp
-	8,  // 6: SFU.PeerSignal.ice:type_name -> SFU.IceCandidate
-	5,  // 7: SFU.PeerSignal.action:type_name -> SFU.Action
-	6,  // 8: SFU.PeerSignal.event:type_name -> SFU.Event
-	9,  // 9: SFU.SFU.Signal:input_type -> SFU.PeerSignal
-	9,  // 10: SFU.SFU.Signal:output_type -> SFU.PeerSignal
-	10, // [10:11] is the sub-list for method output_type
-	9,  // [9:10] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	2,  // 1: SFU.Action.maxLayer:type_name -> SFU.VideoLayer
+	5,  // 2: SFU.Action.layout:type_name -> SFU.EgressLayout
+	8,  // 3: SFU.Error.code:type_name -> SFU.ErrorCode
+	3,  // 4: SFU.Event.type:type_name -> SFU.EventType
+	18, // 5: SFU.Event.quality:type_name -> SFU.Quality
+	15, // 6: SFU.Event.state:type_name -> SFU.RoomState
+	14, // 7: SFU.Event.peer:type_name -> SFU.PeerState
+	13, // 8: SFU.Event.caption:type_name -> SFU.Caption
+	7,  // 9: SFU.PeerState.role:type_name -> SFU.RoleType
+	14, // 10: SFU.RoomState.peers:type_name -> SFU.PeerState
+	4,  // 11: SFU.KeyExchange.type:type_name -> SFU.KeyType
+	6,  // 12: SFU.TrackQuality.pc:type_name -> SFU.PcType
+	17, // 13: SFU.Quality.tracks:type_name -> SFU.TrackQuality
+	5,  // 14: SFU.EgressRequest.layout:type_name -> SFU.EgressLayout
+	5,  // 15: SFU.EgressInfo.layout:type_name -> SFU.EgressLayout
+	18, // 16: SFU.StatsResponse.peers:type_name -> SFU.Quality
+	31, // 17: SFU.ListRoomsResponse.rooms:type_name -> SFU.RoomInfo
+	32, // 18: SFU.RoomInfo.peers:type_name -> SFU.PeerInfo
+	14, // 19: SFU.PeerInfo.state:type_name -> SFU.PeerState
+	33, // 20: SFU.PeerInfo.tracks:type_name -> SFU.TrackInfo
+	6,  // 21: SFU.Sdp.pc:type_name -> SFU.PcType
+	0,  // 22: SFU.Sdp.type:type_name -> SFU.SdpType
+	6,  // 23: SFU.IceCandidate.pc:type_name -> SFU.PcType
+	38, // 24: SFU.PeerSignal.sdp:type_name -> SFU.Sdp
+	39, // 25: SFU.PeerSignal.ice:type_name -> SFU.IceCandidate
+	9,  // 26: SFU.PeerSignal.action:type_name -> SFU.Action
+	12, // 27: SFU.PeerSignal.event:type_name -> SFU.Event
+	10, // 28: SFU.PeerSignal.ack:type_name -> SFU.Ack
+	11, // 29: SFU.PeerSignal.error:type_name -> SFU.Error
+	16, // 30: SFU.PeerSignal.key:type_name -> SFU.KeyExchange
+	40, // 31: SFU.SFU.Signal:input_type -> SFU.PeerSignal
+	27, // 32: SFU.SFU.GetStats:input_type -> SFU.StatsRequest
+	19, // 33: SFU.SFU.StartEgress:input_type -> SFU.EgressRequest
+	19, // 34: SFU.SFU.StopEgress:input_type -> SFU.EgressRequest
+	19, // 35: SFU.SFU.GetEgress:input_type -> SFU.EgressRequest
+	21, // 36: SFU.SFU.Whip:input_type -> SFU.WhipRequest
+	23, // 37: SFU.SFU.Whep:input_type -> SFU.WhepRequest
+	25, // 38: SFU.SFU.EndSession:input_type -> SFU.SessionRequest
+	29, // 39: SFU.SFU.ListRooms:input_type -> SFU.ListRoomsRequest
+	34, // 40: SFU.SFU.CloseRoom:input_type -> SFU.CloseRoomRequest
+	36, // 41: SFU.SFU.KickPeer:input_type -> SFU.KickPeerRequest
+	40, // 42: SFU.SFU.Signal:output_type -> SFU.PeerSignal
+	28, // 43: SFU.SFU.GetStats:output_type -> SFU.StatsResponse
+	20, // 44: SFU.SFU.StartEgress:output_type -> SFU.EgressInfo
+	20, // 45: SFU.SFU.StopEgress:output_type -> SFU.EgressInfo
+	20, // 46: SFU.SFU.GetEgress:output_type -> SFU.EgressInfo
+	22, // 47: SFU.SFU.Whip:output_type -> SFU.WhipResponse
+	24, // 48: SFU.SFU.Whep:output_type -> SFU.WhepResponse
+	26, // 49: SFU.SFU.EndSession:output_type -> SFU.SessionResponse
+	30, // 50: SFU.SFU.ListRooms:output_type -> SFU.ListRoomsResponse
+	35, // 51: SFU.SFU.CloseRoom:output_type -> SFU.CloseRoomResponse
+	37, // 52: SFU.SFU.KickPeer:output_type -> SFU.KickPeerResponse
+	42, // [42:53] is the sub-list for method output_type
+	31, // [31:42] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
 }
 
 func init() { file_sfu_proto_init() }
@@ -787,19 +3335,25 @@ func file_sfu_proto_init() {
 	if File_sfu_proto != nil {
 		return
 	}
-	file_sfu_proto_msgTypes[4].OneofWrappers = []any{
+	file_sfu_proto_msgTypes[0].OneofWrappers = []any{}
+	file_sfu_proto_msgTypes[3].OneofWrappers = []any{}
+	file_sfu_proto_msgTypes[7].OneofWrappers = []any{}
+	file_sfu_proto_msgTypes[31].OneofWrappers = []any{
 		(*PeerSignal_Sdp)(nil),
 		(*PeerSignal_Ice)(nil),
 		(*PeerSignal_Action)(nil),
 		(*PeerSignal_Event)(nil),
+		(*PeerSignal_Ack)(nil),
+		(*PeerSignal_Error)(nil),
+		(*PeerSignal_Key)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sfu_proto_rawDesc), len(file_sfu_proto_rawDesc)),
-			NumEnums:      5,
-			NumMessages:   5,
+			NumEnums:      9,
+			NumMessages:   32,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,7 +19,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	SFU_Signal_FullMethodName = "/SFU.SFU/Signal"
+	SFU_Signal_FullMethodName      = "/SFU.SFU/Signal"
+	SFU_GetStats_FullMethodName    = "/SFU.SFU/GetStats"
+	SFU_StartEgress_FullMethodName = "/SFU.SFU/StartEgress"
+	SFU_StopEgress_FullMethodName  = "/SFU.SFU/StopEgress"
+	SFU_GetEgress_FullMethodName   = "/SFU.SFU/GetEgress"
+	SFU_Whip_FullMethodName        = "/SFU.SFU/Whip"
+	SFU_Whep_FullMethodName        = "/SFU.SFU/Whep"
+	SFU_EndSession_FullMethodName  = "/SFU.SFU/EndSession"
+	SFU_ListRooms_FullMethodName   = "/SFU.SFU/ListRooms"
+	SFU_CloseRoom_FullMethodName   = "/SFU.SFU/CloseRoom"
+	SFU_KickPeer_FullMethodName    = "/SFU.SFU/KickPeer"
 )
 
 // SFUClient is the client API for SFU service.
@@ -27,6 +37,16 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type SFUClient interface {
 	Signal(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PeerSignal, PeerSignal], error)
+	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	StartEgress(ctx context.Context, in *EgressRequest, opts ...grpc.CallOption) (*EgressInfo, error)
+	StopEgress(ctx context.Context, in *EgressRequest, opts ...grpc.CallOption) (*EgressInfo, error)
+	GetEgress(ctx context.Context, in *EgressRequest, opts ...grpc.CallOption) (*EgressInfo, error)
+	Whip(ctx context.Context, in *WhipRequest, opts ...grpc.CallOption) (*WhipResponse, error)
+	Whep(ctx context.Context, in *WhepRequest, opts ...grpc.CallOption) (*WhepResponse, error)
+	EndSession(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*SessionResponse, error)
+	ListRooms(ctx context.Context, in *ListRoomsRequest, opts ...grpc.CallOption) (*ListRoomsResponse, error)
+	CloseRoom(ctx context.Context, in *CloseRoomRequest, opts ...grpc.CallOption) (*CloseRoomResponse, error)
+	KickPeer(ctx context.Context, in *KickPeerRequest, opts ...grpc.CallOption) (*KickPeerResponse, error)
 }
 
 type sFUClient struct {
@@ -50,11 +70,121 @@ func (c *sFUClient) Signal(ctx context.Context, opts ...grpc.CallOption) (grpc.B
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SFU_SignalClient = grpc.BidiStreamingClient[PeerSignal, PeerSignal]
 
+func (c *sFUClient) GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, SFU_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) StartEgress(ctx context.Context, in *EgressRequest, opts ...grpc.CallOption) (*EgressInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EgressInfo)
+	err := c.cc.Invoke(ctx, SFU_StartEgress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) StopEgress(ctx context.Context, in *EgressRequest, opts ...grpc.CallOption) (*EgressInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EgressInfo)
+	err := c.cc.Invoke(ctx, SFU_StopEgress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) GetEgress(ctx context.Context, in *EgressRequest, opts ...grpc.CallOption) (*EgressInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EgressInfo)
+	err := c.cc.Invoke(ctx, SFU_GetEgress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) Whip(ctx context.Context, in *WhipRequest, opts ...grpc.CallOption) (*WhipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WhipResponse)
+	err := c.cc.Invoke(ctx, SFU_Whip_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) Whep(ctx context.Context, in *WhepRequest, opts ...grpc.CallOption) (*WhepResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WhepResponse)
+	err := c.cc.Invoke(ctx, SFU_Whep_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) EndSession(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*SessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionResponse)
+	err := c.cc.Invoke(ctx, SFU_EndSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) ListRooms(ctx context.Context, in *ListRoomsRequest, opts ...grpc.CallOption) (*ListRoomsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRoomsResponse)
+	err := c.cc.Invoke(ctx, SFU_ListRooms_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) CloseRoom(ctx context.Context, in *CloseRoomRequest, opts ...grpc.CallOption) (*CloseRoomResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseRoomResponse)
+	err := c.cc.Invoke(ctx, SFU_CloseRoom_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sFUClient) KickPeer(ctx context.Context, in *KickPeerRequest, opts ...grpc.CallOption) (*KickPeerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KickPeerResponse)
+	err := c.cc.Invoke(ctx, SFU_KickPeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SFUServer is the server API for SFU service.
 // All implementations must embed UnimplementedSFUServer
 // for forward compatibility.
 type SFUServer interface {
 	Signal(grpc.BidiStreamingServer[PeerSignal, PeerSignal]) error
+	GetStats(context.Context, *StatsRequest) (*StatsResponse, error)
+	StartEgress(context.Context, *EgressRequest) (*EgressInfo, error)
+	StopEgress(context.Context, *EgressRequest) (*EgressInfo, error)
+	GetEgress(context.Context, *EgressRequest) (*EgressInfo, error)
+	Whip(context.Context, *WhipRequest) (*WhipResponse, error)
+	Whep(context.Context, *WhepRequest) (*WhepResponse, error)
+	EndSession(context.Context, *SessionRequest) (*SessionResponse, error)
+	ListRooms(context.Context, *ListRoomsRequest) (*ListRoomsResponse, error)
+	CloseRoom(context.Context, *CloseRoomRequest) (*CloseRoomResponse, error)
+	KickPeer(context.Context, *KickPeerRequest) (*KickPeerResponse, error)
 	mustEmbedUnimplementedSFUServer()
 }
 
@@ -68,6 +198,36 @@ type UnimplementedSFUServer struct{}
 func (UnimplementedSFUServer) Signal(grpc.BidiStreamingServer[PeerSignal, PeerSignal]) error {
 	return status.Errorf(codes.Unimplemented, "method Signal not implemented")
 }
+func (UnimplementedSFUServer) GetStats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedSFUServer) StartEgress(context.Context, *EgressRequest) (*EgressInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartEgress not implemented")
+}
+func (UnimplementedSFUServer) StopEgress(context.Context, *EgressRequest) (*EgressInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopEgress not implemented")
+}
+func (UnimplementedSFUServer) GetEgress(context.Context, *EgressRequest) (*EgressInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEgress not implemented")
+}
+func (UnimplementedSFUServer) Whip(context.Context, *WhipRequest) (*WhipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Whip not implemented")
+}
+func (UnimplementedSFUServer) Whep(context.Context, *WhepRequest) (*WhepResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Whep not implemented")
+}
+func (UnimplementedSFUServer) EndSession(context.Context, *SessionRequest) (*SessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EndSession not implemented")
+}
+func (UnimplementedSFUServer) ListRooms(context.Context, *ListRoomsRequest) (*ListRoomsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRooms not implemented")
+}
+func (UnimplementedSFUServer) CloseRoom(context.Context, *CloseRoomRequest) (*CloseRoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseRoom not implemented")
+}
+func (UnimplementedSFUServer) KickPeer(context.Context, *KickPeerRequest) (*KickPeerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KickPeer not implemented")
+}
 func (UnimplementedSFUServer) mustEmbedUnimplementedSFUServer() {}
 func (UnimplementedSFUServer) testEmbeddedByValue()             {}
 
@@ -96,13 +256,234 @@ func _SFU_Signal_Handler(srv interface{}, stream grpc.ServerStream) error {
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SFU_SignalServer = grpc.BidiStreamingServer[PeerSignal, PeerSignal]
 
+func _SFU_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).GetStats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_StartEgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).StartEgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_StartEgress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).StartEgress(ctx, req.(*EgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_StopEgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).StopEgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_StopEgress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).StopEgress(ctx, req.(*EgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_GetEgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).GetEgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_GetEgress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).GetEgress(ctx, req.(*EgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_Whip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WhipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).Whip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_Whip_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).Whip(ctx, req.(*WhipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_Whep_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WhepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).Whep(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_Whep_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).Whep(ctx, req.(*WhepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_EndSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).EndSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_EndSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).EndSession(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_ListRooms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRoomsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).ListRooms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_ListRooms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).ListRooms(ctx, req.(*ListRoomsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_CloseRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseRoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).CloseRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_CloseRoom_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).CloseRoom(ctx, req.(*CloseRoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SFU_KickPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SFUServer).KickPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SFU_KickPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SFUServer).KickPeer(ctx, req.(*KickPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SFU_ServiceDesc is the grpc.ServiceDesc for SFU service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var SFU_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "SFU.SFU",
 	HandlerType: (*SFUServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStats",
+			Handler:    _SFU_GetStats_Handler,
+		},
+		{
+			MethodName: "StartEgress",
+			Handler:    _SFU_StartEgress_Handler,
+		},
+		{
+			MethodName: "StopEgress",
+			Handler:    _SFU_StopEgress_Handler,
+		},
+		{
+			MethodName: "GetEgress",
+			Handler:    _SFU_GetEgress_Handler,
+		},
+		{
+			MethodName: "Whip",
+			Handler:    _SFU_Whip_Handler,
+		},
+		{
+			MethodName: "Whep",
+			Handler:    _SFU_Whep_Handler,
+		},
+		{
+			MethodName: "EndSession",
+			Handler:    _SFU_EndSession_Handler,
+		},
+		{
+			MethodName: "ListRooms",
+			Handler:    _SFU_ListRooms_Handler,
+		},
+		{
+			MethodName: "CloseRoom",
+			Handler:    _SFU_CloseRoom_Handler,
+		},
+		{
+			MethodName: "KickPeer",
+			Handler:    _SFU_KickPeer_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Signal",
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"vidcall/internal/signaling/protocol"
+)
+
+// writes the JSON schema and TypeScript types of the signaling protocol
+func main() {
+	schemaPath := flag.String("schema", "api/schema/signal.schema.json", "JSON schema output")
+	tsPath := flag.String("ts", "../frontend/src/types/protocol.gen.ts", "TypeScript output")
+	flag.Parse()
+
+	schema, err := protocol.Schema()
+	if err != nil {
+		log.Fatalf("unable to build schema: %v", err)
+	}
+
+	if err := os.WriteFile(*schemaPath, append(schema, '\n'), 0o644); err != nil {
+		log.Fatalf("unable to write schema: %v", err)
+	}
+
+	if err := os.WriteFile(*tsPath, []byte(protocol.TypeScript()), 0o644); err != nil {
+		log.Fatalf("unable to write TypeScript types: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"vidcall/pkg/config"
+)
+
+var (
+	ErrNoBreakouts     = errors.New("no breakout rooms are open")
+	ErrBreakoutsOpen   = errors.New("breakout rooms are already open")
+	ErrUnknownBreakout = errors.New("unknown breakout room")
+	ErrBadBreakout     = errors.New("invalid breakout request")
+)
+
+// Sub-rooms of a main room, run by the host
+type Breakouts interface {
+	Open(count int, duration time.Duration, random bool) ([]string, error)
+	Assign(peerID string, roomID string) error
+	Broadcast(msg string) error
+	Recall() error
+	// close the breakout rooms with the main room
+	End()
+}
+
+// breakout room IDs are the main room ID followed by "/breakout-N"
+func IsBreakout(roomID string) bool {
+	return strings.Contains(roomID, "/breakout-")
+}
+
+type BreakoutsObj struct {
+	Mu     sync.Mutex
+	Main   Room
+	MainID string
+	// settings of the breakout rooms, those of the main room
+	Config config.Room
+	// breakout room IDs in creation order
+	IDs   []string
+	Rooms map[string]Room
+	Ends  time.Time
+	Timer *time.Timer
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// video layer forwarded to a subscriber, picked from its bandwidth estimate
+type Layer int32
+
+const (
+	LayerAll    Layer = iota // every temporal layer
+	LayerBase                // base temporal layer only
+	LayerPaused              // no video, audio keeps flowing
+)
+
+func (l Layer) String() string {
+	switch l {
+	case LayerAll:
+		return "all"
+	case LayerBase:
+		return "base"
+	default:
+		return "paused"
+	}
+}
+
+// Filters one publisher video stream for one subscriber
+type VideoForwarder interface {
+	Layer() Layer
+	SetLayer(l Layer)
+	SetMaxLayer(l Layer)
+	// forwarding was stopped, continue the stream on the next keyframe
+	Resync()
+	// returns the packet to send, nil when it is dropped
+	Forward(pkt *rtp.Packet) *rtp.Packet
+	// incoming bitrate of all layers and of the base layer since last call
+	Rates() (all uint64, base uint64)
+	OnREMB(bitrate uint64)
+	REMB() uint64
+	// publisher sequence number of a forwarded packet, false when it is
+	// outside of the recently forwarded window or cannot be known
+	Resolve(outSeq uint16) (uint16, bool)
+	// redo the rewrite of a forwarded packet for retransmission
+	Rewrite(pkt *rtp.Packet, outSeq uint16) *rtp.Packet
+}
+
+// Recent RTP packets of a publisher track
+type PacketCache interface {
+	Push(pkt *rtp.Packet)
+	Get(seq uint16) *rtp.Packet
+}
+
+// bitrate a subscriber can take from a publisher
+type BitrateReport struct {
+	Bitrate uint64
+	At      time.Time
+}
@@ -1,9 +1,14 @@
 package domain
 
 import (
+	"context"
 	"log/slog"
+	"sync"
+	"time"
 	sfu "vidcall/api/proto"
 
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/webrtc/v3"
 )
 
@@ -14,13 +19,37 @@ type Connection interface {
 	HandleOffer(sdp *sfu.PeerSignal_Sdp) error
 	HandleAnswer(sdp *sfu.PeerSignal_Sdp) error
 	SendOffer(pc sfu.PcType) error
+	Quality(ssrc uint32, clockRate float64, inbound bool) *sfu.TrackQuality
+	TargetBitrate() uint64
+	SetVideoCodecs(policy []string)
+	EnqueueSend(msg *sfu.PeerSignal)
 	Close() error
 }
 
 type PConn struct {
+	Ctx        context.Context
 	PC         *webrtc.PeerConnection
 	Log        *slog.Logger
 	IceBuffers chan webrtc.ICECandidateInit
 	RecvQ      chan *sfu.PeerSignal
 	SendQ      chan *sfu.PeerSignal
+	Codecs     []string
+
+	Stats   stats.Getter
+	BWE     cc.BandwidthEstimator
+	RatesMu sync.Mutex
+	Rates   map[uint32]RateSample
+
+	// local candidates held back to be sent together, nothing is sent
+	// once the connection is closed
+	IceMu      sync.Mutex
+	IceDelay   time.Duration
+	IcePending []*sfu.PeerSignal
+	IceTimer   *time.Timer
+	IceClosed  bool
+}
+
+type RateSample struct {
+	Bytes uint64
+	At    time.Time
 }
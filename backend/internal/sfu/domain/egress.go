@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	sfu "vidcall/api/proto"
+)
+
+var (
+	ErrEgressRunning = errors.New("room is already streamed")
+	ErrNoEgress      = errors.New("room is not streamed")
+	ErrBadEgress     = errors.New("invalid egress request")
+)
+
+// Live stream of a room, the room is subscribed like a hidden peer and
+// packaged as HLS, optionally pushed to an RTMP server
+type Egress interface {
+	Start(cfg EgressConfig) error
+	Stop() error
+	Info() *sfu.EgressInfo
+}
+
+type EgressConfig struct {
+	Layout sfu.EgressLayout
+	// speaker of the SPEAKER layout, the first host when empty
+	TargetID string
+	RTMPURL  string
+}
+
+type EgressObj struct {
+	Mu       sync.Mutex
+	Room     Room
+	RoomID   string
+	Playlist string
+	Config   EgressConfig
+	Active   bool
+	Started  time.Time
+	// publishers in the picture
+	Peers  []string
+	Cancel context.CancelFunc
+	Done   chan struct{}
+}
@@ -8,6 +8,9 @@ type Hub interface {
 	AddRoom(roomID string, room Room)
 	RemoveRoom(roomID string) Room
 	GetRoom(roomID string) Room
+	ListRooms() map[string]Room
+	Drain()
+	IsDraining() bool
 }
 
 type HubObj struct {
@@ -15,4 +18,6 @@ type HubObj struct {
 	Turn  string
 	Stuns []string
 	Rooms map[string]Room
+	// set on shutdown, no new peers are accepted
+	Draining bool
 }
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	sfu "vidcall/api/proto"
 )
@@ -12,7 +13,13 @@ type Peer interface {
 	Sub() Subscriber
 	Connect() error
 	Disconnect() error
+	Leave()
+	Kick()
+	Kicked() <-chan struct{}
 	EnqueueEvent(event *sfu.PeerSignal_Event)
+	EnqueueSend(msg *sfu.PeerSignal)
+	Quality() *sfu.Quality
+	Info() *sfu.PeerInfo
 }
 
 type PeerObj struct {
@@ -28,9 +35,40 @@ type PeerObj struct {
 	EventQ chan *sfu.PeerSignal_Event
 }
 
+var (
+	ErrUnknownAction  = errors.New("unknown action")
+	ErrNotImplemented = errors.New("action not implemented")
+	ErrNotAllowed     = errors.New("action not allowed for the peer role")
+	ErrRateLimited    = errors.New("too many requests")
+	ErrBadReaction    = errors.New("reaction must be a short emoji")
+	ErrBadOffer       = errors.New("offer has no codec allowed in the room")
+	ErrNoSession      = errors.New("session not found")
+	ErrBadKey         = errors.New("invalid key exchange")
+	ErrNoOpus         = errors.New("opus audio cannot be decoded, the SFU is built without -tags opus")
+	ErrKicked         = errors.New("removed from the room by the host")
+	ErrNoPeer         = errors.New("peer not found")
+)
+
 type PeerMD struct {
 	Name   string
 	PeerID string
 	RoomID string
 	Role   sfu.RoleType
+	Codecs []string
+	// remote peers received at once, asked at join by bots that mix the
+	// room themselves
+	Slots int
+	// remote audio mixed into one track by the SFU, for clients that
+	// cannot decode a stream per peer
+	MixAudio bool
+	// the room is end-to-end encrypted
+	E2EE bool
+	// ingest or view, the token only starts that kind of HTTP session
+	Scope string
 }
+
+// scopes of the tokens handed to encoders and WHEP players
+const (
+	ScopeIngest = "ingest"
+	ScopeView   = "view"
+)
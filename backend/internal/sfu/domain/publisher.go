@@ -3,20 +3,34 @@ package domain
 import (
 	"context"
 	"log/slog"
+	"sync"
 	sfu "vidcall/api/proto"
 
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3"
 )
 
 type Publisher interface {
 	WireCallBacks(peerID string)
 	PumpAudio(ctx context.Context, local *webrtc.TrackLocalStaticRTP)
-	PumpVideo(ctx context.Context, local *webrtc.TrackLocalStaticRTP, tx *webrtc.RTPTransceiver)
+	PumpVideo(ctx context.Context, local *webrtc.TrackLocalStaticRTP, fwd VideoForwarder)
+	HandleRTCP(pkts []rtcp.Packet, local *webrtc.TrackLocalStaticRTP, fwd VideoForwarder)
+	RequestKeyframe(kind KeyframeKind)
+	ReportBitrate(subscriberID string, bitrate uint64)
+	SetCodecs(policy []string)
+	RejectCodec(decodable map[string]bool)
+	OnVideoCodec(f func())
 	Connect() error
 	Disconnect() error
 	GetLocalAV() *PubAV
+	Tracks() PubAV
+	Tap(kind webrtc.RTPCodecType) (<-chan *rtp.Packet, func())
+	Answer(ctx context.Context, offer string) (string, error)
 	EnqueueSdp(sdp *sfu.PeerSignal_Sdp)
 	EnqueueIce(ice *sfu.PeerSignal_Ice)
+	Quality() []*sfu.TrackQuality
+	State() webrtc.PeerConnectionState
 }
 
 type PubConn struct {
@@ -25,12 +39,34 @@ type PubConn struct {
 	Cancel context.CancelFunc
 	Log    *slog.Logger
 
-	AV      *PubAV
+	AV    *PubAV
+	Cache PacketCache
+	// room codec policy, narrowed when a subscriber cannot decode
+	Codecs  []string
 	RecvSdp chan *sfu.PeerSignal_Sdp
 	RecvIce chan *sfu.PeerSignal_Ice
+
+	// bitrate each subscriber can take, sent upstream as REMB
+	BudgetMu sync.Mutex
+	Budgets  map[string]BitrateReport
 }
 
 type PubAV struct {
 	Video *webrtc.TrackRemote
 	Audio *webrtc.TrackRemote
 }
+
+// RTCP message used to ask a publisher for a keyframe
+type KeyframeKind int
+
+const (
+	KeyframePLI KeyframeKind = iota
+	KeyframeFIR
+)
+
+func (k KeyframeKind) String() string {
+	if k == KeyframeFIR {
+		return "fir"
+	}
+	return "pli"
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// quality score reported to clients
+const (
+	QualityUnknown uint32 = iota
+	QualityPoor
+	QualityGood
+	QualityExcellent
+)
+
+// Call quality of a room aggregated over its lifetime
+type QualitySummary struct {
+	RoomID string
+	Start  time.Time
+	End    time.Time
+	Peers  map[string]*PeerQuality
+}
+
+type PeerQuality struct {
+	PeerID      string
+	Samples     int
+	PoorSamples int
+
+	AvgLoss       float64
+	MaxLoss       float64
+	AvgJitterMs   float64
+	MaxJitterMs   float64
+	AvgRttMs      float64
+	MaxRttMs      float64
+	AvgBitrateBps float64
+}
@@ -2,27 +2,89 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 	sfu "vidcall/api/proto"
+	"vidcall/pkg/config"
+)
+
+var (
+	ErrRoomNotLive = errors.New("room is not live")
+	ErrNotFound    = errors.New("room not found")
+	ErrRoomClosed  = errors.New("room has ended")
+	ErrE2EE        = errors.New("not available in an end-to-end encrypted room")
 )
 
 type Room interface {
 	MakeLive()
 	IsLive() bool
-	AddPeer(peerID string, peer Peer)
+	AddPeer(peerID string, peer Peer) error
 	RemovePeer(peerID string) Peer
 	GetPeer(peerID string) Peer
+	AddViewer(viewerID string, viewer Peer)
+	RemoveViewer(viewerID string)
 	BroadCast(peerID string, event *sfu.PeerSignal_Event)
+	Announce(event *sfu.PeerSignal_Event, update func(s *PeerState))
+	SendState(peerID string)
+	RaiseHand(event *sfu.PeerSignal_Event) bool
+	LowerHand(event *sfu.PeerSignal_Event) bool
+	CallNext() (string, bool)
+	SendHandQueue(peerID string)
+	Breakouts() Breakouts
+	Egress() Egress
+	Transcriber() Transcriber
 	ListPeers() map[string]Peer
+	ListViewers() map[string]Peer
+	RecordQuality(q *sfu.Quality)
+	CodecPolicy() []string
+	E2EE() bool
+	Info() *sfu.RoomInfo
 	Close()
 }
 
 type RoomObj struct {
-	Mu       sync.RWMutex
-	Live     bool
-	ID       string
-	Peers    map[string]Peer
+	Mu    sync.RWMutex
+	Live  bool
+	ID    string
+	Peers map[string]Peer
+	// receive-only peers (WHEP), they get the room events but are not
+	// participants
+	Viewers  map[string]Peer
 	Ctx      context.Context
 	Cancel   context.CancelFunc
 	JoinChan chan Peer
+	Quality  *QualitySummary
+	Codecs   []string
+	// frames are encrypted by the clients, the keys rotate on every join
+	// and leave
+	Encrypted bool
+	KeyEpoch  uint64
+	Config    config.Room
+
+	// peer states and the sequence number of the last state event
+	States map[string]*PeerState
+	Seq    uint64
+	// peer IDs of the raised hands, first raised first
+	Hands []string
+
+	// set under Mu before JoinChan is closed, no peer joins after. The
+	// room may be closed from several places, it is torn down once
+	Closed    bool
+	CloseOnce sync.Once
+
+	Breakout Breakouts
+	Stream   Egress
+	Captions Transcriber
+}
+
+type PeerState struct {
+	PeerID      string
+	Name        string
+	Role        sfu.RoleType
+	Audio       bool
+	Video       bool
+	ScreenShare bool
+	HandRaised  bool
+	JoinedAt    time.Time
 }
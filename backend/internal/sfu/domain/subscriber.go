@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"sync"
 	sfu "vidcall/api/proto"
@@ -13,20 +14,39 @@ type Subscriber interface {
 	WireCallBacks()
 	Connect() error
 	Disconnect() error
+	Answer(ctx context.Context, offer string) (string, error)
 	SubscribeRoom(subcriberID string, room Room) error
 	Subscribe(peer Peer) error
 	Unsubscribe(peer string) error
+	Rebind(peer Peer) error
 	EnqueueSdp(sdp *sfu.PeerSignal_Sdp)
 	EnqueueIce(sdp *sfu.PeerSignal_Ice)
+	PauseVideo(peerID string) error
+	ResumeVideo(peerID string) error
+	SetMaxLayer(peerID string, layer Layer) error
+	SetAudioOnly(on bool)
+	Quality() []*sfu.TrackQuality
+	State() webrtc.PeerConnectionState
 }
 
 type SubConn struct {
+	PeerID string
 	Conn   Connection
 	Mu     sync.RWMutex
 	Ctx    context.Context
 	Cancel context.CancelFunc
 	Log    *slog.Logger
 
+	// video codecs the client answered with
+	Decodes map[string]bool
+	// no video is forwarded, only audio
+	AudioOnly bool
+	// the client offered (WHEP), no offer is sent
+	Answered bool
+	// every remote peer's audio mixed into one track, nil unless the
+	// client asked for it at join
+	Mix AudioMixer
+
 	Videos  *SubVideo
 	RecvSdp chan *sfu.PeerSignal_Sdp
 	RecvIce chan *sfu.PeerSignal_Ice
@@ -47,4 +67,24 @@ type Slot struct {
 	AudioTx    *webrtc.RTPTransceiver
 	PumpCtx    context.Context
 	PumpCancel context.CancelFunc
+
+	Pub Publisher
+	Fwd VideoForwarder
+
+	// video pump, a child of PumpCtx, nil while video is stopped
+	VideoCtx    context.Context
+	VideoCancel context.CancelFunc
+	// video paused by the subscriber
+	Paused bool
 }
+
+// AudioMixer decodes the audio of the remote peers and sends their mix on
+// a single track, for clients that cannot decode one stream per peer
+type AudioMixer interface {
+	Track() webrtc.TrackLocal
+	Add(peerID string, pub Publisher, mime string)
+	Remove(peerID string)
+	Run()
+}
+
+var ErrNotSubscribed = errors.New("not subscribed to peer video")
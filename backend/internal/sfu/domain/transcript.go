@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTranscribing    = errors.New("room is already transcribed")
+	ErrNoTranscription = errors.New("room is not transcribed")
+	ErrNoSpeechToText  = errors.New("no speech to text engine configured")
+)
+
+// Live captions of a room, the audio of every publisher goes through the
+// speech to text engine and each caption is broadcast and stored
+type Transcriber interface {
+	Start() error
+	Stop() error
+	Active() bool
+}
+
+// One utterance of a speaker
+type Caption struct {
+	RoomID string
+	PeerID string
+	Name   string
+	Start  time.Time
+	End    time.Time
+	Text   string
+}
+
+type TranscriberObj struct {
+	Mu      sync.Mutex
+	Room    Room
+	RoomID  string
+	Running bool
+	Started time.Time
+	Cancel  context.CancelFunc
+	Done    chan struct{}
+}
@@ -0,0 +1,48 @@
+package infra
+
+import (
+	"context"
+	"sync"
+	"time"
+	"vidcall/pkg/logger"
+
+	mongodrv "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	mongoOnce sync.Once
+	db        *mongodrv.Database
+)
+
+func InitMongo(dsn string, dbName string, pool uint64) {
+
+	mongoOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+		defer cancel()
+
+		log := logger.GetLog(ctx).With("layer", "infra", "service", "mongodb")
+		if dsn == "" {
+			log.Warn("MONGODB_URI is not set, quality summaries and transcripts will not be stored")
+			return
+		}
+
+		client, err := mongodrv.Connect(ctx,
+			options.Client().
+				ApplyURI(dsn).
+				SetAppName("vidcall-sfu").
+				SetMaxPoolSize(pool),
+		)
+
+		if err != nil {
+			log.Error("Unable to connect to MongoDB")
+			return
+		}
+
+		db = client.Database(dbName)
+
+	})
+}
+
+func DB() *mongodrv.Database { return db }
@@ -40,3 +40,8 @@ func Init(addr string, password string, db int) {
 func C() *goredis.Client {
 	return client
 }
+
+// whether Redis answers, the SFU is not ready without it
+func Ping(ctx context.Context) error {
+	return client.Ping(ctx).Err()
+}
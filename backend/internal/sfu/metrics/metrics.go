@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"net/http"
+	"vidcall/internal/sfu/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "vidcall_sfu"
+
+var (
+	rtpPackets = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rtp_packets_forwarded_total",
+		Help:      "RTP packets forwarded to subscribers.",
+	}, []string{"kind"})
+
+	rtpBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rtp_bytes_forwarded_total",
+		Help:      "RTP bytes forwarded to subscribers.",
+	}, []string{"kind"})
+
+	keyframeRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "keyframe_requests_total",
+		Help:      "Keyframe requests (PLI or FIR) for publishers, by whether they were sent or merged into a pending one.",
+	}, []string{"kind", "result"})
+
+	droppedRTP = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rtp_packets_dropped_total",
+		Help:      "RTP packets not forwarded to a subscriber.",
+	}, []string{"reason"})
+
+	retransmits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nack_retransmits_total",
+		Help:      "Subscriber NACKs answered from the packet cache or forwarded upstream to the publisher.",
+	}, []string{"source"})
+
+	layerSwitches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "video_layer_switches_total",
+		Help:      "Video layer changes made by the congestion policy, by new layer.",
+	}, []string{"layer"})
+
+	droppedSignals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dropped_signals_total",
+		Help:      "Signals dropped because the destination queue was full.",
+	}, []string{"queue"})
+)
+
+// count a packet forwarded to a subscriber, kind is "audio" or "video"
+func ForwardedRTP(kind string, size int) {
+	rtpPackets.WithLabelValues(kind).Inc()
+	rtpBytes.WithLabelValues(kind).Add(float64(size))
+}
+
+// count a packet dropped on the way to a subscriber
+func DroppedRTP(reason string) {
+	droppedRTP.WithLabelValues(reason).Inc()
+}
+
+// count a NACK answered, source is "cache" or "upstream"
+func Retransmitted(source string) {
+	retransmits.WithLabelValues(source).Inc()
+}
+
+func LayerSwitched(layer string) {
+	layerSwitches.WithLabelValues(layer).Inc()
+}
+
+// count a keyframe request, kind is "pli" or "fir"
+func KeyframeRequest(kind string, result string) {
+	keyframeRequests.WithLabelValues(kind, result).Inc()
+}
+
+// count a signal dropped by a non-blocking enqueue
+func DroppedSignal(queue string) {
+	droppedSignals.WithLabelValues(queue).Inc()
+}
+
+// export live rooms and peers of the hub on every scrape
+func RegisterHub(h domain.Hub) {
+	prometheus.MustRegister(&hubCollector{hub: h})
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+var (
+	roomsDesc = prometheus.NewDesc(namespace+"_active_rooms", "Rooms currently held by the hub.", nil, nil)
+	peersDesc = prometheus.NewDesc(namespace+"_room_peers", "Peers currently in a room.", []string{"room_id"}, nil)
+)
+
+type hubCollector struct {
+	hub domain.Hub
+}
+
+func (c *hubCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- roomsDesc
+	ch <- peersDesc
+}
+
+func (c *hubCollector) Collect(ch chan<- prometheus.Metric) {
+	rooms := c.hub.ListRooms()
+
+	ch <- prometheus.MustNewConstMetric(roomsDesc, prometheus.GaugeValue, float64(len(rooms)))
+	for id, room := range rooms {
+		ch <- prometheus.MustNewConstMetric(peersDesc, prometheus.GaugeValue, float64(len(room.ListPeers())), id)
+	}
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"vidcall/internal/sfu/domain"
+	"vidcall/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type peerQualityDoc struct {
+	PeerID        string  `bson:"peerID"`
+	Samples       int     `bson:"samples"`
+	PoorSamples   int     `bson:"poorSamples"`
+	AvgLoss       float64 `bson:"avgLoss"`
+	MaxLoss       float64 `bson:"maxLoss"`
+	AvgJitterMs   float64 `bson:"avgJitterMs"`
+	MaxJitterMs   float64 `bson:"maxJitterMs"`
+	AvgRttMs      float64 `bson:"avgRttMs"`
+	MaxRttMs      float64 `bson:"maxRttMs"`
+	AvgBitrateBps float64 `bson:"avgBitrateBps"`
+}
+
+type qualityDoc struct {
+	RoomID string           `bson:"roomID"`
+	Start  time.Time        `bson:"start"`
+	End    time.Time        `bson:"end"`
+	Peers  []peerQualityDoc `bson:"peers"`
+}
+
+func toQualityDoc(q *domain.QualitySummary) qualityDoc {
+	d := qualityDoc{
+		RoomID: q.RoomID,
+		Start:  q.Start,
+		End:    q.End,
+		Peers:  make([]peerQualityDoc, 0, len(q.Peers)),
+	}
+
+	for _, p := range q.Peers {
+		d.Peers = append(d.Peers, peerQualityDoc{
+			PeerID:        p.PeerID,
+			Samples:       p.Samples,
+			PoorSamples:   p.PoorSamples,
+			AvgLoss:       p.AvgLoss,
+			MaxLoss:       p.MaxLoss,
+			AvgJitterMs:   p.AvgJitterMs,
+			MaxJitterMs:   p.MaxJitterMs,
+			AvgRttMs:      p.AvgRttMs,
+			MaxRttMs:      p.MaxRttMs,
+			AvgBitrateBps: p.AvgBitrateBps,
+		})
+	}
+
+	return d
+}
+
+func SaveQualitySummary(ctx context.Context, db *mongo.Database, q *domain.QualitySummary) error {
+	log := logger.GetLog(ctx).With("layer", "repo", "service", "mongodb", "roomID", q.RoomID)
+
+	col := db.Collection("call_quality")
+
+	opCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := col.InsertOne(opCtx, toQualityDoc(q))
+	if err != nil {
+		log.Warn("unable to insert quality summary")
+		return err
+	}
+
+	return nil
+}
@@ -1 +1,29 @@
 package repo
+
+import (
+	"context"
+	"time"
+	"vidcall/pkg/logger"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const drainingPrefix = "sfu:draining:"
+
+// mark the SFU instance as draining so no new calls are sent its way, the
+// mark outlives the drain by ttl in case the process dies before clearing it
+func MarkDraining(ctx context.Context, rdb *goredis.Client, instance string, ttl time.Duration) error {
+	log := logger.GetLog(ctx).With("layer", "repo", "service", "redis", "instance", instance)
+
+	if err := rdb.Set(ctx, drainingPrefix+instance, time.Now().UnixMilli(), ttl).Err(); err != nil {
+		log.Warn("unable to mark SFU draining")
+		return err
+	}
+
+	return nil
+}
+
+// a restarted instance takes calls again
+func ClearDraining(ctx context.Context, rdb *goredis.Client, instance string) error {
+	return rdb.Del(ctx, drainingPrefix+instance).Err()
+}
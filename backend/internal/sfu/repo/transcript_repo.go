@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"vidcall/internal/sfu/domain"
+	"vidcall/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type captionDoc struct {
+	RoomID string    `bson:"roomID"`
+	PeerID string    `bson:"peerID"`
+	Name   string    `bson:"name"`
+	Start  time.Time `bson:"start"`
+	End    time.Time `bson:"end"`
+	Text   string    `bson:"text"`
+}
+
+// one document per caption, the transcript of a room is read back in start
+// order by the signaling server
+func SaveCaption(ctx context.Context, db *mongo.Database, c *domain.Caption) error {
+	log := logger.GetLog(ctx).With("layer", "repo", "service", "mongodb", "roomID", c.RoomID)
+
+	col := db.Collection("transcripts")
+
+	opCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := col.InsertOne(opCtx, captionDoc{
+		RoomID: c.RoomID,
+		PeerID: c.PeerID,
+		Name:   c.Name,
+		Start:  c.Start,
+		End:    c.End,
+		Text:   c.Text,
+	})
+	if err != nil {
+		log.Warn("unable to insert caption")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"vidcall/pkg/jwtx"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrRevoked = errors.New("token revoked")
+
+// Claims issued by the signaling server
+type Claims struct {
+	Name   string
+	PeerID string
+	RoomID string
+	Role   string
+	// room policy and bot slots
+	Codecs []string
+	E2EE   bool
+	Slots  int
+	// ingest or view for WHIP and WHEP tokens
+	Scope string
+	jwt.RegisteredClaims
+}
+
+// Verifier checks signaling tokens with the shared keyring, the SFU takes
+// no identity the signaling server did not sign
+type Verifier struct {
+	keys *jwtx.Keyring
+	deny *jwtx.Denylist
+}
+
+func NewVerifier(keys *jwtx.Keyring, deny *jwtx.Denylist) *Verifier {
+	return &Verifier{keys: keys, deny: deny}
+}
+
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Claims, error) {
+	var c Claims
+	if err := v.keys.Parse(raw, &c); err != nil {
+		return nil, err
+	}
+
+	if v.deny != nil {
+		revoked, err := v.deny.IsRevoked(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if revoked {
+			return nil, ErrRevoked
+		}
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+)
+
+// every room of the SFU with its peers, in room ID order
+func ListRooms() []*sfu.RoomInfo {
+	rooms := []*sfu.RoomInfo{}
+	for _, r := range hub.Hub().ListRooms() {
+		rooms = append(rooms, r.Info())
+	}
+
+	slices.SortFunc(rooms, func(a, b *sfu.RoomInfo) int {
+		return strings.Compare(a.RoomID, b.RoomID)
+	})
+
+	return rooms
+}
+
+// end a room like its host would, the peers are told and disconnected.
+// Breakout rooms end with their main room
+func EndRoom(roomID string) error {
+	r := hub.Hub().GetRoom(roomID)
+	if r == nil {
+		return domain.ErrNotFound
+	}
+
+	r.BroadCast("", &sfu.PeerSignal_Event{
+		Event: &sfu.Event{Type: sfu.EventType_ROOM_ENDED},
+	})
+	r.Close()
+	return nil
+}
+
+// KickPeer takes a member out of a room or of one of its breakouts. A
+// client's stream is ended, an encoder's ingest session stopped
+func KickPeer(roomID string, peerID string) error {
+	sessionMu.Lock()
+	s, ok := sessions[peerID]
+	sessionMu.Unlock()
+
+	if ok && s.roomID == roomID {
+		return endSession(peerID)
+	}
+
+	for id, r := range hub.Hub().ListRooms() {
+		if id != roomID && !strings.HasPrefix(id, roomID+"/breakout-") {
+			continue
+		}
+
+		if peer := r.GetPeer(peerID); peer != nil {
+			peer.Kick()
+			return nil
+		}
+	}
+
+	return domain.ErrNoPeer
+}
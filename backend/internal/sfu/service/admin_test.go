@@ -0,0 +1,24 @@
+package service
+
+import (
+	"testing"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+	"vidcall/internal/sfu/service/room"
+	"vidcall/pkg/config"
+)
+
+func TestEndRoom(t *testing.T) {
+	hub.Init(config.ICE{})
+	room.NewRoom("end", nil, false, config.Room{JoinQueue: 1})
+
+	if err := EndRoom("end"); err != nil {
+		t.Fatalf("EndRoom() = %v", err)
+	}
+	if err := EndRoom("end"); err != domain.ErrNotFound {
+		t.Fatalf("EndRoom() of an ended room = %v, want %v", err, domain.ErrNotFound)
+	}
+	if err := EndRoom("unknown"); err != domain.ErrNotFound {
+		t.Fatalf("EndRoom() of an unknown room = %v, want %v", err, domain.ErrNotFound)
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"sync"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+	"vidcall/internal/sfu/service/room"
+)
+
+// one room per ID when its first peers join at once
+var joinMu sync.Mutex
+
+// room the peer is in, a breakout room or the room of its token. Only
+// joining creates a room, one that ended stays gone
+func (p *PeerObj) currentRoom() (domain.Room, error) {
+	p.roomMu.Lock()
+	defer p.roomMu.Unlock()
+
+	if p.breakout != nil {
+		return p.breakout, nil
+	}
+
+	return p.mainRoom()
+}
+
+// room of the peer's token, even from a breakout room
+func (p *PeerObj) mainRoom() (domain.Room, error) {
+	if r := hub.Hub().GetRoom(p.Metadata.RoomID); r != nil {
+		return r, nil
+	}
+
+	return nil, domain.ErrNotFound
+}
+
+// room the peer joins, the first peer to join creates it
+func (p *PeerObj) joinRoom() domain.Room {
+	joinMu.Lock()
+	defer joinMu.Unlock()
+
+	if r, err := p.currentRoom(); err == nil {
+		return r
+	}
+
+	md := p.Metadata
+	return room.NewRoom(md.RoomID, md.Codecs, md.E2EE, p.config.Room)
+}
+
+// breakout rooms of the peer's main room, for hosts only
+func (p *PeerObj) hostBreakouts() (domain.Breakouts, error) {
+	md := p.Metadata
+	if md.Role != sfu.RoleType_ROLE_HOST {
+		return nil, domain.ErrNotAllowed
+	}
+
+	main := hub.Hub().GetRoom(md.RoomID)
+	if main == nil || !main.IsLive() {
+		return nil, domain.ErrRoomNotLive
+	}
+
+	return main.Breakouts(), nil
+}
+
+// switch rooms on the same peer connections: drop the subscriptions to the
+// old room and subscribe to the new one
+func (p *PeerObj) moveTo(roomID string) error {
+	md := p.Metadata
+
+	to := hub.Hub().GetRoom(roomID)
+	if to == nil {
+		p.Log.Warn("breakout room is gone", "room", roomID)
+		return nil
+	}
+
+	from, err := p.currentRoom()
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+
+	// stay where the peer is when the room it goes to just ended
+	if err := to.AddPeer(md.PeerID, p); err != nil {
+		p.Log.Warn("breakout room is gone", "room", roomID)
+		return nil
+	}
+
+	from.RemovePeer(md.PeerID)
+	from.Announce(p.createEvent(md.RoomID, sfu.EventType_LEAVE_EVENT), nil)
+
+	if !p.pubOnly {
+		for id := range from.ListPeers() {
+			if err := p.Subscriber.Unsubscribe(id); err != nil {
+				return err
+			}
+		}
+	}
+
+	p.roomMu.Lock()
+	p.breakout = to
+	if roomID == md.RoomID {
+		p.breakout = nil
+	}
+	p.roomMu.Unlock()
+
+	to.SendState(md.PeerID)
+
+	if !p.pubOnly {
+		if err := p.Subscriber.SubscribeRoom(md.PeerID, to); err != nil {
+			return err
+		}
+	}
+	to.Announce(p.createEvent(md.RoomID, sfu.EventType_JOIN_EVENT), nil)
+
+	p.Log.Info("moved room", "room", roomID)
+	return nil
+}
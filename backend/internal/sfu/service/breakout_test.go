@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+	"vidcall/pkg/config"
+)
+
+func TestCurrentRoom(t *testing.T) {
+	hub.Init(config.ICE{})
+
+	p := &PeerObj{
+		PeerObj: &domain.PeerObj{Metadata: &domain.PeerMD{RoomID: "current", PeerID: "alice"}},
+		config:  &config.SFU{Room: config.Room{JoinQueue: 1}},
+	}
+
+	if _, err := p.currentRoom(); err != domain.ErrNotFound {
+		t.Fatalf("currentRoom() before join = %v, want %v", err, domain.ErrNotFound)
+	}
+	if hub.Hub().GetRoom("current") != nil {
+		t.Fatal("currentRoom() created the room")
+	}
+
+	r := p.joinRoom()
+	if got, err := p.currentRoom(); err != nil || got != r {
+		t.Fatalf("currentRoom() after join = %v, %v, want the joined room", got, err)
+	}
+	if again := p.joinRoom(); again != r {
+		t.Fatal("joinRoom() created a second room")
+	}
+
+	// an ended room is not brought back by a late action
+	r.Close()
+	if _, err := p.currentRoom(); err != domain.ErrNotFound {
+		t.Fatalf("currentRoom() after close = %v, want %v", err, domain.ErrNotFound)
+	}
+	if _, err := p.mainRoom(); err != domain.ErrNotFound {
+		t.Fatalf("mainRoom() after close = %v, want %v", err, domain.ErrNotFound)
+	}
+	if hub.Hub().GetRoom("current") != nil {
+		t.Fatal("room is still in the hub after close")
+	}
+}
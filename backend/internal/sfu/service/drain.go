@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+)
+
+// rooms are checked this often while draining
+const drainPoll = time.Second
+
+// Drain stops new peers from joining and tells every room the server is
+// going away so clients can move elsewhere. It returns once no peer is
+// left, rooms still in use when ctx ends are ended
+func Drain(ctx context.Context, log *slog.Logger) {
+	h := hub.Hub()
+	h.Drain()
+
+	drainingE := &sfu.Event{Type: sfu.EventType_SERVER_DRAINING}
+	if deadline, ok := ctx.Deadline(); ok {
+		endsAt := deadline.UnixMilli()
+		drainingE.EndsAt = &endsAt
+	}
+
+	for _, r := range h.ListRooms() {
+		r.BroadCast("", &sfu.PeerSignal_Event{Event: drainingE})
+	}
+
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+
+	for {
+		n := peerCount()
+		if n == 0 {
+			log.Info("rooms drained")
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Warn("drain timed out, ending rooms", "peers", n)
+			endRooms()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// helper function to count the peers of every room
+func peerCount() int {
+	n := 0
+	for _, r := range hub.Hub().ListRooms() {
+		n += len(r.ListPeers())
+	}
+	return n
+}
+
+// helper function to end every room, breakouts end with their main room
+func endRooms() {
+	for id := range hub.Hub().ListRooms() {
+		if !domain.IsBreakout(id) {
+			// the host may have ended it meanwhile
+			_ = EndRoom(id)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+)
+
+// longest key blob relayed, public keys and wrapped sender keys are far
+// shorter
+const maxKeyBlob = 4096
+
+// relay key material of an end-to-end encrypted room to one peer, or to
+// the whole room for a public key without toID. The blob is not read
+func (p *PeerObj) handleKey(key *sfu.PeerSignal_Key) error {
+	k := key.Key
+	r, err := p.currentRoom()
+	if err != nil {
+		return err
+	}
+
+	if !r.E2EE() {
+		return domain.ErrNotAllowed
+	}
+
+	if k.Blob == "" || len(k.Blob) > maxKeyBlob || k.Type == sfu.KeyType_SENDER_KEY && k.GetToID() == "" {
+		return domain.ErrBadKey
+	}
+
+	relayed := &sfu.PeerSignal{Payload: &sfu.PeerSignal_Key{Key: &sfu.KeyExchange{
+		Type:   k.Type,
+		FromID: p.Metadata.PeerID,
+		ToID:   k.ToID,
+		Blob:   k.Blob,
+		Epoch:  k.Epoch,
+	}}}
+
+	if to := k.GetToID(); to != "" {
+		peer := r.GetPeer(to)
+		if peer == nil || to == p.Metadata.PeerID {
+			return domain.ErrBadKey
+		}
+
+		peer.EnqueueSend(relayed)
+		return nil
+	}
+
+	for id, peer := range r.ListPeers() {
+		if id != p.Metadata.PeerID {
+			peer.EnqueueSend(relayed)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package egress
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+)
+
+// publishers are checked this often, ffmpeg restarts when they change
+const membershipInterval = 2 * time.Second
+
+type EgressObj struct {
+	*domain.EgressObj
+	log *slog.Logger
+}
+
+var (
+	once   sync.Once
+	outDir string
+	ffmpeg string
+)
+
+// directory of the HLS output and ffmpeg binary, defaults are the temp
+// directory and ffmpeg from PATH
+func Init(dir string, bin string) {
+	once.Do(func() {
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "vidcall-egress")
+		}
+		if bin == "" {
+			bin = "ffmpeg"
+		}
+
+		outDir = dir
+		ffmpeg = bin
+	})
+}
+
+func NewEgress(roomID string, r domain.Room) domain.Egress {
+	Init("", "")
+
+	// breakout room IDs contain slashes
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(roomID)
+
+	return &EgressObj{
+		EgressObj: &domain.EgressObj{
+			Room:     r,
+			RoomID:   roomID,
+			Playlist: filepath.Join(outDir, name, "index.m3u8"),
+		},
+		log: slog.Default().With("layer", "egress", "room ID", roomID),
+	}
+}
+
+func (e *EgressObj) Start(cfg domain.EgressConfig) error {
+	if err := validate(cfg); err != nil {
+		return err
+	}
+
+	e.Mu.Lock()
+	defer e.Mu.Unlock()
+
+	if e.Active {
+		return domain.ErrEgressRunning
+	}
+
+	if !e.Room.IsLive() {
+		return domain.ErrRoomNotLive
+	}
+
+	// ffmpeg cannot decode encrypted frames
+	if e.Room.E2EE() {
+		return domain.ErrE2EE
+	}
+
+	// room IDs of "." or ".." would leave the output directory
+	if filepath.Dir(filepath.Dir(e.Playlist)) != filepath.Clean(outDir) {
+		return domain.ErrBadEgress
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.Playlist), 0o755); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e.Config = cfg
+	e.Active = true
+	e.Started = time.Now()
+	e.Peers = nil
+	e.Cancel = cancel
+	e.Done = make(chan struct{})
+
+	go e.run(ctx, cfg, e.Done)
+
+	e.Room.BroadCast("", e.event(sfu.EventType_EGRESS_STARTED))
+	e.log.Info("egress started", "layout", cfg.Layout.String(), "rtmp", cfg.RTMPURL != "")
+
+	return nil
+}
+
+// stop the stream and wait for ffmpeg to finish the playlist
+func (e *EgressObj) Stop() error {
+	e.Mu.Lock()
+	// Cancel is cleared by a stop in progress
+	if !e.Active || e.Cancel == nil {
+		e.Mu.Unlock()
+		return domain.ErrNoEgress
+	}
+
+	e.Cancel()
+	e.Cancel = nil
+	done := e.Done
+	e.Mu.Unlock()
+
+	// still active until ffmpeg is gone, a new stream would share the
+	// playlist
+	<-done
+
+	e.Mu.Lock()
+	e.Active = false
+	e.Mu.Unlock()
+
+	e.Room.BroadCast("", e.event(sfu.EventType_EGRESS_STOPPED))
+	e.log.Info("egress stopped")
+
+	return nil
+}
+
+func (e *EgressObj) Info() *sfu.EgressInfo {
+	e.Mu.Lock()
+	defer e.Mu.Unlock()
+
+	info := &sfu.EgressInfo{
+		RoomID:   e.RoomID,
+		Layout:   e.Config.Layout,
+		Active:   e.Active,
+		Playlist: e.Playlist,
+		Peers:    slices.Clone(e.Peers),
+		Rtmp:     e.Config.RTMPURL != "",
+	}
+	if !e.Started.IsZero() {
+		info.StartedAt = e.Started.UnixMilli()
+	}
+
+	return info
+}
+
+// keep ffmpeg fed with the publishers the layout asks for
+func (e *EgressObj) run(ctx context.Context, cfg domain.EgressConfig, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(membershipInterval)
+	defer ticker.Stop()
+
+	var cur *pipeline
+	defer func() {
+		if cur != nil {
+			cur.stop()
+		}
+	}()
+
+	for {
+		srcs := e.sources(cfg)
+
+		if cur == nil || !cur.serves(srcs) {
+			if cur != nil {
+				cur.stop()
+				cur = nil
+			}
+
+			if len(srcs) > 0 {
+				p, err := startPipeline(cfg, srcs, e.Playlist, e.log)
+				if err != nil {
+					e.log.Error("unable to start ffmpeg", "err", err)
+				} else {
+					cur = p
+				}
+			}
+
+			e.setPeers(srcs)
+		}
+
+		var exited chan struct{}
+		if cur != nil {
+			exited = cur.exited
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-exited:
+			// restarted on the next tick
+			e.log.Warn("ffmpeg exited")
+			cur.stop()
+			cur = nil
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishers in the picture, in a stable order
+func (e *EgressObj) sources(cfg domain.EgressConfig) []source {
+	peers := e.Room.ListPeers()
+
+	ids := make([]string, 0, len(peers))
+	for id := range peers {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	if cfg.Layout == sfu.EgressLayout_SPEAKER {
+		target := cfg.TargetID
+		if target == "" {
+			for _, id := range ids {
+				if peers[id].GetMetaData().Role == sfu.RoleType_ROLE_HOST {
+					target = id
+					break
+				}
+			}
+		}
+
+		ids = slices.DeleteFunc(ids, func(id string) bool { return id != target })
+	}
+
+	srcs := []source{}
+	for _, id := range ids {
+		pub := peers[id].Pub()
+		av := pub.Tracks()
+
+		// a tile needs a picture
+		if av.Video == nil {
+			continue
+		}
+
+		srcs = append(srcs, source{peerID: id, pub: pub, audio: av.Audio, video: av.Video})
+		if len(srcs) == maxTiles {
+			break
+		}
+	}
+
+	return srcs
+}
+
+func (e *EgressObj) setPeers(srcs []source) {
+	e.Mu.Lock()
+	defer e.Mu.Unlock()
+
+	e.Peers = e.Peers[:0]
+	for _, src := range srcs {
+		e.Peers = append(e.Peers, src.peerID)
+	}
+}
+
+func (e *EgressObj) event(t sfu.EventType) *sfu.PeerSignal_Event {
+	roomID := e.RoomID
+	return &sfu.PeerSignal_Event{
+		Event: &sfu.Event{Type: t, RoomID: &roomID},
+	}
+}
+
+// helper function to check a stream request before anything is started
+func validate(cfg domain.EgressConfig) error {
+	if _, ok := sfu.EgressLayout_name[int32(cfg.Layout)]; !ok {
+		return domain.ErrBadEgress
+	}
+
+	if cfg.RTMPURL == "" {
+		return nil
+	}
+
+	// | and [ ] are the separators of the ffmpeg tee muxer
+	if !strings.HasPrefix(cfg.RTMPURL, "rtmp://") && !strings.HasPrefix(cfg.RTMPURL, "rtmps://") ||
+		strings.ContainsAny(cfg.RTMPURL, "|[] \n") {
+		return domain.ErrBadEgress
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package egress
+
+import (
+	"fmt"
+	"strings"
+	"vidcall/internal/sfu/domain"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	maxTiles = 9
+	width    = 1280
+	height   = 720
+
+	hlsTime     = 2
+	hlsListSize = 10
+)
+
+// an SDP file given to ffmpeg, its streams are 0:v and 0:a
+type input struct {
+	sdp   string
+	audio bool
+}
+
+// session description of one publisher, ffmpeg receives on the given
+// loopback ports
+func sessionSDP(src source, ports map[*webrtc.TrackRemote]int) string {
+	var b strings.Builder
+	b.WriteString("v=0\r\n")
+	b.WriteString("o=- 0 0 IN IP4 127.0.0.1\r\n")
+	fmt.Fprintf(&b, "s=%s\r\n", src.peerID)
+	b.WriteString("c=IN IP4 127.0.0.1\r\n")
+	b.WriteString("t=0 0\r\n")
+
+	for _, remote := range []*webrtc.TrackRemote{src.video, src.audio} {
+		if remote == nil {
+			continue
+		}
+
+		codec := remote.Codec()
+		pt := uint8(remote.PayloadType())
+		name, _ := strings.CutPrefix(codec.MimeType, remote.Kind().String()+"/")
+
+		fmt.Fprintf(&b, "m=%s %d RTP/AVP %d\r\n", remote.Kind().String(), ports[remote], pt)
+		if codec.Channels > 0 {
+			fmt.Fprintf(&b, "a=rtpmap:%d %s/%d/%d\r\n", pt, name, codec.ClockRate, codec.Channels)
+		} else {
+			fmt.Fprintf(&b, "a=rtpmap:%d %s/%d\r\n", pt, name, codec.ClockRate)
+		}
+		if codec.SDPFmtpLine != "" {
+			fmt.Fprintf(&b, "a=fmtp:%d %s\r\n", pt, codec.SDPFmtpLine)
+		}
+		b.WriteString("a=recvonly\r\n")
+	}
+
+	return b.String()
+}
+
+// ffmpeg command line: decode the inputs, lay them out and encode to HLS,
+// teed to RTMP when asked
+func ffmpegArgs(cfg domain.EgressConfig, inputs []input, playlist string) []string {
+	args := []string{"-hide_banner", "-loglevel", "warning", "-nostdin"}
+
+	for _, in := range inputs {
+		args = append(args,
+			"-protocol_whitelist", "file,udp,rtp",
+			"-reorder_queue_size", "512",
+			"-i", in.sdp,
+		)
+	}
+
+	filter, hasAudio := layoutFilter(inputs)
+	args = append(args, "-filter_complex", filter, "-map", "[vout]")
+	if hasAudio {
+		args = append(args, "-map", "[aout]")
+	}
+
+	args = append(args,
+		"-c:v", "libx264", "-preset", "veryfast", "-tune", "zerolatency",
+		"-pix_fmt", "yuv420p", "-r", "30", "-g", "60", "-keyint_min", "60", "-sc_threshold", "0",
+		"-b:v", "2500k", "-maxrate", "2500k", "-bufsize", "5000k",
+	)
+	if hasAudio {
+		args = append(args, "-c:a", "aac", "-b:a", "128k", "-ar", "48000", "-ac", "2")
+	}
+
+	// segments are named after the playlist, next to it. A restart
+	// continues the playlist after a discontinuity
+	hlsFlags := "delete_segments+append_list+discont_start+omit_endlist"
+
+	if cfg.RTMPURL == "" {
+		return append(args,
+			"-f", "hls",
+			"-hls_time", fmt.Sprint(hlsTime),
+			"-hls_list_size", fmt.Sprint(hlsListSize),
+			"-hls_flags", hlsFlags,
+			playlist,
+		)
+	}
+
+	hls := fmt.Sprintf("[f=hls:hls_time=%d:hls_list_size=%d:hls_flags=%s]%s",
+		hlsTime, hlsListSize, hlsFlags, playlist)
+	rtmp := "[f=flv:onfail=ignore]" + cfg.RTMPURL
+
+	return append(args, "-flags", "+global_header", "-f", "tee", hls+"|"+rtmp)
+}
+
+// grid of the inputs into [vout] and their audio mixed into [aout]
+func layoutFilter(inputs []input) (string, bool) {
+	cols := 1
+	for cols*cols < len(inputs) {
+		cols++
+	}
+	rows := (len(inputs) + cols - 1) / cols
+
+	// even sizes for yuv420p
+	tileW := width / cols &^ 1
+	tileH := height / rows &^ 1
+
+	chains := []string{}
+	layout := []string{}
+	tiles := ""
+	for i := range inputs {
+		chains = append(chains, fmt.Sprintf(
+			"[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=30[v%d]",
+			i, tileW, tileH, tileW, tileH, i))
+
+		layout = append(layout, fmt.Sprintf("%d_%d", i%cols*tileW, i/cols*tileH))
+		tiles += fmt.Sprintf("[v%d]", i)
+	}
+
+	if len(inputs) == 1 {
+		chains = append(chains, fmt.Sprintf("[v0]scale=%d:%d[vout]", width, height))
+	} else {
+		chains = append(chains, fmt.Sprintf("%sxstack=inputs=%d:layout=%s:fill=black,scale=%d:%d[vout]",
+			tiles, len(inputs), strings.Join(layout, "|"), width, height))
+	}
+
+	voices := ""
+	n := 0
+	for i, in := range inputs {
+		if !in.audio {
+			continue
+		}
+
+		chains = append(chains, fmt.Sprintf("[%d:a]aresample=async=1[a%d]", i, i))
+		voices += fmt.Sprintf("[a%d]", i)
+		n++
+	}
+
+	switch {
+	case n == 1:
+		chains = append(chains, voices+"anull[aout]")
+	case n > 1:
+		chains = append(chains, fmt.Sprintf("%samix=inputs=%d:duration=longest:dropout_transition=0[aout]", voices, n))
+	}
+
+	return strings.Join(chains, ";"), n > 0
+}
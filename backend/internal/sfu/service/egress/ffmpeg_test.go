@@ -0,0 +1,148 @@
+package egress
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"vidcall/internal/sfu/domain"
+)
+
+func TestLayoutFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []input
+		// parts the filter graph must have, in order
+		want     []string
+		hasAudio bool
+	}{
+		{
+			name:     "single speaker",
+			inputs:   []input{{audio: true}},
+			want:     []string{"[0:v]scale=1280:720:", "[v0]scale=1280:720[vout]", "[0:a]aresample=async=1[a0]", "[a0]anull[aout]"},
+			hasAudio: true,
+		},
+		{
+			name:   "video only",
+			inputs: []input{{}},
+			want:   []string{"[v0]scale=1280:720[vout]"},
+		},
+		{
+			name:     "side by side",
+			inputs:   []input{{audio: true}, {audio: true}},
+			want:     []string{"scale=640:720:", "[v0][v1]xstack=inputs=2:layout=0_0|640_0:", "[a0][a1]amix=inputs=2:"},
+			hasAudio: true,
+		},
+		{
+			name:     "two by two, one muted",
+			inputs:   []input{{audio: true}, {}, {audio: true}, {}},
+			want:     []string{"scale=640:360:", "layout=0_0|640_0|0_360|640_360:", "[a0][a2]amix=inputs=2:"},
+			hasAudio: true,
+		},
+		{
+			name:   "three columns, even tiles",
+			inputs: make([]input, 5),
+			want:   []string{"scale=426:360:", "layout=0_0|426_0|852_0|0_360|426_360:"},
+		},
+		{
+			name:   "full grid",
+			inputs: make([]input, maxTiles),
+			want:   []string{"scale=426:240:", "xstack=inputs=9:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, hasAudio := layoutFilter(tt.inputs)
+
+			if hasAudio != tt.hasAudio {
+				t.Errorf("hasAudio: got %v, want %v", hasAudio, tt.hasAudio)
+			}
+			if strings.Contains(filter, "[aout]") != tt.hasAudio {
+				t.Errorf("[aout] in %q, want it %v", filter, tt.hasAudio)
+			}
+
+			rest := filter
+			for _, part := range tt.want {
+				i := strings.Index(rest, part)
+				if i < 0 {
+					t.Fatalf("%q not found in order in %q", part, filter)
+				}
+				rest = rest[i+len(part):]
+			}
+		})
+	}
+}
+
+func TestFFmpegArgs(t *testing.T) {
+	inputs := []input{{sdp: "/tmp/a.sdp", audio: true}, {sdp: "/tmp/b.sdp"}}
+
+	tests := []struct {
+		name   string
+		cfg    domain.EgressConfig
+		inputs []input
+		// arguments that must follow each other
+		want [][]string
+		// arguments that must not be there
+		absent []string
+		last   string
+	}{
+		{
+			name:   "HLS",
+			inputs: inputs,
+			want: [][]string{
+				{"-i", "/tmp/a.sdp"},
+				{"-i", "/tmp/b.sdp"},
+				{"-map", "[vout]", "-map", "[aout]"},
+				{"-c:a", "aac"},
+				{"-f", "hls", "-hls_time", "2", "-hls_list_size", "10"},
+			},
+			absent: []string{"tee"},
+			last:   "/hls/index.m3u8",
+		},
+		{
+			name:   "no audio",
+			inputs: []input{{sdp: "/tmp/b.sdp"}},
+			want:   [][]string{{"-map", "[vout]", "-c:v", "libx264"}},
+			absent: []string{"[aout]", "-c:a"},
+			last:   "/hls/index.m3u8",
+		},
+		{
+			name:   "HLS and RTMP",
+			cfg:    domain.EgressConfig{RTMPURL: "rtmp://live.example.com/app/key"},
+			inputs: inputs,
+			want:   [][]string{{"-flags", "+global_header", "-f", "tee"}},
+			absent: []string{"hls"},
+			last:   "[f=hls:hls_time=2:hls_list_size=10:hls_flags=delete_segments+append_list+discont_start+omit_endlist]/hls/index.m3u8|[f=flv:onfail=ignore]rtmp://live.example.com/app/key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := ffmpegArgs(tt.cfg, tt.inputs, "/hls/index.m3u8")
+
+			for _, seq := range tt.want {
+				if !containsSeq(args, seq) {
+					t.Errorf("%q not in %q", seq, args)
+				}
+			}
+			for _, a := range tt.absent {
+				if slices.Contains(args, a) {
+					t.Errorf("%q in %q", a, args)
+				}
+			}
+			if got := args[len(args)-1]; got != tt.last {
+				t.Errorf("output: got %q, want %q", got, tt.last)
+			}
+		})
+	}
+}
+
+// helper function to find seq as consecutive arguments
+func containsSeq(args []string, seq []string) bool {
+	for i := range args {
+		if i+len(seq) <= len(args) && slices.Equal(args[i:i+len(seq)], seq) {
+			return true
+		}
+	}
+	return false
+}
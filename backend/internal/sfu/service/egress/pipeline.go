@@ -0,0 +1,216 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"vidcall/internal/sfu/domain"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	// time ffmpeg gets to write the last segment after SIGINT
+	stopTimeout = 5 * time.Second
+	// ffmpeg needs a moment to bind its ports before the keyframe is useful
+	keyframeDelay = time.Second
+)
+
+// publisher tracks of one input
+type source struct {
+	peerID string
+	pub    domain.Publisher
+	audio  *webrtc.TrackRemote
+	video  *webrtc.TrackRemote
+}
+
+// one ffmpeg run over a fixed set of publishers, RTP is sent to it over
+// loopback UDP and described by one SDP file per publisher
+type pipeline struct {
+	srcs   []source
+	cancel context.CancelFunc
+	exited chan struct{}
+	stops  []func()
+	conns  []*net.UDPConn
+	tmpDir string
+}
+
+func startPipeline(cfg domain.EgressConfig, srcs []source, playlist string, log *slog.Logger) (*pipeline, error) {
+	tmpDir, err := os.MkdirTemp("", "vidcall-egress-")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pipeline{srcs: srcs, exited: make(chan struct{}), tmpDir: tmpDir}
+
+	inputs := make([]input, 0, len(srcs))
+	ports := map[*webrtc.TrackRemote]int{}
+	taken := map[int]bool{}
+	for i, src := range srcs {
+		in := input{sdp: filepath.Join(tmpDir, fmt.Sprintf("input-%d.sdp", i)), audio: src.audio != nil}
+
+		for _, remote := range []*webrtc.TrackRemote{src.video, src.audio} {
+			if remote == nil {
+				continue
+			}
+
+			port, err := freePort(taken)
+			if err != nil {
+				p.cleanup()
+				return nil, err
+			}
+			ports[remote] = port
+			taken[port] = true
+		}
+
+		if err := os.WriteFile(in.sdp, []byte(sessionSDP(src, ports)), 0o600); err != nil {
+			p.cleanup()
+			return nil, err
+		}
+		inputs = append(inputs, in)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	cmd := exec.CommandContext(ctx, ffmpeg, ffmpegArgs(cfg, inputs, playlist)...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = stopTimeout
+	cmd.Stderr = &logWriter{log: log}
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		p.cleanup()
+		return nil, err
+	}
+
+	go func() {
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			log.Warn("ffmpeg failed", "err", err)
+		}
+		close(p.exited)
+	}()
+
+	for _, src := range srcs {
+		for _, remote := range []*webrtc.TrackRemote{src.video, src.audio} {
+			if remote == nil {
+				continue
+			}
+
+			conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: ports[remote]})
+			if err != nil {
+				p.stop()
+				return nil, err
+			}
+
+			q, stop := src.pub.Tap(remote.Kind())
+			p.conns = append(p.conns, conn)
+			p.stops = append(p.stops, stop)
+
+			go forward(q, conn)
+		}
+
+		pub := src.pub
+		time.AfterFunc(keyframeDelay, func() { pub.RequestKeyframe(domain.KeyframePLI) })
+	}
+
+	log.Info("ffmpeg started", "inputs", len(inputs))
+	return p, nil
+}
+
+// same publishers with the same tracks
+func (p *pipeline) serves(srcs []source) bool {
+	if len(p.srcs) != len(srcs) {
+		return false
+	}
+
+	for i := range srcs {
+		if p.srcs[i] != srcs[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// end ffmpeg first so it can finish the segment, then the taps
+func (p *pipeline) stop() {
+	p.cancel()
+	<-p.exited
+
+	for _, stop := range p.stops {
+		stop()
+	}
+	for _, conn := range p.conns {
+		_ = conn.Close()
+	}
+
+	p.cleanup()
+}
+
+func (p *pipeline) cleanup() {
+	_ = os.RemoveAll(p.tmpDir)
+}
+
+// copy the tapped packets to ffmpeg, until the tap is stopped
+func forward(q <-chan *rtp.Packet, conn *net.UDPConn) {
+	for pkt := range q {
+		buf, err := pkt.Marshal()
+		if err != nil {
+			continue
+		}
+
+		// refused until ffmpeg listens
+		_, _ = conn.Write(buf)
+	}
+}
+
+// even loopback port with a free odd neighbour, ffmpeg takes port+1 for
+// RTCP. The ports are only reserved once ffmpeg binds them
+func freePort(taken map[int]bool) (int, error) {
+	for range 20 {
+		rtpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			return 0, err
+		}
+
+		port := rtpConn.LocalAddr().(*net.UDPAddr).Port
+		if port%2 != 0 || taken[port] {
+			_ = rtpConn.Close()
+			continue
+		}
+
+		rtcpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port + 1})
+		_ = rtpConn.Close()
+		if err != nil {
+			continue
+		}
+		_ = rtcpConn.Close()
+
+		return port, nil
+	}
+
+	return 0, fmt.Errorf("no free UDP port pair")
+}
+
+// ffmpeg stderr in the SFU log
+type logWriter struct {
+	log *slog.Logger
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		if line != "" {
+			w.log.Warn("ffmpeg", "msg", line)
+		}
+	}
+
+	return len(b), nil
+}
@@ -2,25 +2,47 @@ package service
 
 import (
 	"fmt"
+	"time"
 	sfu "vidcall/api/proto"
-	"vidcall/internal/sfu/service/hub"
-	"vidcall/internal/sfu/service/room"
+	"vidcall/internal/sfu/domain"
+	"vidcall/pkg/logger"
+	"vidcall/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func (p *PeerObj) handleActions(act *sfu.PeerSignal_Action) error {
+// bytes, enough for an emoji with modifiers
+const maxReactionLen = 32
+
+func (p *PeerObj) handleActions(act *sfu.PeerSignal_Action) (err error) {
 	md := p.Metadata
-	r := hub.Hub().GetRoom(md.RoomID)
 
-	if r == nil {
-		r = room.NewRoom(md.RoomID)
+	ctx, span := tracing.Start(p.Ctx, "sfu.action "+act.Action.Type.String(),
+		attribute.String("peer.id", md.PeerID),
+		attribute.String("room.id", md.RoomID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	var r domain.Room
+	switch act.Action.Type {
+	case sfu.ActionType_START_ROOM, sfu.ActionType_JOIN:
+		r = p.joinRoom()
+	default:
+		if r, err = p.currentRoom(); err != nil {
+			return err
+		}
 	}
 
-	log := p.Log.With("handlers", "action", "peer ID", md.PeerID)
+	log := logger.WithSpan(ctx, p.Log.With("handlers", "action", "peer ID", md.PeerID))
 
 	switch act.Action.Type {
 	case sfu.ActionType_START_ROOM:
+		p.Publisher.SetCodecs(r.CodecPolicy())
+
 		if r.GetPeer(md.PeerID) == nil {
-			r.AddPeer(md.PeerID, p)
+			if err := r.AddPeer(md.PeerID, p); err != nil {
+				return err
+			}
 		}
 
 		if !r.IsLive() && md.Role == sfu.RoleType_ROLE_HOST {
@@ -31,13 +53,18 @@ func (p *PeerObj) handleActions(act *sfu.PeerSignal_Action) error {
 
 			log.Info("host start room")
 		}
+		r.SendState(md.PeerID)
 
 		return nil
 
 	case sfu.ActionType_JOIN:
 		fmt.Println(md.PeerID, "joining")
+		p.Publisher.SetCodecs(r.CodecPolicy())
+
 		if r.GetPeer(md.PeerID) == nil {
-			r.AddPeer(md.PeerID, p)
+			if err := r.AddPeer(md.PeerID, p); err != nil {
+				return err
+			}
 		}
 
 		// room is not live
@@ -49,13 +76,16 @@ func (p *PeerObj) handleActions(act *sfu.PeerSignal_Action) error {
 			roomActiveE := p.createEvent(md.RoomID, sfu.EventType_ROOM_ACTIVE)
 			p.EnqueueSend(&sfu.PeerSignal{Payload: roomActiveE})
 		}
+		// snapshot first, later events are numbered after it
+		r.SendState(md.PeerID)
+
 		if err := p.Subscriber.SubscribeRoom(md.PeerID, r); err != nil {
 			return err
 		}
 		fmt.Println(md.PeerID, "subcribed to room")
 		// create event and broadcast
 		joinE := p.createEvent(md.RoomID, sfu.EventType_JOIN_EVENT)
-		r.BroadCast(md.PeerID, joinE)
+		r.Announce(joinE, nil)
 		log.Info("guest join room")
 
 		return nil
@@ -68,7 +98,7 @@ func (p *PeerObj) handleActions(act *sfu.PeerSignal_Action) error {
 		if r.IsLive() {
 			// create event and broadcast
 			leaveE := p.createEvent(md.RoomID, sfu.EventType_LEAVE_EVENT)
-			r.BroadCast(md.PeerID, leaveE)
+			r.Announce(leaveE, nil)
 
 			// trigger context to disconnect pc
 			p.Cancel()
@@ -78,56 +108,322 @@ func (p *PeerObj) handleActions(act *sfu.PeerSignal_Action) error {
 		return nil
 
 	case sfu.ActionType_END_ROOM:
-		if r.IsLive() && md.Role == sfu.RoleType_ROLE_HOST {
-			r.Close()
-			// create end room event
-			endRoomE := p.createEvent(md.RoomID, sfu.EventType_ROOM_ENDED)
-			r.BroadCast(md.PeerID, endRoomE)
-
-			// trigger to disconnect pc
-			p.Cancel()
-			log.Info("Action: end room")
+		if md.Role != sfu.RoleType_ROLE_HOST {
+			return domain.ErrNotAllowed
+		}
 
-			return nil
+		// the host may be in a breakout room
+		if r, err = p.mainRoom(); err != nil {
+			return err
+		}
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
 		}
 
-	case sfu.ActionType_AUDIO_ON:
-		if r.IsLive() {
-			audioOnE := p.createEvent(md.RoomID, sfu.EventType_AUDIO_ENABLED)
-			r.BroadCast(md.PeerID, audioOnE)
+		// create end room event, before the room context is gone
+		endRoomE := p.createEvent(md.RoomID, sfu.EventType_ROOM_ENDED)
+		r.BroadCast(md.PeerID, endRoomE)
+		r.Close()
+
+		// trigger to disconnect pc
+		p.Cancel()
+		log.Info("Action: end room")
 
-			log.Info("Action: audio enabled")
+	case sfu.ActionType_AUDIO_ON:
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
 		}
-	case sfu.ActionType_AUDIO_OFF:
-		if r.IsLive() {
-			audioOffE := p.createEvent(md.RoomID, sfu.EventType_AUDIO_DISABLED)
-			r.BroadCast(md.PeerID, audioOffE)
+		audioOnE := p.createEvent(md.RoomID, sfu.EventType_AUDIO_ENABLED)
+		r.Announce(audioOnE, func(s *domain.PeerState) { s.Audio = true })
+
+		log.Info("Action: audio enabled")
 
-			log.Info("Action: audio disabled")
+	case sfu.ActionType_AUDIO_OFF:
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
 		}
-	case sfu.ActionType_VIDEO_ON:
-		if r.IsLive() {
-			videoOnE := p.createEvent(md.RoomID, sfu.EventType_VIDEO_ENABLED)
-			r.BroadCast(md.PeerID, videoOnE)
+		audioOffE := p.createEvent(md.RoomID, sfu.EventType_AUDIO_DISABLED)
+		r.Announce(audioOffE, func(s *domain.PeerState) { s.Audio = false })
+
+		log.Info("Action: audio disabled")
 
-			log.Info("Action: video enabled")
+	case sfu.ActionType_VIDEO_ON:
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
 		}
+		videoOnE := p.createEvent(md.RoomID, sfu.EventType_VIDEO_ENABLED)
+		r.Announce(videoOnE, func(s *domain.PeerState) { s.Video = true })
+
+		log.Info("Action: video enabled")
 
 	case sfu.ActionType_VIDEO_OFF:
-		if r.IsLive() {
-			videoOffE := p.createEvent(md.RoomID, sfu.EventType_VIDEO_DISABLED)
-			r.BroadCast(md.PeerID, videoOffE)
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
+		}
+		videoOffE := p.createEvent(md.RoomID, sfu.EventType_VIDEO_DISABLED)
+		r.Announce(videoOffE, func(s *domain.PeerState) { s.Video = false })
+
+		log.Info("Action: video disabled")
 
-			log.Info("Action: video disabled")
+	case sfu.ActionType_SCREEN_SHARE_ON:
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
+		}
+		shareE := p.createEvent(md.RoomID, sfu.EventType_SCREEN_SHARE_STARTED)
+		r.Announce(shareE, func(s *domain.PeerState) { s.ScreenShare = true })
+
+		log.Info("Action: screen share started")
+
+	case sfu.ActionType_SCREEN_SHARE_OFF:
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
 		}
-	case sfu.ActionType_DUBBING_ON:
-	case sfu.ActionType_DUBBING_OFF:
+		shareE := p.createEvent(md.RoomID, sfu.EventType_SCREEN_SHARE_STOPPED)
+		r.Announce(shareE, func(s *domain.PeerState) { s.ScreenShare = false })
+
+		log.Info("Action: screen share stopped")
+
+	case sfu.ActionType_RAISE_HAND:
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
+		}
+		if r.RaiseHand(p.createEvent(md.RoomID, sfu.EventType_HAND_RAISED)) {
+			log.Info("Action: hand raised")
+		}
+
+	case sfu.ActionType_LOWER_HAND:
+		if r.LowerHand(p.createEvent(md.RoomID, sfu.EventType_HAND_LOWERED)) {
+			log.Info("Action: hand lowered")
+		}
+
+	case sfu.ActionType_CALL_NEXT:
+		if md.Role != sfu.RoleType_ROLE_HOST {
+			return domain.ErrNotAllowed
+		}
+		if peerID, ok := r.CallNext(); ok {
+			log.Info("Action: called on peer", "target", peerID)
+		}
+
+	case sfu.ActionType_GET_HAND_QUEUE:
+		if md.Role != sfu.RoleType_ROLE_HOST {
+			return domain.ErrNotAllowed
+		}
+		r.SendHandQueue(md.PeerID)
+
+	case sfu.ActionType_REACT:
+		if !r.IsLive() {
+			return domain.ErrRoomNotLive
+		}
+
+		emoji := act.Action.GetEmoji()
+		if emoji == "" || len(emoji) > maxReactionLen {
+			return domain.ErrBadReaction
+		}
+		if !p.reactions.Allow() {
+			return domain.ErrRateLimited
+		}
+
+		reactionE := p.createEvent(md.RoomID, sfu.EventType_REACTION)
+		reactionE.Event.Emoji = &emoji
+		r.BroadCast(md.PeerID, reactionE)
+
+	case sfu.ActionType_BREAKOUT_OPEN:
+		b, err := p.hostBreakouts()
+		if err != nil {
+			return err
+		}
+
+		duration := time.Duration(act.Action.GetDurationSec()) * time.Second
+		ids, err := b.Open(int(act.Action.GetCount()), duration, act.Action.GetRandom())
+		if err != nil {
+			return err
+		}
+		log.Info("Action: breakouts opened", "rooms", len(ids), "duration", duration)
+
+	case sfu.ActionType_BREAKOUT_ASSIGN:
+		b, err := p.hostBreakouts()
+		if err != nil {
+			return err
+		}
+
+		if err := b.Assign(act.Action.GetTargetID(), act.Action.GetRoomID()); err != nil {
+			return err
+		}
+		log.Info("Action: breakout assigned", "target", act.Action.GetTargetID(), "room", act.Action.GetRoomID())
+
+	case sfu.ActionType_BREAKOUT_BROADCAST:
+		b, err := p.hostBreakouts()
+		if err != nil {
+			return err
+		}
+
+		if err := b.Broadcast(act.Action.GetMessage()); err != nil {
+			return err
+		}
+		log.Info("Action: breakout message sent")
+
+	case sfu.ActionType_BREAKOUT_RECALL:
+		b, err := p.hostBreakouts()
+		if err != nil {
+			return err
+		}
+
+		if err := b.Recall(); err != nil {
+			return err
+		}
+		log.Info("Action: breakouts recalled")
+
+	case sfu.ActionType_EGRESS_START:
+		e, err := p.hostEgress()
+		if err != nil {
+			return err
+		}
+
+		cfg := domain.EgressConfig{
+			Layout:   act.Action.GetLayout(),
+			TargetID: act.Action.GetTargetID(),
+			RTMPURL:  act.Action.GetRtmpURL(),
+		}
+		if err := e.Start(cfg); err != nil {
+			return err
+		}
+		log.Info("Action: egress started", "layout", cfg.Layout.String())
+
+	case sfu.ActionType_EGRESS_STOP:
+		e, err := p.hostEgress()
+		if err != nil {
+			return err
+		}
+
+		if err := e.Stop(); err != nil {
+			return err
+		}
+		log.Info("Action: egress stopped")
+
+	case sfu.ActionType_TRANSCRIPTION_START:
+		t, err := p.hostTranscriber()
+		if err != nil {
+			return err
+		}
+
+		if err := t.Start(); err != nil {
+			return err
+		}
+		log.Info("Action: transcription started")
+
+	case sfu.ActionType_TRANSCRIPTION_STOP:
+		t, err := p.hostTranscriber()
+		if err != nil {
+			return err
+		}
+
+		if err := t.Stop(); err != nil {
+			return err
+		}
+		log.Info("Action: transcription stopped")
+
+	case sfu.ActionType_SYNC_STATE:
+		r.SendState(md.PeerID)
+		log.Info("Action: room state resync")
+
+	case sfu.ActionType_PAUSE_VIDEO:
+		target := act.Action.GetTargetID()
+		if err := p.Subscriber.PauseVideo(target); err != nil {
+			log.Warn("unable to pause video", "target", target, "err", err)
+			return err
+		}
+		log.Info("Action: video paused", "target", target)
+
+	case sfu.ActionType_RESUME_VIDEO:
+		target := act.Action.GetTargetID()
+		if err := p.Subscriber.ResumeVideo(target); err != nil {
+			log.Warn("unable to resume video", "target", target, "err", err)
+			return err
+		}
+		log.Info("Action: video resumed", "target", target)
+
+	case sfu.ActionType_SET_MAX_LAYER:
+		layer := domain.LayerAll
+		if act.Action.GetMaxLayer() == sfu.VideoLayer_LAYER_BASE {
+			layer = domain.LayerBase
+		}
+
+		target := act.Action.GetTargetID()
+		if err := p.Subscriber.SetMaxLayer(target, layer); err != nil {
+			log.Warn("unable to set max layer", "target", target, "err", err)
+			return err
+		}
+		log.Info("Action: max layer set", "target", target, "layer", layer.String())
+
+	case sfu.ActionType_AUDIO_ONLY_ON:
+		p.Subscriber.SetAudioOnly(true)
+		log.Info("Action: audio only on")
+
+	case sfu.ActionType_AUDIO_ONLY_OFF:
+		p.Subscriber.SetAudioOnly(false)
+		log.Info("Action: audio only off")
+
+	case sfu.ActionType_DUBBING_ON, sfu.ActionType_DUBBING_OFF:
+		return domain.ErrNotImplemented
+
 	default:
+		return domain.ErrUnknownAction
 	}
 
 	return nil
 }
 
+// answer a client action, refused actions are reported back and only
+// internal errors end the session
+func (p *PeerObj) answerAction(requestID string, err error) error {
+	if err == nil {
+		p.EnqueueSend(&sfu.PeerSignal{Payload: &sfu.PeerSignal_Ack{
+			Ack: &sfu.Ack{RequestID: requestID},
+		}})
+		return nil
+	}
+
+	code := errorCode(err)
+	msg := err.Error()
+	if code == sfu.ErrorCode_INTERNAL {
+		msg = "internal error"
+	}
+
+	p.EnqueueSend(&sfu.PeerSignal{Payload: &sfu.PeerSignal_Error{
+		Error: &sfu.Error{RequestID: requestID, Code: code, Message: msg},
+	}})
+
+	if code == sfu.ErrorCode_INTERNAL {
+		return err
+	}
+	return nil
+}
+
+// helper function to map an action error to its protocol code
+func errorCode(err error) sfu.ErrorCode {
+	switch err {
+	case domain.ErrUnknownAction:
+		return sfu.ErrorCode_UNKNOWN_ACTION
+	case domain.ErrNotImplemented, domain.ErrNoSpeechToText, domain.ErrNoOpus:
+		return sfu.ErrorCode_NOT_IMPLEMENTED
+	case domain.ErrNotAllowed, domain.ErrE2EE:
+		return sfu.ErrorCode_NOT_ALLOWED
+	case domain.ErrRoomNotLive, domain.ErrNotFound, domain.ErrRoomClosed:
+		return sfu.ErrorCode_ROOM_NOT_LIVE
+	case domain.ErrNotSubscribed:
+		return sfu.ErrorCode_NOT_SUBSCRIBED
+	case domain.ErrRateLimited:
+		return sfu.ErrorCode_RATE_LIMITED
+	case domain.ErrBadReaction, domain.ErrBadBreakout, domain.ErrUnknownBreakout,
+		domain.ErrNoBreakouts, domain.ErrBreakoutsOpen,
+		domain.ErrBadEgress, domain.ErrEgressRunning, domain.ErrNoEgress,
+		domain.ErrTranscribing, domain.ErrNoTranscription, domain.ErrBadKey:
+		return sfu.ErrorCode_BAD_REQUEST
+	default:
+		return sfu.ErrorCode_INTERNAL
+	}
+}
+
 func (p *PeerObj) handleEvents(evt *sfu.PeerSignal_Event) error {
 
 	md := p.Metadata
@@ -144,10 +440,12 @@ func (p *PeerObj) handleEvents(evt *sfu.PeerSignal_Event) error {
 		log.Info("room inactive event")
 
 	case sfu.EventType_JOIN_EVENT:
-		r := hub.Hub().GetRoom(md.RoomID)
-		_ = r.GetPeer(md.PeerID)
+		r, err := p.currentRoom()
+		if err != nil {
+			return nil
+		}
 
-		if evt.Event.PeerID != md.PeerID {
+		if p.subscribesTo(evt.Event.PeerID) {
 			peer := r.GetPeer(evt.Event.PeerID)
 			if peer == nil {
 				p.Log.Error("peer does not exist")
@@ -166,7 +464,7 @@ func (p *PeerObj) handleEvents(evt *sfu.PeerSignal_Event) error {
 
 	case sfu.EventType_LEAVE_EVENT:
 
-		if evt.Event.PeerID != md.PeerID {
+		if p.subscribesTo(evt.Event.PeerID) {
 			if err := p.Subscriber.Unsubscribe(evt.Event.PeerID); err != nil {
 				return err
 			}
@@ -191,11 +489,55 @@ func (p *PeerObj) handleEvents(evt *sfu.PeerSignal_Event) error {
 	case sfu.EventType_VIDEO_DISABLED:
 		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
 		log.Info("video disabled event")
+	case sfu.EventType_SCREEN_SHARE_STARTED, sfu.EventType_SCREEN_SHARE_STOPPED:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("screen share event")
+	case sfu.EventType_HAND_RAISED, sfu.EventType_HAND_LOWERED, sfu.EventType_CALLED_ON:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("hand event", "target", evt.Event.PeerID)
+	case sfu.EventType_REACTION, sfu.EventType_HAND_QUEUE:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+	case sfu.EventType_BREAKOUT_MOVED:
+		if err := p.moveTo(evt.Event.GetRoomID()); err != nil {
+			return err
+		}
+
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("breakout moved event", "room", evt.Event.GetRoomID())
+	case sfu.EventType_BREAKOUTS_OPENED, sfu.EventType_BREAKOUTS_CLOSED, sfu.EventType_BREAKOUT_MESSAGE:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("breakout event")
+	case sfu.EventType_EGRESS_STARTED, sfu.EventType_EGRESS_STOPPED:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("egress event")
+	case sfu.EventType_TRANSCRIPTION_STARTED, sfu.EventType_TRANSCRIPTION_STOPPED:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("transcription event")
+	case sfu.EventType_CAPTION:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+	case sfu.EventType_KEY_ROTATE:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("key rotate event", "epoch", evt.Event.GetKeyEpoch())
+	case sfu.EventType_ROOM_STATE:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("room state event", "seq", evt.Event.Seq)
+	case sfu.EventType_SERVER_DRAINING:
+		p.EnqueueSend(&sfu.PeerSignal{Payload: evt})
+		log.Info("server draining event")
 	default:
 	}
 	return nil
 }
 
+// helper function to check whether the peer receives a publisher
+func (p *PeerObj) subscribesTo(peerID string) bool {
+	if p.pubOnly || peerID == p.Metadata.PeerID {
+		return false
+	}
+
+	return p.target == "" || p.target == peerID
+}
+
 // helper funciton to create event
 func (p *PeerObj) createEvent(_ string, e sfu.EventType) *sfu.PeerSignal_Event {
 	return &sfu.PeerSignal_Event{
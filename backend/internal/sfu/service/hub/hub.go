@@ -3,6 +3,7 @@ package hub
 import (
 	"sync"
 	"vidcall/internal/sfu/domain"
+	"vidcall/pkg/config"
 )
 
 type HubObj struct {
@@ -14,10 +15,11 @@ var (
 	hub  *domain.HubObj
 )
 
-func Init() {
+func Init(c config.ICE) {
 	once.Do(func() {
 		hub = &domain.HubObj{
-			Stuns: []string{"stun:stun.l.google.com:19302"},
+			Stuns: c.Stuns,
+			Turn:  c.Turn,
 			Rooms: make(map[string]domain.Room),
 		}
 	})
@@ -67,3 +69,28 @@ func (h *HubObj) GetRoom(roomID string) domain.Room {
 
 	return v
 }
+
+func (h *HubObj) ListRooms() map[string]domain.Room {
+	h.Mu.RLock()
+	defer h.Mu.RUnlock()
+
+	rooms := make(map[string]domain.Room, len(h.Rooms))
+	for id, room := range h.Rooms {
+		rooms[id] = room
+	}
+
+	return rooms
+}
+
+// stop accepting peers, the rooms in progress carry on
+func (h *HubObj) Drain() {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	h.Draining = true
+}
+
+func (h *HubObj) IsDraining() bool {
+	h.Mu.RLock()
+	defer h.Mu.RUnlock()
+	return h.Draining
+}
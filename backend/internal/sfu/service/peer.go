@@ -4,74 +4,72 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"sync"
 	"time"
 
 	sfu "vidcall/api/proto"
 	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/metrics"
+	"vidcall/internal/sfu/service/hub"
 	"vidcall/internal/sfu/service/rtc"
+	"vidcall/pkg/config"
 
 	"golang.org/x/sync/errgroup"
-	"google.golang.org/grpc/metadata"
+	"golang.org/x/time/rate"
 )
 
 type PeerObj struct {
 	*domain.PeerObj
+
+	reactions *rate.Limiter
+	// settings of the rooms the peer creates
+	config *config.SFU
+
+	// publisher-only peers (WHIP) subscribe to no one, viewers (WHEP) only
+	// subscribe, to everyone or to target
+	pubOnly  bool
+	viewOnly bool
+	target   string
+
+	// breakout room the peer was moved to, nil in the main room
+	roomMu   sync.Mutex
+	breakout domain.Room
+
+	// closed when the host kicks the peer, Signal ends its stream
+	kicked   chan struct{}
+	kickOnce sync.Once
 }
 
-func NewPeer(ctx context.Context, stream sfu.SFU_SignalServer, poolSize int, log *slog.Logger) (domain.Peer, error) {
+const (
+	reactionInterval = time.Second
+	reactionBurst    = 5
+)
+
+func NewPeer(ctx context.Context, stream sfu.SFU_SignalServer, peermd *domain.PeerMD, poolSize int, c *config.SFU, log *slog.Logger) (domain.Peer, error) {
 	log = log.With("layer", "service")
 
 	// Create channel to send msg and events
-	sendQ := make(chan *sfu.PeerSignal, 64)
-	eventQ := make(chan *sfu.PeerSignal_Event, 64)
+	sendQ := make(chan *sfu.PeerSignal, c.Peer.Queue)
+	eventQ := make(chan *sfu.PeerSignal_Event, c.Peer.Queue)
 
-	duration := time.Duration(50 * time.Millisecond)
-
-	pub, err := rtc.NewPublisher(ctx, sendQ, log, duration)
+	pub, err := rtc.NewPublisher(ctx, sendQ, log, c)
 	if err != nil {
 		return nil, err
 	}
 
-	sub, err := rtc.NewSubscriber(ctx, sendQ, log, poolSize, duration)
+	sub, err := rtc.NewSubscriber(ctx, peermd.PeerID, sendQ, log, poolSize, peermd.MixAudio, c)
 	if err != nil {
 		return nil, err
 	}
 
-	// peer metadata
-	md, _ := metadata.FromIncomingContext(ctx)
-
-	get_md := func(v []string) string {
-		if len(v) > 0 {
-			return v[0]
-		} else {
-			return ""
-		}
-	}
-
-	var r sfu.RoleType
-	mdRole := get_md(md.Get("role"))
-	if mdRole == "host" {
-		r = sfu.RoleType_ROLE_HOST
-	} else if mdRole == "guest" {
-		r = sfu.RoleType_ROLE_GUEST
-	} else if mdRole == "bot" {
-		r = sfu.RoleType_ROLE_BOT
-	}
-
-	peermd := &domain.PeerMD{
-		Name:   get_md(md.Get("name")),
-		PeerID: get_md(md.Get("peer-id")),
-		RoomID: get_md(md.Get("room-id")),
-		Role:   r,
-	}
-
 	// wire call backs
 	pub.WireCallBacks(peermd.PeerID)
 	sub.WireCallBacks()
 
 	pCtx, pCancel := context.WithCancel(ctx)
 
-	return &PeerObj{
+	p := &PeerObj{
 		PeerObj: &domain.PeerObj{
 			Metadata:   peermd,
 			Log:        log,
@@ -83,7 +81,13 @@ func NewPeer(ctx context.Context, stream sfu.SFU_SignalServer, poolSize int, log
 			SendQ:      sendQ,
 			EventQ:     eventQ,
 		},
-	}, nil
+		reactions: rate.NewLimiter(rate.Every(reactionInterval), reactionBurst),
+		config:    c,
+		kicked:    make(chan struct{}),
+	}
+	pub.OnVideoCodec(p.rebindSubscribers)
+
+	return p, nil
 }
 
 func (p *PeerObj) GetMetaData() *domain.PeerMD {
@@ -108,6 +112,7 @@ func (p *PeerObj) Connect() error {
 	// start send loop and on event loop
 	g.Go(func() error { return p.sendCycle() })
 	g.Go(func() error { return p.eventCycle() })
+	g.Go(func() error { return p.qualityCycle() })
 
 	// main loop
 	g.Go(func() error {
@@ -146,7 +151,13 @@ func (p *PeerObj) Connect() error {
 					}
 
 				case *sfu.PeerSignal_Action:
-					err := p.handleActions(pl)
+					err := p.answerAction(pl.Action.RequestID, p.handleActions(pl))
+					if err != nil {
+						return err
+					}
+
+				case *sfu.PeerSignal_Key:
+					err := p.answerAction(pl.Key.RequestID, p.handleKey(pl))
 					if err != nil {
 						return err
 					}
@@ -182,10 +193,62 @@ func (p *PeerObj) Disconnect() error {
 	return nil
 }
 
+// leave the room the peer is in, unless a newer session of the same peer
+// took its place
+func (p *PeerObj) Leave() {
+	md := p.Metadata
+
+	p.roomMu.Lock()
+	r := p.breakout
+	p.roomMu.Unlock()
+
+	if r == nil {
+		r = hub.Hub().GetRoom(md.RoomID)
+	}
+
+	if r == nil || r.GetPeer(md.PeerID) != domain.Peer(p) {
+		return
+	}
+
+	r.RemovePeer(md.PeerID)
+	r.Announce(p.createEvent(md.RoomID, sfu.EventType_LEAVE_EVENT), nil)
+}
+
+func (p *PeerObj) Kick() {
+	p.kickOnce.Do(func() { close(p.kicked) })
+}
+
+func (p *PeerObj) Kicked() <-chan struct{} {
+	return p.kicked
+}
+
+// helper function to hand the peer's subscribers a track of its new video
+// codec, including the ones that could not decode the old one
+func (p *PeerObj) rebindSubscribers() {
+	r, err := p.currentRoom()
+	if err != nil {
+		return
+	}
+
+	subs := r.ListPeers()
+	maps.Copy(subs, r.ListViewers())
+
+	for id, sub := range subs {
+		if id == p.Metadata.PeerID {
+			continue
+		}
+
+		if err := sub.Sub().Rebind(p); err != nil {
+			p.Log.Error("unable to rebind subscriber", "subscriber", id, "err", err)
+		}
+	}
+}
+
 func (p *PeerObj) EnqueueEvent(event *sfu.PeerSignal_Event) {
 	select {
 	case p.EventQ <- event:
 	default:
+		metrics.DroppedSignal("event")
 	}
 }
 
@@ -193,6 +256,7 @@ func (p *PeerObj) EnqueueSend(msg *sfu.PeerSignal) {
 	select {
 	case p.SendQ <- msg:
 	default:
+		metrics.DroppedSignal("send")
 	}
 }
 
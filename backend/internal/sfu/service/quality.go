@@ -0,0 +1,103 @@
+package service
+
+import (
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const qualityInterval = 5 * time.Second
+
+// current uplink and downlink quality of the peer
+func (p *PeerObj) Quality() *sfu.Quality {
+	tracks := append(p.Publisher.Quality(), p.Subscriber.Quality()...)
+
+	return &sfu.Quality{
+		PeerID: p.Metadata.PeerID,
+		Score:  score(tracks),
+		Tracks: tracks,
+	}
+}
+
+// connection states and published tracks of the peer, for the admin API.
+// The room replaces the state with the one it keeps
+func (p *PeerObj) Info() *sfu.PeerInfo {
+	md := p.Metadata
+	info := &sfu.PeerInfo{
+		State:    &sfu.PeerState{PeerID: md.PeerID, Name: md.Name, Role: md.Role},
+		PubState: p.Publisher.State().String(),
+		SubState: p.Subscriber.State().String(),
+		Tracks:   []*sfu.TrackInfo{},
+	}
+
+	av := p.Publisher.Tracks()
+	for kind, remote := range map[string]*webrtc.TrackRemote{"audio": av.Audio, "video": av.Video} {
+		if remote == nil {
+			continue
+		}
+
+		info.Tracks = append(info.Tracks, &sfu.TrackInfo{
+			Kind:  kind,
+			Codec: remote.Codec().MimeType,
+			Ssrc:  uint32(remote.SSRC()),
+		})
+	}
+
+	return info
+}
+
+// periodically report quality to the client and the room summary
+func (p *PeerObj) qualityCycle() error {
+	ticker := time.NewTicker(qualityInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.Ctx.Done():
+			return nil
+		case <-ticker.C:
+			q := p.Quality()
+
+			p.EnqueueSend(&sfu.PeerSignal{
+				Payload: &sfu.PeerSignal_Event{
+					Event: &sfu.Event{
+						Name:    p.Metadata.Name,
+						PeerID:  p.Metadata.PeerID,
+						Type:    sfu.EventType_QUALITY,
+						Quality: q,
+					},
+				},
+			})
+
+			// viewers are not in the call summary
+			if r := hub.Hub().GetRoom(p.Metadata.RoomID); r != nil && !p.viewOnly {
+				r.RecordQuality(q)
+			}
+		}
+	}
+}
+
+// score the worst track of the peer
+func score(tracks []*sfu.TrackQuality) uint32 {
+	if len(tracks) == 0 {
+		return domain.QualityUnknown
+	}
+
+	var loss, rtt float64
+	for _, t := range tracks {
+		loss = max(loss, t.PacketLoss)
+		rtt = max(rtt, t.RttMs)
+	}
+
+	switch {
+	case loss < 0.02 && rtt < 150:
+		return domain.QualityExcellent
+	case loss < 0.05 && rtt < 300:
+		return domain.QualityGood
+	default:
+		return domain.QualityPoor
+	}
+}
@@ -0,0 +1,228 @@
+package room
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/pkg/config"
+)
+
+const (
+	maxBreakouts   = 50
+	maxBreakoutMsg = 500
+)
+
+type BreakoutsObj struct {
+	*domain.BreakoutsObj
+}
+
+func NewBreakouts(mainID string, main domain.Room, c config.Room) domain.Breakouts {
+	return &BreakoutsObj{
+		BreakoutsObj: &domain.BreakoutsObj{
+			Main:   main,
+			MainID: mainID,
+			Config: c,
+			Rooms:  make(map[string]domain.Room),
+		},
+	}
+}
+
+// create the breakout rooms, recalled after duration unless it is 0
+func (b *BreakoutsObj) Open(count int, duration time.Duration, random bool) ([]string, error) {
+	if count < 1 || count > maxBreakouts || duration < 0 {
+		return nil, domain.ErrBadBreakout
+	}
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if len(b.IDs) > 0 {
+		return nil, domain.ErrBreakoutsOpen
+	}
+
+	codecs := b.Main.CodecPolicy()
+	for i := range count {
+		id := fmt.Sprintf("%s/breakout-%d", b.MainID, i+1)
+
+		r := NewRoom(id, codecs, b.Main.E2EE(), b.Config)
+		r.MakeLive()
+
+		b.IDs = append(b.IDs, id)
+		b.Rooms[id] = r
+	}
+
+	openedE := &sfu.Event{Type: sfu.EventType_BREAKOUTS_OPENED, Breakouts: slices.Clone(b.IDs)}
+
+	if duration > 0 {
+		b.Ends = time.Now().Add(duration)
+		b.Timer = time.AfterFunc(duration, func() { _ = b.Recall() })
+
+		endsAt := b.Ends.UnixMilli()
+		openedE.EndsAt = &endsAt
+	}
+
+	b.Main.BroadCast("", &sfu.PeerSignal_Event{Event: openedE})
+
+	if random {
+		b.assignRandom()
+	}
+
+	return slices.Clone(b.IDs), nil
+}
+
+// move a peer to a breakout room, or back to the main room
+func (b *BreakoutsObj) Assign(peerID string, roomID string) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if len(b.IDs) == 0 {
+		return domain.ErrNoBreakouts
+	}
+
+	if _, ok := b.Rooms[roomID]; !ok && roomID != b.MainID {
+		return domain.ErrUnknownBreakout
+	}
+
+	peer := b.find(peerID)
+	if peer == nil {
+		return domain.ErrBadBreakout
+	}
+
+	move(peer, roomID)
+	return nil
+}
+
+// send a message from the host to every room
+func (b *BreakoutsObj) Broadcast(msg string) error {
+	if msg == "" || len(msg) > maxBreakoutMsg {
+		return domain.ErrBadBreakout
+	}
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if len(b.IDs) == 0 {
+		return domain.ErrNoBreakouts
+	}
+
+	msgE := &sfu.PeerSignal_Event{
+		Event: &sfu.Event{Type: sfu.EventType_BREAKOUT_MESSAGE, Message: &msg},
+	}
+
+	b.Main.BroadCast("", msgE)
+	for _, id := range b.IDs {
+		b.Rooms[id].BroadCast("", msgE)
+	}
+
+	return nil
+}
+
+// move everyone back to the main room and close the breakout rooms
+func (b *BreakoutsObj) Recall() error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if len(b.IDs) == 0 {
+		return domain.ErrNoBreakouts
+	}
+
+	if b.Timer != nil {
+		b.Timer.Stop()
+		b.Timer = nil
+	}
+
+	closedE := &sfu.PeerSignal_Event{
+		Event: &sfu.Event{Type: sfu.EventType_BREAKOUTS_CLOSED},
+	}
+
+	b.Main.BroadCast("", closedE)
+
+	// peers leave through their own event loop, the closed rooms stay
+	// usable until they are gone
+	for _, id := range b.IDs {
+		r := b.Rooms[id]
+		for _, peer := range r.ListPeers() {
+			peer.EnqueueEvent(closedE)
+			move(peer, b.MainID)
+		}
+		r.Close()
+	}
+
+	b.IDs = nil
+	b.Rooms = make(map[string]domain.Room)
+	b.Ends = time.Time{}
+
+	return nil
+}
+
+func (b *BreakoutsObj) End() {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if b.Timer != nil {
+		b.Timer.Stop()
+		b.Timer = nil
+	}
+
+	endedE := &sfu.PeerSignal_Event{
+		Event: &sfu.Event{Type: sfu.EventType_ROOM_ENDED},
+	}
+
+	for _, id := range b.IDs {
+		r := b.Rooms[id]
+		r.BroadCast("", endedE)
+		r.Close()
+	}
+
+	b.IDs = nil
+	b.Rooms = make(map[string]domain.Room)
+}
+
+// spread the main room peers but the hosts over the breakout rooms
+func (b *BreakoutsObj) assignRandom() {
+	peers := []domain.Peer{}
+	for _, peer := range b.Main.ListPeers() {
+		if peer.GetMetaData().Role != sfu.RoleType_ROLE_HOST {
+			peers = append(peers, peer)
+		}
+	}
+
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+
+	for i, peer := range peers {
+		move(peer, b.IDs[i%len(b.IDs)])
+	}
+}
+
+// find a peer in the main or a breakout room
+func (b *BreakoutsObj) find(peerID string) domain.Peer {
+	if peer := b.Main.GetPeer(peerID); peer != nil {
+		return peer
+	}
+
+	for _, id := range b.IDs {
+		if peer := b.Rooms[id].GetPeer(peerID); peer != nil {
+			return peer
+		}
+	}
+
+	return nil
+}
+
+// the peer moves itself so its connections are only touched by its own
+// event loop
+func move(peer domain.Peer, roomID string) {
+	md := peer.GetMetaData()
+
+	peer.EnqueueEvent(&sfu.PeerSignal_Event{
+		Event: &sfu.Event{
+			Type:   sfu.EventType_BREAKOUT_MOVED,
+			Name:   md.Name,
+			PeerID: md.PeerID,
+			RoomID: &roomID,
+		},
+	})
+}
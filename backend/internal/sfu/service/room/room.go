@@ -1,28 +1,47 @@
 package room
 
 import (
+	"cmp"
 	"context"
+	"maps"
+	"slices"
+	"time"
 	sfu "vidcall/api/proto"
 	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/infra"
+	"vidcall/internal/sfu/repo"
+	"vidcall/internal/sfu/service/egress"
 	"vidcall/internal/sfu/service/hub"
+	"vidcall/internal/sfu/service/transcript"
+	"vidcall/pkg/config"
 )
 
 type RoomObj struct {
 	*domain.RoomObj
 }
 
-func NewRoom(roomID string) domain.Room {
+func NewRoom(roomID string, codecs []string, e2ee bool, c config.Room) domain.Room {
 
 	rCtx, rCancel := context.WithCancel(context.Background())
 
 	room := &RoomObj{
 		RoomObj: &domain.RoomObj{
-			ID:       roomID,
-			Live:     false,
-			Peers:    make(map[string]domain.Peer),
-			Ctx:      rCtx,
-			Cancel:   rCancel,
-			JoinChan: make(chan domain.Peer, 64),
+			ID:        roomID,
+			Live:      false,
+			Peers:     make(map[string]domain.Peer),
+			Viewers:   make(map[string]domain.Peer),
+			Ctx:       rCtx,
+			Cancel:    rCancel,
+			JoinChan:  make(chan domain.Peer, c.JoinQueue),
+			Codecs:    codecs,
+			Encrypted: e2ee,
+			Config:    c,
+			States:    make(map[string]*domain.PeerState),
+			Quality: &domain.QualitySummary{
+				RoomID: roomID,
+				Start:  time.Now(),
+				Peers:  make(map[string]*domain.PeerQuality),
+			},
 		},
 	}
 
@@ -33,10 +52,47 @@ func NewRoom(roomID string) domain.Room {
 
 }
 
+// the admin API, a drain, the breakouts and the host may all end the
+// room, only the first one tears it down
 func (r *RoomObj) Close() {
+	r.CloseOnce.Do(r.close)
+}
+
+func (r *RoomObj) close() {
+	r.Mu.RLock()
+	breakouts := r.Breakout
+	stream := r.Stream
+	captions := r.Captions
+	r.Mu.RUnlock()
+
+	if breakouts != nil {
+		breakouts.End()
+	}
+
+	// peers hear the stream stopped before the room is gone
+	if stream != nil {
+		_ = stream.Stop()
+	}
+	if captions != nil {
+		_ = captions.Stop()
+	}
+
+	r.Mu.Lock()
+	r.Closed = true
+	r.Mu.Unlock()
+
 	r.Cancel()
 	close(r.JoinChan)
 	hub.Hub().RemoveRoom(r.ID)
+
+	// store the call quality summary
+	if db := infra.DB(); db != nil {
+		r.Mu.Lock()
+		r.Quality.End = time.Now()
+		r.Mu.Unlock()
+
+		go repo.SaveQualitySummary(context.Background(), db, r.Quality)
+	}
 }
 
 func (r *RoomObj) MakeLive() {
@@ -51,13 +107,40 @@ func (r *RoomObj) IsLive() bool {
 	return r.Live
 }
 
-func (r *RoomObj) AddPeer(peerID string, peer domain.Peer) {
+// add a peer, refused once the room is closed
+func (r *RoomObj) AddPeer(peerID string, peer domain.Peer) error {
 	r.Mu.Lock()
 	defer r.Mu.Unlock()
+
+	if r.Closed || r.Ctx.Err() != nil {
+		return domain.ErrRoomClosed
+	}
+
+	_, rejoined := r.Peers[peerID]
 	r.Peers[peerID] = peer
+	if !rejoined {
+		r.rotateKeys()
+	}
+
+	if _, ok := r.States[peerID]; !ok {
+		md := peer.GetMetaData()
+		r.States[peerID] = &domain.PeerState{
+			PeerID:   peerID,
+			Name:     md.Name,
+			Role:     md.Role,
+			Audio:    true,
+			Video:    true,
+			JoinedAt: time.Now(),
+		}
+	}
+
+	// trigger new peer join to subcriber audio, nothing may be listening
+	select {
+	case r.JoinChan <- peer:
+	default:
+	}
 
-	// trigger new peer join to subcriber audio
-	r.JoinChan <- peer
+	return nil
 }
 
 func (r *RoomObj) RemovePeer(peerID string) domain.Peer {
@@ -71,6 +154,13 @@ func (r *RoomObj) RemovePeer(peerID string) domain.Peer {
 	}
 
 	delete(r.Peers, peerID)
+	delete(r.States, peerID)
+	r.rotateKeys()
+
+	if i := slices.Index(r.Hands, peerID); i >= 0 {
+		r.Hands = slices.Delete(r.Hands, i, i+1)
+		r.sendHandQueue()
+	}
 
 	return v
 }
@@ -88,6 +178,18 @@ func (r *RoomObj) GetPeer(peerID string) domain.Peer {
 	return v
 }
 
+func (r *RoomObj) AddViewer(viewerID string, viewer domain.Peer) {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+	r.Viewers[viewerID] = viewer
+}
+
+func (r *RoomObj) RemoveViewer(viewerID string) {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+	delete(r.Viewers, viewerID)
+}
+
 func (r *RoomObj) BroadCast(peerID string, event *sfu.PeerSignal_Event) {
 
 	r.Mu.Lock()
@@ -105,13 +207,333 @@ func (r *RoomObj) BroadCast(peerID string, event *sfu.PeerSignal_Event) {
 			peer.EnqueueEvent(event)
 		}
 	}
+
+	for _, viewer := range r.Viewers {
+		viewer.EnqueueEvent(event)
+	}
 }
 
+// apply a change to the state of the event's peer, number the event and
+// send it to every peer of the room, the sender included
+func (r *RoomObj) Announce(event *sfu.PeerSignal_Event, update func(s *domain.PeerState)) {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	r.announce(event, update)
+}
+
+// helper function to announce with the room lock held
+func (r *RoomObj) announce(event *sfu.PeerSignal_Event, update func(s *domain.PeerState)) {
+	s, ok := r.States[event.Event.PeerID]
+	if ok && update != nil {
+		update(s)
+	}
+	if ok {
+		event.Event.Peer = toPeerState(s)
+	}
+
+	r.Seq++
+	event.Event.Seq = r.Seq
+
+	for _, peer := range r.Peers {
+		select {
+		case <-r.Ctx.Done():
+			return
+		default:
+			peer.EnqueueEvent(event)
+		}
+	}
+
+	// viewers follow joins and leaves to subscribe
+	for _, viewer := range r.Viewers {
+		viewer.EnqueueEvent(event)
+	}
+}
+
+// send the full room state to a peer, on join and when it missed events
+func (r *RoomObj) SendState(peerID string) {
+	r.Mu.RLock()
+	defer r.Mu.RUnlock()
+
+	peer, ok := r.Peers[peerID]
+	if !ok {
+		return
+	}
+
+	states := make([]*domain.PeerState, 0, len(r.States))
+	for _, s := range r.States {
+		states = append(states, s)
+	}
+	slices.SortFunc(states, func(a, b *domain.PeerState) int {
+		return a.JoinedAt.Compare(b.JoinedAt)
+	})
+
+	state := &sfu.RoomState{
+		Peers:     make([]*sfu.PeerState, 0, len(states)),
+		Encrypted: r.Encrypted,
+		KeyEpoch:  r.KeyEpoch,
+	}
+	for _, s := range states {
+		state.Peers = append(state.Peers, toPeerState(s))
+	}
+
+	peer.EnqueueEvent(&sfu.PeerSignal_Event{
+		Event: &sfu.Event{
+			Type:  sfu.EventType_ROOM_STATE,
+			Seq:   r.Seq,
+			State: state,
+		},
+	})
+
+	if peer.GetMetaData().Role == sfu.RoleType_ROLE_HOST {
+		peer.EnqueueEvent(r.handQueueEvent())
+	}
+}
+
+// queue the event's peer to speak, false if its hand is already raised
+func (r *RoomObj) RaiseHand(event *sfu.PeerSignal_Event) bool {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	peerID := event.Event.PeerID
+	if _, ok := r.States[peerID]; !ok || slices.Contains(r.Hands, peerID) {
+		return false
+	}
+
+	r.Hands = append(r.Hands, peerID)
+	r.announce(event, func(s *domain.PeerState) { s.HandRaised = true })
+	r.sendHandQueue()
+
+	return true
+}
+
+// take the event's peer out of the queue, false if its hand is not raised
+func (r *RoomObj) LowerHand(event *sfu.PeerSignal_Event) bool {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	i := slices.Index(r.Hands, event.Event.PeerID)
+	if i < 0 {
+		return false
+	}
+
+	r.Hands = slices.Delete(r.Hands, i, i+1)
+	r.announce(event, func(s *domain.PeerState) { s.HandRaised = false })
+	r.sendHandQueue()
+
+	return true
+}
+
+// lower the first raised hand and tell the room who was called on
+func (r *RoomObj) CallNext() (string, bool) {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	if len(r.Hands) == 0 {
+		return "", false
+	}
+
+	peerID := r.Hands[0]
+	r.Hands = r.Hands[1:]
+
+	var name string
+	if s, ok := r.States[peerID]; ok {
+		name = s.Name
+	}
+
+	calledE := &sfu.PeerSignal_Event{
+		Event: &sfu.Event{Type: sfu.EventType_CALLED_ON, Name: name, PeerID: peerID},
+	}
+	r.announce(calledE, func(s *domain.PeerState) { s.HandRaised = false })
+	r.sendHandQueue()
+
+	return peerID, true
+}
+
+func (r *RoomObj) SendHandQueue(peerID string) {
+	r.Mu.RLock()
+	defer r.Mu.RUnlock()
+
+	if peer, ok := r.Peers[peerID]; ok {
+		peer.EnqueueEvent(r.handQueueEvent())
+	}
+}
+
+// send the hand queue to the hosts, with the room lock held
+func (r *RoomObj) sendHandQueue() {
+	event := r.handQueueEvent()
+
+	for _, peer := range r.Peers {
+		if peer.GetMetaData().Role == sfu.RoleType_ROLE_HOST {
+			peer.EnqueueEvent(event)
+		}
+	}
+}
+
+func (r *RoomObj) handQueueEvent() *sfu.PeerSignal_Event {
+	return &sfu.PeerSignal_Event{
+		Event: &sfu.Event{
+			Type:      sfu.EventType_HAND_QUEUE,
+			HandQueue: slices.Clone(r.Hands),
+		},
+	}
+}
+
+// helper function to convert a peer state
+func toPeerState(s *domain.PeerState) *sfu.PeerState {
+	return &sfu.PeerState{
+		PeerID:      s.PeerID,
+		Name:        s.Name,
+		Role:        s.Role,
+		Audio:       s.Audio,
+		Video:       s.Video,
+		ScreenShare: s.ScreenShare,
+		HandRaised:  s.HandRaised,
+		JoinedAt:    s.JoinedAt.UnixMilli(),
+	}
+}
+
+// peers of the room with their state, for the admin API
+func (r *RoomObj) Info() *sfu.RoomInfo {
+	r.Mu.RLock()
+	defer r.Mu.RUnlock()
+
+	info := &sfu.RoomInfo{
+		RoomID:    r.ID,
+		Live:      r.Live,
+		Encrypted: r.Encrypted,
+		Peers:     make([]*sfu.PeerInfo, 0, len(r.Peers)),
+		Viewers:   uint32(len(r.Viewers)),
+	}
+
+	for id, peer := range r.Peers {
+		p := peer.Info()
+		if s, ok := r.States[id]; ok {
+			p.State = toPeerState(s)
+		}
+		info.Peers = append(info.Peers, p)
+	}
+
+	slices.SortFunc(info.Peers, func(a, b *sfu.PeerInfo) int {
+		return cmp.Compare(a.GetState().GetJoinedAt(), b.GetState().GetJoinedAt())
+	})
+
+	return info
+}
+
+// a copy, callers range over it while peers join, leave and move
 func (r *RoomObj) ListPeers() map[string]domain.Peer {
 	r.Mu.RLock()
 	defer r.Mu.RUnlock()
 
-	peers := r.Peers
+	return maps.Clone(r.Peers)
+}
+
+func (r *RoomObj) ListViewers() map[string]domain.Peer {
+	r.Mu.RLock()
+	defer r.Mu.RUnlock()
+
+	return maps.Clone(r.Viewers)
+}
+
+// fold a quality report into the room summary
+func (r *RoomObj) RecordQuality(q *sfu.Quality) {
+	if len(q.Tracks) == 0 {
+		return
+	}
+
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	pq, ok := r.Quality.Peers[q.PeerID]
+	if !ok {
+		pq = &domain.PeerQuality{PeerID: q.PeerID}
+		r.Quality.Peers[q.PeerID] = pq
+	}
+
+	var loss, jitter, rtt, bitrate float64
+	for _, t := range q.Tracks {
+		loss = max(loss, t.PacketLoss)
+		jitter = max(jitter, t.JitterMs)
+		rtt = max(rtt, t.RttMs)
+		bitrate += float64(t.BitrateBps)
+	}
+
+	pq.Samples++
+	if q.Score == domain.QualityPoor {
+		pq.PoorSamples++
+	}
+
+	n := float64(pq.Samples)
+	pq.AvgLoss += (loss - pq.AvgLoss) / n
+	pq.AvgJitterMs += (jitter - pq.AvgJitterMs) / n
+	pq.AvgRttMs += (rtt - pq.AvgRttMs) / n
+	pq.AvgBitrateBps += (bitrate - pq.AvgBitrateBps) / n
+
+	pq.MaxLoss = max(pq.MaxLoss, loss)
+	pq.MaxJitterMs = max(pq.MaxJitterMs, jitter)
+	pq.MaxRttMs = max(pq.MaxRttMs, rtt)
+}
+
+// breakout rooms of the room, created on first use
+func (r *RoomObj) Breakouts() domain.Breakouts {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	if r.Breakout == nil {
+		r.Breakout = NewBreakouts(r.ID, r, r.Config)
+	}
+
+	return r.Breakout
+}
+
+// live stream of the room, created on first use
+func (r *RoomObj) Egress() domain.Egress {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	if r.Stream == nil {
+		r.Stream = egress.NewEgress(r.ID, r)
+	}
+
+	return r.Stream
+}
+
+// live captions of the room, created on first use
+func (r *RoomObj) Transcriber() domain.Transcriber {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	if r.Captions == nil {
+		r.Captions = transcript.NewTranscriber(r.ID, r)
+	}
+
+	return r.Captions
+}
+
+func (r *RoomObj) E2EE() bool {
+	return r.Encrypted
+}
+
+// a new key epoch after a join or leave, so a leaving peer cannot decrypt
+// what follows and a joining one what came before. With the room lock held
+func (r *RoomObj) rotateKeys() {
+	if !r.Encrypted {
+		return
+	}
+
+	r.KeyEpoch++
+	epoch := r.KeyEpoch
+	event := &sfu.PeerSignal_Event{
+		Event: &sfu.Event{Type: sfu.EventType_KEY_ROTATE, KeyEpoch: &epoch},
+	}
+
+	for _, peer := range r.Peers {
+		peer.EnqueueEvent(event)
+	}
+}
 
-	return peers
+// video codecs allowed in the room, in order of preference
+func (r *RoomObj) CodecPolicy() []string {
+	return r.Codecs
 }
@@ -0,0 +1,98 @@
+package room
+
+import (
+	"testing"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+	"vidcall/pkg/config"
+)
+
+// fakePeer implements what a room uses of its peers, anything else panics
+type fakePeer struct {
+	domain.Peer
+	md     *domain.PeerMD
+	events []*sfu.PeerSignal_Event
+}
+
+func newFakePeer(id string) *fakePeer {
+	return &fakePeer{md: &domain.PeerMD{PeerID: id, Name: id}}
+}
+
+func (p *fakePeer) GetMetaData() *domain.PeerMD { return p.md }
+
+func (p *fakePeer) EnqueueEvent(event *sfu.PeerSignal_Event) {
+	p.events = append(p.events, event)
+}
+
+func newTestRoom(t *testing.T, id string) domain.Room {
+	t.Helper()
+	hub.Init(config.ICE{})
+
+	return NewRoom(id, nil, false, config.Room{JoinQueue: 1})
+}
+
+func TestAddPeerAfterClose(t *testing.T) {
+	r := newTestRoom(t, "add-after-close")
+
+	if err := r.AddPeer("alice", newFakePeer("alice")); err != nil {
+		t.Fatalf("AddPeer() = %v", err)
+	}
+	// the join queue is full, the next join must not block
+	if err := r.AddPeer("bob", newFakePeer("bob")); err != nil {
+		t.Fatalf("AddPeer() with a full join queue = %v", err)
+	}
+
+	r.Close()
+
+	if err := r.AddPeer("carol", newFakePeer("carol")); err != domain.ErrRoomClosed {
+		t.Fatalf("AddPeer() after close = %v, want %v", err, domain.ErrRoomClosed)
+	}
+	if r.GetPeer("carol") != nil {
+		t.Fatal("peer added to a closed room")
+	}
+}
+
+func TestListPeersCopy(t *testing.T) {
+	r := newTestRoom(t, "list-peers")
+	defer r.Close()
+
+	for _, id := range []string{"alice", "bob"} {
+		if err := r.AddPeer(id, newFakePeer(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// peers leaving while a caller ranges over the list do not change it
+	peers := r.ListPeers()
+	for id := range peers {
+		r.RemovePeer(id)
+	}
+	if len(peers) != 2 {
+		t.Fatalf("ListPeers() changed to %d peers, want 2", len(peers))
+	}
+	if n := len(r.ListPeers()); n != 0 {
+		t.Fatalf("room has %d peers, want 0", n)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	r := newTestRoom(t, "close-twice")
+	alice := newFakePeer("alice")
+	if err := r.AddPeer("alice", alice); err != nil {
+		t.Fatal(err)
+	}
+
+	// the host and the admin API end the room at the same time
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Close()
+	}()
+	r.Close()
+	<-done
+
+	if hub.Hub().GetRoom("close-twice") != nil {
+		t.Fatal("room is still in the hub after close")
+	}
+}
@@ -0,0 +1,72 @@
+package rtc
+
+import (
+	"sync"
+	"vidcall/internal/sfu/metrics"
+
+	"github.com/pion/rtp"
+)
+
+// packets a subscriber may fall behind before its packets are dropped
+const fanoutQueue = 256
+
+// Broadcaster fans the packets of one publisher track kind out to its
+// subscribers. Every subscriber has its own bounded queue so a slow one
+// only loses its own packets. It outlives the remote track, a camera
+// turned off and on again feeds the same subscribers. Packets are shared,
+// writers must not modify them.
+type Broadcaster struct {
+	mu     sync.RWMutex
+	queues map[chan *rtp.Packet]struct{}
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{queues: make(map[chan *rtp.Packet]struct{})}
+}
+
+// add a subscriber queue, closed by Unregister or Close
+func (b *Broadcaster) Register() chan *rtp.Packet {
+	q := make(chan *rtp.Packet, fanoutQueue)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.queues[q] = struct{}{}
+	return q
+}
+
+func (b *Broadcaster) Unregister(q chan *rtp.Packet) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.queues[q]; !ok {
+		return
+	}
+
+	delete(b.queues, q)
+	close(q)
+}
+
+func (b *Broadcaster) Broadcast(pkt *rtp.Packet) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for q := range b.queues {
+		select {
+		case q <- pkt:
+		default:
+			metrics.DroppedRTP("slow_subscriber")
+		}
+	}
+}
+
+// detach every subscriber, the broadcaster stays usable for new ones
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for q := range b.queues {
+		delete(b.queues, q)
+		close(q)
+	}
+}
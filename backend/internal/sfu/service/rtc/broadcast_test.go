@@ -0,0 +1,88 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+// helper function to take every packet already queued, false once closed
+func drain(q chan *rtp.Packet) (int, bool) {
+	n := 0
+	for {
+		select {
+		case _, ok := <-q:
+			if !ok {
+				return n, false
+			}
+			n++
+		default:
+			return n, true
+		}
+	}
+}
+
+func TestBroadcastSlowSubscriber(t *testing.T) {
+	b := NewBroadcaster()
+	fast := b.Register()
+	slow := b.Register()
+
+	total := 2 * fanoutQueue
+	for i := range total {
+		b.Broadcast(&rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i)}})
+
+		// the fast subscriber keeps up, the slow one never reads
+		if n, _ := drain(fast); n != 1 {
+			t.Fatalf("fast subscriber got %d packets, want 1", n)
+		}
+	}
+
+	// the slow queue keeps the oldest packets, the rest are dropped
+	if pkt := <-slow; pkt.SequenceNumber != 0 {
+		t.Fatalf("slow subscriber first packet = %d, want 0", pkt.SequenceNumber)
+	}
+	if n, open := drain(slow); n != fanoutQueue-1 || !open {
+		t.Fatalf("slow subscriber got %d more packets, open %v, want %d, true", n, open, fanoutQueue-1)
+	}
+}
+
+func TestBroadcastUnregister(t *testing.T) {
+	b := NewBroadcaster()
+	gone := b.Register()
+	stays := b.Register()
+
+	b.Unregister(gone)
+	// stop functions may run more than once
+	b.Unregister(gone)
+
+	b.Broadcast(&rtp.Packet{})
+
+	if n, open := drain(gone); n != 0 || open {
+		t.Fatalf("unregistered queue got %d packets, open %v, want 0, false", n, open)
+	}
+	if n, open := drain(stays); n != 1 || !open {
+		t.Fatalf("registered queue got %d packets, open %v, want 1, true", n, open)
+	}
+}
+
+func TestBroadcastClose(t *testing.T) {
+	b := NewBroadcaster()
+	before := b.Register()
+
+	b.Broadcast(&rtp.Packet{})
+	b.Close()
+
+	// queued packets are still delivered before the close
+	if n, open := drain(before); n != 1 || open {
+		t.Fatalf("queue got %d packets, open %v, want 1, false", n, open)
+	}
+	b.Unregister(before)
+
+	// a track added back reaches subscribers registered after the close
+	after := b.Register()
+	b.Broadcast(&rtp.Packet{})
+
+	if n, open := drain(after); n != 1 || !open {
+		t.Fatalf("queue registered after close got %d packets, open %v, want 1, true", n, open)
+	}
+}
@@ -0,0 +1,39 @@
+package rtc
+
+import (
+	"sync"
+
+	"github.com/pion/rtp"
+)
+
+// about 1s of 8Mbps video, must be a power of two
+const cacheSize = 1024
+
+// PacketCache keeps the last packets of a publisher track so subscriber
+// NACKs can be answered without going back to the publisher
+type PacketCache struct {
+	mu   sync.RWMutex
+	pkts [cacheSize]*rtp.Packet
+}
+
+func NewPacketCache() *PacketCache {
+	return &PacketCache{}
+}
+
+func (c *PacketCache) Push(pkt *rtp.Packet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pkts[pkt.SequenceNumber%cacheSize] = pkt
+}
+
+func (c *PacketCache) Get(seq uint16) *rtp.Packet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	pkt := c.pkts[seq%cacheSize]
+	if pkt == nil || pkt.SequenceNumber != seq {
+		return nil
+	}
+
+	return pkt
+}
@@ -0,0 +1,208 @@
+package rtc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+)
+
+// room codec policy used when the room does not set one
+var DefaultCodecPolicy = []string{"vp8", "h264", "vp9", "av1"}
+
+var codecMimeTypes = map[string]string{
+	"vp8":  webrtc.MimeTypeVP8,
+	"vp9":  webrtc.MimeTypeVP9,
+	"av1":  webrtc.MimeTypeAV1,
+	"h264": webrtc.MimeTypeH264,
+}
+
+// nack and transport-cc feedback are added with the interceptors
+var videoFeedback = []webrtc.RTCPFeedback{{Type: "goog-remb"}, {Type: "ccm", Parameter: "fir"}}
+
+func video(mime string, fmtp string, pt webrtc.PayloadType) webrtc.RTPCodecParameters {
+	return webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: mime, ClockRate: 90000, SDPFmtpLine: fmtp, RTCPFeedback: videoFeedback},
+		PayloadType:        pt,
+	}
+}
+
+func rtx(apt string, pt webrtc.PayloadType) webrtc.RTPCodecParameters {
+	return webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: "video/rtx", ClockRate: 90000, SDPFmtpLine: "apt=" + apt},
+		PayloadType:        pt,
+	}
+}
+
+// video codecs the SFU forwards, payload types follow pion's defaults
+var videoCodecs = map[string][]webrtc.RTPCodecParameters{
+	"vp8": {
+		video(webrtc.MimeTypeVP8, "", 96),
+	},
+	"vp9": {
+		video(webrtc.MimeTypeVP9, "profile-id=0", 98),
+		video(webrtc.MimeTypeVP9, "profile-id=2", 100),
+	},
+	"av1": {
+		video(webrtc.MimeTypeAV1, "", 45),
+	},
+	"h264": {
+		video(webrtc.MimeTypeH264, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f", 102),
+		video(webrtc.MimeTypeH264, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f", 106),
+		video(webrtc.MimeTypeH264, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=4d001f", 127),
+		video(webrtc.MimeTypeH264, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=64001f", 112),
+	},
+}
+
+var g711Codecs = []webrtc.RTPCodecParameters{
+	{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMU, ClockRate: 8000}, PayloadType: 0},
+	{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMA, ClockRate: 8000}, PayloadType: 8},
+}
+
+var rtxCodecs = []webrtc.RTPCodecParameters{
+	rtx("96", 97), rtx("98", 99), rtx("100", 101), rtx("45", 46),
+	rtx("102", 103), rtx("106", 107), rtx("127", 125), rtx("112", 113),
+}
+
+// register the codec set of every pc
+func registerCodecs(m *webrtc.MediaEngine) error {
+	opus := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2, SDPFmtpLine: "minptime=10;useinbandfec=1"},
+		PayloadType:        111,
+	}
+	if err := m.RegisterCodec(opus, webrtc.RTPCodecTypeAudio); err != nil {
+		return err
+	}
+
+	// G.711 of the phone gateway, forwarded as is
+	for _, c := range g711Codecs {
+		if err := m.RegisterCodec(c, webrtc.RTPCodecTypeAudio); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range DefaultCodecPolicy {
+		for _, c := range videoCodecs[name] {
+			if err := m.RegisterCodec(c, webrtc.RTPCodecTypeVideo); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range rtxCodecs {
+		if err := m.RegisterCodec(c, webrtc.RTPCodecTypeVideo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// negotiated video codecs ordered by a room policy, codecs outside the
+// policy are left out
+func orderCodecs(negotiated []webrtc.RTPCodecParameters, policy []string) []webrtc.RTPCodecParameters {
+	var ordered []webrtc.RTPCodecParameters
+	kept := map[webrtc.PayloadType]bool{}
+
+	for _, name := range policy {
+		mime := codecMimeTypes[strings.ToLower(name)]
+		for _, c := range negotiated {
+			if mime != "" && strings.EqualFold(c.MimeType, mime) {
+				ordered = append(ordered, c)
+				kept[c.PayloadType] = true
+			}
+		}
+	}
+
+	// keep the retransmission codecs of what is left
+	for _, c := range negotiated {
+		if !strings.EqualFold(c.MimeType, "video/rtx") {
+			continue
+		}
+
+		apt, err := strconv.ParseUint(strings.TrimPrefix(c.SDPFmtpLine, "apt="), 10, 8)
+		if err == nil && kept[webrtc.PayloadType(apt)] {
+			ordered = append(ordered, c)
+		}
+	}
+
+	return ordered
+}
+
+// policy name of a mime type, "" when not forwarded
+func codecName(mime string) string {
+	for name, m := range codecMimeTypes {
+		if strings.EqualFold(m, mime) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// receive slots a viewer offers, one per audio and video pair
+func ReceiveSlots(raw string) int {
+	desc := sdp.SessionDescription{}
+	if err := desc.Unmarshal([]byte(raw)); err != nil {
+		return 0
+	}
+
+	count := map[string]int{}
+	for _, media := range desc.MediaDescriptions {
+		count[media.MediaName.Media]++
+	}
+
+	return max(count["audio"], count["video"])
+}
+
+// whether a session description has an audio or video m-line at all,
+// phones publish no video and some encoders no audio
+func sdpHasMedia(raw string, kind webrtc.RTPCodecType) bool {
+	desc := sdp.SessionDescription{}
+	if err := desc.Unmarshal([]byte(raw)); err != nil {
+		return true
+	}
+
+	for _, media := range desc.MediaDescriptions {
+		if media.MediaName.Media == kind.String() && media.MediaName.Port.Value != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// video codec names found in a session description
+func sdpVideoCodecs(raw string) map[string]bool {
+	names := map[string]bool{}
+
+	desc := sdp.SessionDescription{}
+	if err := desc.Unmarshal([]byte(raw)); err != nil {
+		return names
+	}
+
+	for _, media := range desc.MediaDescriptions {
+		if media.MediaName.Media != "video" {
+			continue
+		}
+
+		for _, format := range media.MediaName.Formats {
+			pt, err := strconv.ParseUint(format, 10, 8)
+			if err != nil {
+				continue
+			}
+
+			codec, err := desc.GetCodecForPayloadType(uint8(pt))
+			if err != nil {
+				continue
+			}
+
+			if name := codecName("video/" + codec.Name); name != "" {
+				names[name] = true
+			}
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,108 @@
+package rtc
+
+import (
+	"sort"
+	"time"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/metrics"
+)
+
+const (
+	congestionInterval = time.Second
+	// bandwidth kept free for each forwarded audio track
+	audioReserve = 64_000
+	// extra bandwidth needed before going up a layer, avoids flapping
+	upgradeHeadroom = 1.15
+)
+
+func (s *SubConn) congestionCycle() {
+	ticker := time.NewTicker(congestionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.Ctx.Done():
+			return
+		case <-ticker.C:
+			s.applyCongestionPolicy()
+		}
+	}
+}
+
+// split the subscriber bandwidth estimate between forwarded videos,
+// audio is reserved first so it survives congestion
+func (s *SubConn) applyCongestionPolicy() {
+	target := s.Conn.TargetBitrate()
+	if target == 0 {
+		return
+	}
+
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	ids := make([]int, 0, len(s.Videos.SlotToOwner))
+	for id := range s.Videos.SlotToOwner {
+		if s.Videos.Slots[id].Fwd != nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	sort.Ints(ids)
+
+	// the client may advertise a lower limit with REMB
+	for _, id := range ids {
+		if remb := s.Videos.Slots[id].Fwd.REMB(); remb > 0 && remb < target {
+			target = remb
+		}
+	}
+
+	var available uint64
+	if reserve := uint64(audioReserve * len(ids)); target > reserve {
+		available = target - reserve
+	}
+	share := available / uint64(len(ids))
+
+	for _, id := range ids {
+		slot := s.Videos.Slots[id]
+
+		all, base := slot.Fwd.Rates()
+		current := slot.Fwd.Layer()
+		layer := pickLayer(current, available, all, base)
+
+		if layer != current {
+			slot.Fwd.SetLayer(layer)
+			metrics.LayerSwitched(layer.String())
+			s.Log.Info("video layer switched", "publisher", s.Videos.SlotToOwner[id], "layer", layer.String(), "target", target)
+		}
+
+		switch layer {
+		case domain.LayerAll:
+			available -= min(all, available)
+		case domain.LayerBase:
+			available -= min(base, available)
+		}
+
+		// let the publisher adapt to what this subscriber can take
+		slot.Pub.ReportBitrate(s.PeerID, share)
+	}
+}
+
+func pickLayer(current domain.Layer, available uint64, all uint64, base uint64) domain.Layer {
+	need := func(l domain.Layer, rate uint64) uint64 {
+		if l < current {
+			return uint64(float64(rate) * upgradeHeadroom)
+		}
+		return rate
+	}
+
+	switch {
+	case available >= need(domain.LayerAll, all):
+		return domain.LayerAll
+	case available >= need(domain.LayerBase, base):
+		return domain.LayerBase
+	}
+
+	return domain.LayerPaused
+}
@@ -1,27 +1,77 @@
 package rtc
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"time"
 	sfu "vidcall/api/proto"
 	"vidcall/internal/sfu/domain"
-	"vidcall/internal/sfu/service/hub"
-
+	"vidcall/internal/sfu/metrics"
+	"vidcall/pkg/config"
+	"vidcall/pkg/tracing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/interceptor/pkg/gcc"
+	"github.com/pion/interceptor/pkg/nack"
+	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/webrtc/v3"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type PConn struct {
 	*domain.PConn
 }
 
+// starting estimate before the first TWCC feedback arrives
+const initialBitrate = 1_000_000
+
 // create new peer connection
-func NewPConn(sendQ chan *sfu.PeerSignal, log *slog.Logger, debounceInterval time.Duration, withAudioLevel bool) (domain.Connection, error) {
+func NewPConn(ctx context.Context, sendQ chan *sfu.PeerSignal, log *slog.Logger, c *config.SFU, withAudioLevel bool, withBWE bool) (domain.Connection, error) {
 
-	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{
-		ICEServers: []webrtc.ICEServer{
-			{URLs: hub.Hub().GetStuns()},
-		},
+	m := &webrtc.MediaEngine{}
+	if err := registerCodecs(m); err != nil {
+		return nil, err
+	}
+
+	i := &interceptor.Registry{}
+	if err := registerInterceptors(m, i); err != nil {
+		return nil, err
+	}
+
+	// stream stats of this pc, fed by RTP and RTCP reports
+	var getter stats.Getter
+	statsFactory, err := stats.NewInterceptor()
+	if err != nil {
+		return nil, err
+	}
+	statsFactory.OnNewPeerConnection(func(_ string, g stats.Getter) { getter = g })
+	i.Add(statsFactory)
+
+	// send side bandwidth estimation from the client's TWCC feedback
+	var bwe cc.BandwidthEstimator
+	if withBWE {
+		if err := webrtc.ConfigureTWCCHeaderExtensionSender(m, i); err != nil {
+			return nil, err
+		}
+
+		ccFactory, err := cc.NewInterceptor(func() (cc.BandwidthEstimator, error) {
+			return gcc.NewSendSideBWE(
+				gcc.SendSideBWEInitialBitrate(initialBitrate),
+				gcc.SendSideBWEPacer(gcc.NewNoOpPacer()),
+			)
+		})
+		if err != nil {
+			return nil, err
+		}
+		ccFactory.OnNewPeerConnection(func(_ string, e cc.BandwidthEstimator) { bwe = e })
+		i.Add(ccFactory)
+	}
+
+	api := webrtc.NewAPI(webrtc.WithMediaEngine(m), webrtc.WithInterceptorRegistry(i))
+	pc, err := api.NewPeerConnection(webrtc.Configuration{
+		ICEServers: iceServers(c.ICE),
 	})
 
 	if err != nil {
@@ -31,22 +81,71 @@ func NewPConn(sendQ chan *sfu.PeerSignal, log *slog.Logger, debounceInterval tim
 
 	pconn := &PConn{
 		PConn: &domain.PConn{
+			Ctx:        ctx,
 			PC:         pc,
+			Stats:      getter,
+			BWE:        bwe,
+			Rates:      make(map[uint32]domain.RateSample),
 			Log:        log,
-			IceBuffers: make(chan webrtc.ICECandidateInit, 64),
+			IceBuffers: make(chan webrtc.ICECandidateInit, c.Peer.Queue),
 			SendQ:      sendQ,
+			IceDelay:   c.ICE.Debounce,
 		},
 	}
 
 	return pconn, nil
 }
 
+// helper function to list the STUN servers and the TURN server, if any
+func iceServers(c config.ICE) []webrtc.ICEServer {
+	servers := []webrtc.ICEServer{}
+	if len(c.Stuns) > 0 {
+		servers = append(servers, webrtc.ICEServer{URLs: c.Stuns})
+	}
+
+	if c.Turn != "" {
+		servers = append(servers, webrtc.ICEServer{
+			URLs:       []string{c.Turn},
+			Username:   c.TurnUsername,
+			Credential: c.TurnCredential,
+		})
+	}
+
+	return servers
+}
+
+// interceptors are set up by hand: subscriber NACKs are answered from the
+// publisher packet cache, so pion's per-sender NACK responder is left out
+func registerInterceptors(m *webrtc.MediaEngine, i *interceptor.Registry) error {
+	generator, err := nack.NewGeneratorInterceptor()
+	if err != nil {
+		return err
+	}
+
+	m.RegisterFeedback(webrtc.RTCPFeedback{Type: "nack"}, webrtc.RTPCodecTypeVideo)
+	m.RegisterFeedback(webrtc.RTCPFeedback{Type: "nack", Parameter: "pli"}, webrtc.RTPCodecTypeVideo)
+	i.Add(generator)
+
+	if err := webrtc.ConfigureRTCPReports(i); err != nil {
+		return err
+	}
+
+	if err := webrtc.ConfigureSimulcastExtensionHeaders(m); err != nil {
+		return err
+	}
+
+	return webrtc.ConfigureTWCCSender(m, i)
+}
+
 func (c *PConn) GetPC() *webrtc.PeerConnection {
 	return c.PC
 }
 
 // add ice from client
-func (c *PConn) HandleRemoteIce(candidate *sfu.PeerSignal_Ice) error {
+func (c *PConn) HandleRemoteIce(candidate *sfu.PeerSignal_Ice) (err error) {
+	_, span := tracing.Start(c.Ctx, "pconn.remote_ice", attribute.String("pc", candidate.Ice.Pc.String()))
+	defer func() { tracing.End(span, err) }()
+
 	mline := uint16(candidate.Ice.SdpMlineIndex)
 	ice := webrtc.ICECandidateInit{
 		Candidate:        candidate.Ice.Candidate,
@@ -67,7 +166,10 @@ func (c *PConn) HandleRemoteIce(candidate *sfu.PeerSignal_Ice) error {
 }
 
 // Send offer to client
-func (c *PConn) SendOffer(pc sfu.PcType) error {
+func (c *PConn) SendOffer(pc sfu.PcType) (err error) {
+	_, span := tracing.Start(c.Ctx, "pconn.send_offer", attribute.String("pc", pc.String()))
+	defer func() { tracing.End(span, err) }()
+
 	offer, err := c.PC.CreateOffer(nil)
 	if err != nil {
 		c.Log.Error("unable to create offer")
@@ -88,13 +190,16 @@ func (c *PConn) SendOffer(pc sfu.PcType) error {
 		},
 	}
 
-	c.enqueueSend(r)
+	c.EnqueueSend(r)
 
 	return nil
 }
 
 // handle offer from client
-func (c *PConn) HandleOffer(sdp *sfu.PeerSignal_Sdp) error {
+func (c *PConn) HandleOffer(sdp *sfu.PeerSignal_Sdp) (err error) {
+	_, span := tracing.Start(c.Ctx, "pconn.handle_offer", attribute.String("pc", sdp.Sdp.Pc.String()))
+	defer func() { tracing.End(span, err) }()
+
 	offer := webrtc.SessionDescription{
 		Type: webrtc.SDPTypeOffer,
 		SDP:  sdp.Sdp.Sdp,
@@ -110,6 +215,25 @@ func (c *PConn) HandleOffer(sdp *sfu.PeerSignal_Sdp) error {
 	// Flush already received ice candidates
 	go c.flushIce()
 
+	// answer with the room's codec order
+	if len(c.Codecs) > 0 {
+		for _, tx := range c.PC.GetTransceivers() {
+			if tx.Kind() != webrtc.RTPCodecTypeVideo || tx.Receiver() == nil {
+				continue
+			}
+
+			codecs := orderCodecs(tx.Receiver().GetParameters().Codecs, c.Codecs)
+			if len(codecs) == 0 {
+				continue
+			}
+
+			if err := tx.SetCodecPreferences(codecs); err != nil {
+				c.Log.Error("unable to set codec preferences")
+				return err
+			}
+		}
+	}
+
 	// Create answer and set local description
 	answer, err := c.PC.CreateAnswer(nil)
 	if err != nil {
@@ -133,13 +257,16 @@ func (c *PConn) HandleOffer(sdp *sfu.PeerSignal_Sdp) error {
 		},
 	}
 
-	c.enqueueSend(res)
+	c.EnqueueSend(res)
 
 	return nil
 }
 
 // handle answer from client
-func (c *PConn) HandleAnswer(sdp *sfu.PeerSignal_Sdp) error {
+func (c *PConn) HandleAnswer(sdp *sfu.PeerSignal_Sdp) (err error) {
+	_, span := tracing.Start(c.Ctx, "pconn.handle_answer", attribute.String("pc", sdp.Sdp.Pc.String()))
+	defer func() { tracing.End(span, err) }()
+
 	answer := webrtc.SessionDescription{
 		Type: webrtc.SDPTypeAnswer,
 		SDP:  sdp.Sdp.Sdp,
@@ -159,11 +286,17 @@ func (c *PConn) HandleAnswer(sdp *sfu.PeerSignal_Sdp) error {
 }
 
 // send ice to client
+// send a gathered candidate, batched with the ones that follow it closely.
+// The end of gathering sends what is left at once
 func (c *PConn) HandleLocalIce(candidate *webrtc.ICECandidate, pcType sfu.PcType) {
 	if candidate == nil {
+		c.sendLocalIce()
 		return
 	}
 
+	_, span := tracing.Start(c.Ctx, "pconn.local_ice", attribute.String("pc", pcType.String()))
+	defer span.End()
+
 	can := candidate.ToJSON()
 	ice := &sfu.IceCandidate{
 		Pc:            pcType,
@@ -178,7 +311,42 @@ func (c *PConn) HandleLocalIce(candidate *webrtc.ICECandidate, pcType sfu.PcType
 		},
 	}
 
-	c.enqueueSend(req)
+	c.IceMu.Lock()
+	defer c.IceMu.Unlock()
+
+	if c.IceClosed {
+		return
+	}
+
+	if c.IceDelay <= 0 {
+		c.EnqueueSend(req)
+		return
+	}
+
+	c.IcePending = append(c.IcePending, req)
+	if c.IceTimer == nil {
+		c.IceTimer = time.AfterFunc(c.IceDelay, c.sendLocalIce)
+	} else {
+		c.IceTimer.Reset(c.IceDelay)
+	}
+}
+
+// helper function to send the held back local candidates
+func (c *PConn) sendLocalIce() {
+	c.IceMu.Lock()
+	defer c.IceMu.Unlock()
+
+	if c.IceTimer != nil {
+		c.IceTimer.Stop()
+		c.IceTimer = nil
+	}
+
+	if !c.IceClosed {
+		for _, req := range c.IcePending {
+			c.EnqueueSend(req)
+		}
+	}
+	c.IcePending = nil
 }
 
 func (c *PConn) flushIce() {
@@ -199,15 +367,30 @@ func (c *PConn) flushIce() {
 	}
 }
 
-func (c *PConn) enqueueSend(msg *sfu.PeerSignal) {
+// video codecs accepted in answers, in order of preference
+func (c *PConn) SetVideoCodecs(policy []string) {
+	c.Codecs = policy
+}
+
+func (c *PConn) EnqueueSend(msg *sfu.PeerSignal) {
 	select {
 	case c.SendQ <- msg:
 	default:
+		metrics.DroppedSignal("send")
 	}
 }
 
 func (c *PConn) Close() error {
 
+	// the send queue is closed after the connection
+	c.IceMu.Lock()
+	c.IceClosed = true
+	if c.IceTimer != nil {
+		c.IceTimer.Stop()
+	}
+	c.IcePending = nil
+	c.IceMu.Unlock()
+
 	close(c.IceBuffers)
 
 	if err := c.PC.Close(); err != nil {
@@ -217,3 +400,66 @@ func (c *PConn) Close() error {
 
 	return nil
 }
+
+// quality of one stream of this pc, inbound for published tracks and
+// outbound (from receiver reports) for forwarded ones
+func (c *PConn) Quality(ssrc uint32, clockRate float64, inbound bool) *sfu.TrackQuality {
+	q := &sfu.TrackQuality{}
+	if c.Stats == nil {
+		return q
+	}
+
+	st := c.Stats.Get(ssrc)
+	if st == nil {
+		return q
+	}
+
+	var bytes uint64
+	if inbound {
+		in := st.InboundRTPStreamStats
+		expected := float64(in.PacketsReceived) + float64(in.PacketsLost)
+		if expected > 0 && in.PacketsLost > 0 {
+			q.PacketLoss = float64(in.PacketsLost) / expected
+		}
+		if clockRate > 0 {
+			q.JitterMs = in.Jitter / clockRate * 1000
+		}
+		q.RttMs = float64(st.RemoteOutboundRTPStreamStats.RoundTripTime.Milliseconds())
+		bytes = in.BytesReceived
+	} else {
+		remote := st.RemoteInboundRTPStreamStats
+		q.PacketLoss = remote.FractionLost
+		q.JitterMs = remote.Jitter * 1000
+		q.RttMs = float64(remote.RoundTripTime.Milliseconds())
+		bytes = st.OutboundRTPStreamStats.BytesSent
+	}
+
+	q.BitrateBps = c.bitrate(ssrc, bytes)
+	return q
+}
+
+// bitrate since the previous sample of the stream
+func (c *PConn) bitrate(ssrc uint32, bytes uint64) uint64 {
+	c.RatesMu.Lock()
+	defer c.RatesMu.Unlock()
+
+	now := time.Now()
+	last, ok := c.Rates[ssrc]
+	c.Rates[ssrc] = domain.RateSample{Bytes: bytes, At: now}
+
+	elapsed := now.Sub(last.At).Seconds()
+	if !ok || elapsed <= 0 || bytes < last.Bytes {
+		return 0
+	}
+
+	return uint64(float64(bytes-last.Bytes) * 8 / elapsed)
+}
+
+// bandwidth estimate towards the client in bps, 0 when not estimated
+func (c *PConn) TargetBitrate() uint64 {
+	if c.BWE == nil {
+		return 0
+	}
+
+	return uint64(c.BWE.GetTargetBitrate())
+}
@@ -0,0 +1,82 @@
+package rtc
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+
+	"github.com/pion/webrtc/v3"
+)
+
+func newTestPConn(t *testing.T, debounce time.Duration) *PConn {
+	t.Helper()
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = pc.Close() })
+
+	return &PConn{PConn: &domain.PConn{
+		Ctx:        context.Background(),
+		PC:         pc,
+		Log:        slog.Default(),
+		IceBuffers: make(chan webrtc.ICECandidateInit, 1),
+		SendQ:      make(chan *sfu.PeerSignal, 8),
+		IceDelay:   debounce,
+	}}
+}
+
+func hostCandidate(port uint16) *webrtc.ICECandidate {
+	return &webrtc.ICECandidate{
+		Foundation: "1",
+		Address:    "127.0.0.1",
+		Protocol:   webrtc.ICEProtocolUDP,
+		Port:       port,
+		Typ:        webrtc.ICECandidateTypeHost,
+		Component:  1,
+	}
+}
+
+func TestLocalIceDebounce(t *testing.T) {
+	const debounce = 30 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		debounce time.Duration
+		// what happens after the two candidates
+		after func(c *PConn)
+		// candidates sent right away, and once the debounce is over
+		now   int
+		later int
+	}{
+		{name: "off", debounce: 0, now: 2, later: 2},
+		{name: "batched", debounce: debounce, now: 0, later: 2},
+		{name: "end of gathering", debounce: debounce, after: func(c *PConn) { c.HandleLocalIce(nil, sfu.PcType_PUB) }, now: 2, later: 2},
+		{name: "closed", debounce: debounce, after: func(c *PConn) { _ = c.Close() }, now: 0, later: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestPConn(t, tt.debounce)
+
+			c.HandleLocalIce(hostCandidate(5000), sfu.PcType_PUB)
+			c.HandleLocalIce(hostCandidate(5001), sfu.PcType_PUB)
+			if tt.after != nil {
+				tt.after(c)
+			}
+
+			if n := len(c.SendQ); n != tt.now {
+				t.Fatalf("sent %d candidates at once, want %d", n, tt.now)
+			}
+
+			time.Sleep(3 * debounce)
+			if n := len(c.SendQ); n != tt.later {
+				t.Fatalf("sent %d candidates after the debounce, want %d", n, tt.later)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package rtc
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"vidcall/internal/sfu/domain"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	rembTTL        = 3 * time.Second
+	keyframeRetry  = 500 * time.Millisecond
+	picIDMask7Bit  = 0x7f
+	picIDMask15Bit = 0x7fff
+)
+
+// Forwarder drops video by layer and rewrites sequence numbers and VP8
+// picture IDs so the subscriber sees a continuous stream
+type Forwarder struct {
+	mu       sync.Mutex
+	vp8      bool
+	keyframe func()
+
+	target     domain.Layer
+	maxLayer   domain.Layer
+	layer      domain.Layer
+	frameStart bool
+	waitKey    bool
+	waitSync   bool
+	lastKeyReq time.Time
+
+	resync    bool
+	lastOut   uint16
+	seqOffset uint16
+	picOffset uint16
+	dropped   bool
+	lastDrop  uint16
+
+	allBytes  uint64
+	baseBytes uint64
+	lastRates time.Time
+
+	remb   uint64
+	rembAt time.Time
+
+	history [cacheSize]sent
+}
+
+// how a forwarded packet was rewritten
+type sent struct {
+	out       uint16
+	in        uint16
+	picOffset uint16
+	valid     bool
+}
+
+func NewForwarder(mimeType string, keyframe func()) *Forwarder {
+	return &Forwarder{
+		vp8:        strings.EqualFold(mimeType, webrtc.MimeTypeVP8),
+		keyframe:   keyframe,
+		frameStart: true,
+		lastRates:  time.Now(),
+	}
+}
+
+func (f *Forwarder) Layer() domain.Layer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.target
+}
+
+// switch layer at the next frame boundary
+func (f *Forwarder) SetLayer(l domain.Layer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.target = l
+}
+
+func (f *Forwarder) Forward(pkt *rtp.Packet) *rtp.Packet {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.frameStart {
+		f.apply(max(f.target, f.maxLayer))
+	}
+	f.frameStart = pkt.Marker
+
+	var (
+		d  vp8Desc
+		ok bool
+	)
+	if f.vp8 {
+		d, ok = parseVP8(pkt.Payload)
+	}
+
+	size := uint64(pkt.MarshalSize())
+	f.allBytes += size
+	if !ok || d.tid == 0 {
+		f.baseBytes += size
+	}
+
+	if f.drop(d, ok) {
+		f.seqOffset++
+		if ok && d.picIDLen > 0 && (!f.dropped || d.picID != f.lastDrop) {
+			f.picOffset++
+			f.lastDrop = d.picID
+			f.dropped = true
+		}
+		return nil
+	}
+
+	// continue right after the last packet sent before the pause
+	if f.resync {
+		f.seqOffset = pkt.SequenceNumber - f.lastOut - 1
+		f.resync = false
+	}
+
+	outSeq := pkt.SequenceNumber - f.seqOffset
+	f.lastOut = outSeq
+	f.history[outSeq%cacheSize] = sent{out: outSeq, in: pkt.SequenceNumber, picOffset: f.picOffset, valid: true}
+
+	return f.rewrite(pkt, outSeq, f.picOffset)
+}
+
+// publisher sequence number of a forwarded packet. Only the last cacheSize
+// sequence numbers sent are known, anything else is not ours to resend
+func (f *Forwarder) Resolve(outSeq uint16) (uint16, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	last := f.history[f.lastOut%cacheSize]
+	if !last.valid || last.out != f.lastOut || f.lastOut-outSeq >= cacheSize {
+		return 0, false
+	}
+
+	if h, ok := f.sent(outSeq); ok {
+		return h.in, true
+	}
+
+	// never forwarded, it was lost before reaching us. Its publisher
+	// sequence number is only known when nothing was dropped around it
+	var prev, next sent
+	for o := outSeq - 1; f.lastOut-o < cacheSize && !prev.valid; o-- {
+		prev, _ = f.sent(o)
+	}
+	for o := outSeq + 1; o != f.lastOut+1 && !next.valid; o++ {
+		next, _ = f.sent(o)
+	}
+
+	if !prev.valid || !next.valid || next.in-prev.in != next.out-prev.out {
+		return 0, false
+	}
+
+	return prev.in + (outSeq - prev.out), true
+}
+
+// helper function to look up a forwarded packet in the history
+func (f *Forwarder) sent(outSeq uint16) (sent, bool) {
+	h := f.history[outSeq%cacheSize]
+	if h.valid && h.out == outSeq {
+		return h, true
+	}
+	return sent{}, false
+}
+
+func (f *Forwarder) Rewrite(pkt *rtp.Packet, outSeq uint16) *rtp.Packet {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var picOffset uint16
+	if h, ok := f.sent(outSeq); ok {
+		picOffset = h.picOffset
+	}
+
+	return f.rewrite(pkt, outSeq, picOffset)
+}
+
+func (f *Forwarder) rewrite(pkt *rtp.Packet, outSeq uint16, picOffset uint16) *rtp.Packet {
+	out := *pkt
+	out.SequenceNumber = outSeq
+
+	if !f.vp8 || picOffset == 0 {
+		return &out
+	}
+
+	d, ok := parseVP8(pkt.Payload)
+	if !ok || d.picIDLen == 0 {
+		return &out
+	}
+
+	mask := uint16(picIDMask7Bit)
+	if d.picIDLen == 2 {
+		mask = picIDMask15Bit
+	}
+	out.Payload = d.withPicID(pkt.Payload, (d.picID-picOffset)&mask)
+
+	return &out
+}
+
+// cap the layer picked by the congestion policy
+func (f *Forwarder) SetMaxLayer(l domain.Layer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maxLayer = l
+}
+
+// forwarding was stopped and restarts on the next keyframe
+func (f *Forwarder) Resync() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.resync = true
+	f.waitKey = f.vp8
+	f.frameStart = true
+	f.lastKeyReq = time.Time{}
+	f.requestKeyframe()
+}
+
+// incoming bitrate of all layers and of the base layer since last call
+func (f *Forwarder) Rates() (uint64, uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(f.lastRates).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+
+	all := uint64(float64(f.allBytes) * 8 / elapsed)
+	base := uint64(float64(f.baseBytes) * 8 / elapsed)
+
+	f.allBytes, f.baseBytes = 0, 0
+	f.lastRates = now
+
+	return all, base
+}
+
+// limit advertised by the subscriber with REMB
+func (f *Forwarder) OnREMB(bitrate uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.remb = bitrate
+	f.rembAt = time.Now()
+}
+
+func (f *Forwarder) REMB() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if time.Since(f.rembAt) > rembTTL {
+		return 0
+	}
+	return f.remb
+}
+
+func (f *Forwarder) apply(l domain.Layer) {
+	if l == f.layer {
+		return
+	}
+
+	switch {
+	// resuming needs a keyframe to decode from
+	case f.layer == domain.LayerPaused:
+		f.waitKey = f.vp8
+		f.requestKeyframe()
+
+	// higher temporal layers reference frames that were dropped
+	case f.layer == domain.LayerBase && l == domain.LayerAll:
+		f.waitSync = true
+	}
+
+	f.layer = l
+}
+
+func (f *Forwarder) drop(d vp8Desc, ok bool) bool {
+	switch {
+	case f.layer == domain.LayerPaused:
+		return true
+
+	case f.waitKey:
+		if ok && d.keyframe {
+			f.waitKey = false
+			f.waitSync = false
+			return false
+		}
+		f.requestKeyframe()
+		return true
+
+	case !ok:
+		return false
+
+	case d.keyframe:
+		f.waitSync = false
+		return false
+
+	case d.tid > 0 && f.layer == domain.LayerBase:
+		return true
+
+	case d.tid > 0 && f.waitSync:
+		if d.sync {
+			f.waitSync = false
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+func (f *Forwarder) requestKeyframe() {
+	if f.keyframe == nil || time.Since(f.lastKeyReq) < keyframeRetry {
+		return
+	}
+
+	f.lastKeyReq = time.Now()
+	go f.keyframe()
+}
@@ -0,0 +1,217 @@
+package rtc
+
+import (
+	"testing"
+	"vidcall/internal/sfu/domain"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+// helper function to build a one packet VP8 frame with a 15 bit picture ID
+func vp8Packet(seq uint16, picID uint16, tid uint8) *rtp.Packet {
+	return &rtp.Packet{
+		Header: rtp.Header{SequenceNumber: seq, Marker: true},
+		Payload: []byte{
+			0x90,                       // X, S, partition 0
+			0xa0,                       // I, T
+			0x80 | byte(picID>>8&0x7f), // M, picture ID
+			byte(picID),
+			tid << 6, // TID
+			0x01,     // interframe
+		},
+	}
+}
+
+type fwdIn struct {
+	seq   uint16
+	picID uint16
+	tid   uint8
+	layer domain.Layer
+}
+
+type fwdOut struct {
+	drop  bool
+	seq   uint16
+	picID uint16
+}
+
+type resolved struct {
+	in uint16
+	ok bool
+}
+
+func TestForwarder(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []fwdIn
+		out     []fwdOut
+		resolve map[uint16]resolved
+	}{
+		{
+			name: "wraparound across a layer switch",
+			in: []fwdIn{
+				{65533, 0x7ffd, 0, domain.LayerAll},
+				{65534, 0x7ffe, 1, domain.LayerAll},
+				{65535, 0x7fff, 0, domain.LayerBase},
+				{0, 0, 1, domain.LayerBase},
+				{1, 1, 0, domain.LayerBase},
+				{2, 2, 1, domain.LayerBase},
+				{3, 3, 0, domain.LayerBase},
+			},
+			out: []fwdOut{
+				{false, 65533, 0x7ffd},
+				{false, 65534, 0x7ffe},
+				{false, 65535, 0x7fff},
+				{true, 0, 0},
+				{false, 0, 0},
+				{true, 0, 0},
+				{false, 1, 1},
+			},
+			resolve: map[uint16]resolved{
+				65533: {65533, true},
+				65535: {65535, true},
+				0:     {1, true},
+				1:     {3, true},
+				// not forwarded yet
+				2: {0, false},
+			},
+		},
+		{
+			name: "gap",
+			in: []fwdIn{
+				{100, 10, 0, domain.LayerAll},
+				{101, 11, 0, domain.LayerAll},
+				{103, 13, 0, domain.LayerAll},
+				{104, 14, 0, domain.LayerAll},
+			},
+			out: []fwdOut{
+				{false, 100, 10},
+				{false, 101, 11},
+				{false, 103, 13},
+				{false, 104, 14},
+			},
+			resolve: map[uint16]resolved{
+				101: {101, true},
+				// lost upstream, nothing dropped around it
+				102: {102, true},
+				103: {103, true},
+			},
+		},
+		{
+			name: "gap next to a dropped packet",
+			in: []fwdIn{
+				{100, 10, 0, domain.LayerBase},
+				{101, 11, 1, domain.LayerBase},
+				{103, 13, 0, domain.LayerBase},
+				{104, 14, 0, domain.LayerBase},
+			},
+			out: []fwdOut{
+				{false, 100, 10},
+				{true, 0, 0},
+				// the lost picture stays a gap, only the dropped one is closed
+				{false, 102, 12},
+				{false, 103, 13},
+			},
+			resolve: map[uint16]resolved{
+				100: {100, true},
+				// either 101 or 102 of the publisher
+				101: {0, false},
+				102: {103, true},
+				103: {104, true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewForwarder(webrtc.MimeTypeVP8, nil)
+
+			for i, in := range tt.in {
+				f.SetLayer(in.layer)
+				got := f.Forward(vp8Packet(in.seq, in.picID, in.tid))
+
+				want := tt.out[i]
+				if want.drop {
+					if got != nil {
+						t.Fatalf("packet %d forwarded as %d, want dropped", in.seq, got.SequenceNumber)
+					}
+					continue
+				}
+				if got == nil {
+					t.Fatalf("packet %d dropped", in.seq)
+				}
+
+				d, _ := parseVP8(got.Payload)
+				if got.SequenceNumber != want.seq || d.picID != want.picID {
+					t.Errorf("packet %d = seq %d picID %#x, want seq %d picID %#x", in.seq, got.SequenceNumber, d.picID, want.seq, want.picID)
+				}
+			}
+
+			for out, want := range tt.resolve {
+				in, ok := f.Resolve(out)
+				if ok != want.ok || (ok && in != want.in) {
+					t.Errorf("Resolve(%d) = %d, %v, want %d, %v", out, in, ok, want.in, want.ok)
+				}
+			}
+		})
+	}
+}
+
+func TestForwarderResolveWindow(t *testing.T) {
+	f := NewForwarder(webrtc.MimeTypeVP8, nil)
+
+	if _, ok := f.Resolve(0); ok {
+		t.Fatal("Resolve() before any packet was forwarded")
+	}
+
+	// start close to the wraparound so the window spans it
+	start := uint16(65000)
+	for i := range uint16(2 * cacheSize) {
+		f.Forward(vp8Packet(start+i, i&0x7fff, 0))
+	}
+	last := start + 2*cacheSize - 1
+
+	tests := []struct {
+		name string
+		out  uint16
+		ok   bool
+	}{
+		{"last", last, true},
+		{"oldest kept", last - cacheSize + 1, true},
+		{"evicted", last - cacheSize, false},
+		{"long gone", last - 3*cacheSize, false},
+		{"not sent yet", last + 1, false},
+	}
+
+	for _, tt := range tests {
+		in, ok := f.Resolve(tt.out)
+		if ok != tt.ok {
+			t.Errorf("%s: Resolve(%d) ok = %v, want %v", tt.name, tt.out, ok, tt.ok)
+		}
+		if ok && in != tt.out {
+			t.Errorf("%s: Resolve(%d) = %d", tt.name, tt.out, in)
+		}
+	}
+}
+
+func TestForwarderRewrite(t *testing.T) {
+	f := NewForwarder(webrtc.MimeTypeVP8, nil)
+	f.SetLayer(domain.LayerBase)
+
+	f.Forward(vp8Packet(10, 0x7fff, 0))
+	f.Forward(vp8Packet(11, 0, 1))
+	f.Forward(vp8Packet(12, 1, 0))
+
+	in, ok := f.Resolve(11)
+	if !ok || in != 12 {
+		t.Fatalf("Resolve(11) = %d, %v, want 12, true", in, ok)
+	}
+
+	// a retransmission carries the rewrite of the original send
+	got := f.Rewrite(vp8Packet(in, 1, 0), 11)
+	d, _ := parseVP8(got.Payload)
+	if got.SequenceNumber != 11 || d.picID != 0 {
+		t.Fatalf("Rewrite() = seq %d picID %#x, want seq 11 picID 0", got.SequenceNumber, d.picID)
+	}
+}
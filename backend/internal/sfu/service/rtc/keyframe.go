@@ -0,0 +1,100 @@
+package rtc
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/metrics"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+)
+
+// KeyframeRequester merges the keyframe requests of every subscriber of a
+// publisher video track into at most one PLI or FIR per interval
+type KeyframeRequester struct {
+	mu       sync.Mutex
+	pc       *webrtc.PeerConnection
+	log      *slog.Logger
+	ssrc     uint32
+	interval time.Duration
+
+	last    time.Time
+	pending bool
+	fir     bool
+	firSeq  uint8
+}
+
+func NewKeyframeRequester(pc *webrtc.PeerConnection, interval time.Duration, log *slog.Logger) *KeyframeRequester {
+	return &KeyframeRequester{pc: pc, interval: interval, log: log}
+}
+
+// publisher track the requests go to
+func (k *KeyframeRequester) SetSSRC(ssrc uint32) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.ssrc = ssrc
+}
+
+// send now, or once when the interval is over if a request was just sent
+func (k *KeyframeRequester) Request(kind domain.KeyframeKind) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.ssrc == 0 {
+		return
+	}
+
+	// FIR wins over PLI when requests are merged
+	if kind == domain.KeyframeFIR {
+		k.fir = true
+	}
+
+	wait := k.interval - time.Since(k.last)
+	if wait <= 0 {
+		k.send()
+		return
+	}
+
+	metrics.KeyframeRequest(kind.String(), "merged")
+	if k.pending {
+		return
+	}
+
+	k.pending = true
+	time.AfterFunc(wait, k.flush)
+}
+
+func (k *KeyframeRequester) flush() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.pending {
+		k.send()
+	}
+}
+
+func (k *KeyframeRequester) send() {
+	k.pending = false
+	k.last = time.Now()
+
+	kind := domain.KeyframePLI
+	var pkt rtcp.Packet = &rtcp.PictureLossIndication{MediaSSRC: k.ssrc}
+
+	if k.fir {
+		kind = domain.KeyframeFIR
+		k.fir = false
+		k.firSeq++
+		pkt = &rtcp.FullIntraRequest{
+			MediaSSRC: k.ssrc,
+			FIR:       []rtcp.FIREntry{{SSRC: k.ssrc, SequenceNumber: k.firSeq}},
+		}
+	}
+
+	if err := k.pc.WriteRTCP([]rtcp.Packet{pkt}); err != nil {
+		k.log.Error("unable to request keyframe", "kind", kind.String())
+		return
+	}
+	metrics.KeyframeRequest(kind.String(), "sent")
+}
@@ -0,0 +1,138 @@
+package rtc
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"vidcall/internal/sfu/domain"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+)
+
+// interceptor keeping the RTCP a peer connection writes, nothing reaches
+// the network
+type rtcpRecorder struct {
+	interceptor.NoOp
+
+	mu   sync.Mutex
+	pkts []rtcp.Packet
+}
+
+func (r *rtcpRecorder) NewInterceptor(_ string) (interceptor.Interceptor, error) {
+	return r, nil
+}
+
+func (r *rtcpRecorder) BindRTCPWriter(_ interceptor.RTCPWriter) interceptor.RTCPWriter {
+	return interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.pkts = append(r.pkts, pkts...)
+		return 0, nil
+	})
+}
+
+func (r *rtcpRecorder) sent() []rtcp.Packet {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]rtcp.Packet(nil), r.pkts...)
+}
+
+func newRecordedPC(t *testing.T) (*webrtc.PeerConnection, *rtcpRecorder) {
+	t.Helper()
+
+	rec := &rtcpRecorder{}
+	reg := &interceptor.Registry{}
+	reg.Add(rec)
+
+	pc, err := webrtc.NewAPI(webrtc.WithInterceptorRegistry(reg)).NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	return pc, rec
+}
+
+// helper function to name the requests as the publisher gets them
+func kinds(pkts []rtcp.Packet) []string {
+	names := []string{}
+	for _, pkt := range pkts {
+		switch pkt.(type) {
+		case *rtcp.PictureLossIndication:
+			names = append(names, "PLI")
+		case *rtcp.FullIntraRequest:
+			names = append(names, "FIR")
+		}
+	}
+	return names
+}
+
+func TestKeyframeMerge(t *testing.T) {
+	const interval = 30 * time.Millisecond
+	pli, fir := domain.KeyframePLI, domain.KeyframeFIR
+
+	tests := []struct {
+		name     string
+		requests []domain.KeyframeKind
+		want     []string
+	}{
+		{"single request", []domain.KeyframeKind{pli}, []string{"PLI"}},
+		{"burst sends one more after the interval", []domain.KeyframeKind{pli, pli, pli, pli}, []string{"PLI", "PLI"}},
+		{"FIR wins the merge", []domain.KeyframeKind{pli, pli, fir, pli}, []string{"PLI", "FIR"}},
+		{"FIR right away", []domain.KeyframeKind{fir, pli}, []string{"FIR", "PLI"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc, rec := newRecordedPC(t)
+			k := NewKeyframeRequester(pc, interval, slog.Default())
+			k.SetSSRC(1234)
+
+			for _, kind := range tt.requests {
+				k.Request(kind)
+			}
+			time.Sleep(3 * interval)
+
+			if got := kinds(rec.sent()); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyframeTarget(t *testing.T) {
+	pc, rec := newRecordedPC(t)
+	k := NewKeyframeRequester(pc, 0, slog.Default())
+
+	// no published track yet
+	k.Request(domain.KeyframePLI)
+	if n := len(rec.sent()); n != 0 {
+		t.Fatalf("got %d requests before the track is known", n)
+	}
+
+	k.SetSSRC(42)
+	k.Request(domain.KeyframePLI)
+	k.Request(domain.KeyframeFIR)
+	k.Request(domain.KeyframeFIR)
+
+	sent := rec.sent()
+	if len(sent) != 3 {
+		t.Fatalf("got %d requests, want 3", len(sent))
+	}
+
+	if p, ok := sent[0].(*rtcp.PictureLossIndication); !ok || p.MediaSSRC != 42 {
+		t.Errorf("got %v, want a PLI for 42", sent[0])
+	}
+
+	// FIR sequence numbers tell a new request from a repeated one
+	for i, want := range []uint8{1, 2} {
+		f, ok := sent[i+1].(*rtcp.FullIntraRequest)
+		if !ok || f.MediaSSRC != 42 || len(f.FIR) != 1 || f.FIR[0].SSRC != 42 || f.FIR[0].SequenceNumber != want {
+			t.Errorf("request %d: got %+v, want a FIR for 42 numbered %d", i+1, sent[i+1], want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package rtc
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/metrics"
+	"vidcall/pkg/audio"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+)
+
+const mixFrame = 20 * time.Millisecond
+
+// AudioMix is the MCU audio of one subscriber: the audio of every peer it
+// subscribes to is decoded, summed and encoded again every frame. Opus is
+// sent when the build can encode it, PCMU otherwise.
+type AudioMix struct {
+	ctx      context.Context
+	log      *slog.Logger
+	listener string
+
+	track *webrtc.TrackLocalStaticSample
+	enc   audio.Encoder
+	rate  int
+	mixer *audio.Mixer
+
+	mu      sync.Mutex
+	sources map[string]context.CancelFunc
+}
+
+func NewAudioMix(ctx context.Context, listener string, log *slog.Logger) (*AudioMix, error) {
+	capability := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMU, ClockRate: 8000}
+	if audio.CanEncode(audio.MimeOpus) {
+		capability = webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2, SDPFmtpLine: "minptime=10;useinbandfec=1"}
+	}
+
+	rate := int(capability.ClockRate)
+	enc, err := audio.NewEncoder(capability.MimeType, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	track, err := webrtc.NewTrackLocalStaticSample(capability, "mix"+listener, "pion")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AudioMix{
+		ctx:      ctx,
+		log:      log.With("mix", capability.MimeType),
+		listener: listener,
+		track:    track,
+		enc:      enc,
+		rate:     rate,
+		mixer:    audio.NewMixer(audio.Samples(rate, mixFrame)),
+		sources:  make(map[string]context.CancelFunc),
+	}, nil
+}
+
+func (m *AudioMix) Track() webrtc.TrackLocal {
+	return m.track
+}
+
+// start mixing a remote peer's audio, a codec the build cannot decode is
+// left out of the mix
+func (m *AudioMix) Add(peerID string, pub domain.Publisher, mime string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sources[peerID]; ok || peerID == m.listener {
+		return
+	}
+
+	dec, err := audio.NewDecoder(mime, m.rate)
+	if err != nil {
+		m.log.Warn("unable to mix peer audio", "peer ID", peerID, "codec", mime, "err", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.sources[peerID] = cancel
+	go m.decode(ctx, peerID, pub, dec)
+}
+
+func (m *AudioMix) Remove(peerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cancel, ok := m.sources[peerID]; ok {
+		cancel()
+		delete(m.sources, peerID)
+	}
+}
+
+// send one mixed frame per tick until the subscriber is gone, silence
+// keeps the stream going while no one talks
+func (m *AudioMix) Run() {
+	ticker := time.NewTicker(mixFrame)
+	defer ticker.Stop()
+
+	n := audio.Samples(m.rate, mixFrame)
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pcm := audio.Sum(m.mixer.Next(), m.listener, n)
+
+		payload, err := m.enc.Encode(pcm)
+		if err != nil {
+			m.log.Error("unable to encode mixed audio", "err", err)
+			continue
+		}
+
+		if err := m.track.WriteSample(media.Sample{Data: payload, Duration: mixFrame}); err != nil {
+			m.log.Error("unable to send mixed audio")
+			return
+		}
+		metrics.ForwardedRTP("audio", len(payload))
+	}
+}
+
+// the only reader of a remote peer's audio for this mix
+func (m *AudioMix) decode(ctx context.Context, peerID string, pub domain.Publisher, dec audio.Decoder) {
+	q, stop := pub.Tap(webrtc.RTPCodecTypeAudio)
+	defer stop()
+	defer m.mixer.Remove(peerID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pkt, ok := <-q:
+			if !ok {
+				return
+			}
+
+			pcm, err := dec.Decode(pkt.Payload)
+			if err != nil {
+				metrics.DroppedRTP("undecodable")
+				continue
+			}
+			m.mixer.Push(peerID, pcm)
+		}
+	}
+}
@@ -3,30 +3,65 @@ package rtc
 import (
 	"context"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 	sfu "vidcall/api/proto"
 	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/metrics"
+	"vidcall/pkg/config"
 
 	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3"
 )
 
 type PubConn struct {
 	*domain.PubConn
-	wg sync.WaitGroup
+
+	// published tracks, kinds of the last offer and of the tracks
+	// received, ready closes once every expected track is here
+	trackMu  sync.RWMutex
+	expected map[webrtc.RTPCodecType]bool
+	received map[webrtc.RTPCodecType]bool
+	ready    chan struct{}
+	isReady  bool
+
+	keyframes *KeyframeRequester
+
+	// one reader per published track, fanned out to the subscribers. They
+	// live as long as the publisher, tracks may come and go
+	audio *Broadcaster
+	video *Broadcaster
+
+	// upstream NACKs already sent, shared by all subscribers
+	nackMu   sync.Mutex
+	nackSent map[uint16]time.Time
+
+	codecMu sync.Mutex
+	// called once the video track switched codec, the subscribers' local
+	// tracks were built for the old one
+	onCodec func()
 }
 
+const (
+	keyframeInterval = 500 * time.Millisecond
+	rembInterval     = time.Second
+	minREMB          = 100_000
+	nackInterval     = 100 * time.Millisecond
+)
+
 // Create conncection for client to push media
-func NewPublisher(ctx context.Context, sendQ chan *sfu.PeerSignal, log *slog.Logger, debounceInterval time.Duration) (domain.Publisher, error) {
+func NewPublisher(ctx context.Context, sendQ chan *sfu.PeerSignal, log *slog.Logger, c *config.SFU) (domain.Publisher, error) {
 
-	conn, err := NewPConn(sendQ, log, debounceInterval, true)
+	pubCtx, pubCancel := context.WithCancel(ctx)
+
+	conn, err := NewPConn(pubCtx, sendQ, log, c, true, false)
 	if err != nil {
+		pubCancel()
 		return nil, err
 	}
 
-	pubCtx, pubCancel := context.WithCancel(ctx)
-
 	p := &PubConn{
 		PubConn: &domain.PubConn{
 			Log:     log,
@@ -36,12 +71,19 @@ func NewPublisher(ctx context.Context, sendQ chan *sfu.PeerSignal, log *slog.Log
 			Cancel:  pubCancel,
 			RecvSdp: make(chan *sfu.PeerSignal_Sdp),
 			RecvIce: make(chan *sfu.PeerSignal_Ice),
+			Budgets: make(map[string]domain.BitrateReport),
+			Cache:   NewPacketCache(),
 		},
+		nackSent:  make(map[uint16]time.Time),
+		keyframes: NewKeyframeRequester(conn.GetPC(), keyframeInterval, log),
+		audio:     NewBroadcaster(),
+		video:     NewBroadcaster(),
+		// audio and video until an offer says otherwise
+		expected: map[webrtc.RTPCodecType]bool{webrtc.RTPCodecTypeAudio: true, webrtc.RTPCodecTypeVideo: true},
+		received: make(map[webrtc.RTPCodecType]bool),
+		ready:    make(chan struct{}),
 	}
 
-	// wait group for publisher audio and video tracks attachment
-	p.wg.Add(2)
-
 	return p, nil
 
 }
@@ -61,6 +103,7 @@ func (p *PubConn) WireCallBacks(peerID string) {
 
 // start ice/sdp exchange for pc
 func (p *PubConn) Connect() error {
+	go p.rembCycle()
 
 	for {
 		select {
@@ -73,6 +116,15 @@ func (p *PubConn) Connect() error {
 			}
 
 			if sdp.Sdp.Type == sfu.SdpType_OFFER {
+				policy := p.codecPolicy()
+				if !offersCodec(sdp.Sdp.Sdp, policy) {
+					p.Log.Warn("offer has no video codec allowed in the room", "codecs", policy)
+					p.sendCodecRejected(policy)
+					continue
+				}
+
+				p.expectTracks(sdp.Sdp.Sdp)
+				p.Conn.SetVideoCodecs(policy)
 				if err := p.Conn.HandleOffer(sdp); err != nil {
 					return err
 				}
@@ -101,6 +153,10 @@ func (p *PubConn) Disconnect() error {
 
 	p.Cancel()
 
+	// end the pumps and taps of every subscriber
+	p.audio.Close()
+	p.video.Close()
+
 	close(p.RecvSdp)
 	close(p.RecvIce)
 
@@ -109,15 +165,99 @@ func (p *PubConn) Disconnect() error {
 	return nil
 }
 
+// published tracks, once those of the first offer are all here
 func (p *PubConn) GetLocalAV() *domain.PubAV {
-	p.wg.Wait()
-	return p.AV
+	select {
+	case <-p.ready:
+	case <-p.Ctx.Done():
+	}
+
+	av := p.Tracks()
+	return &av
+}
+
+// GetLocalAV waits only for the kinds the offer publishes, an audio only
+// phone or a video only encoder would never send the other track
+func (p *PubConn) expectTracks(offer string) {
+	p.trackMu.Lock()
+	defer p.trackMu.Unlock()
+
+	for _, kind := range []webrtc.RTPCodecType{webrtc.RTPCodecTypeAudio, webrtc.RTPCodecTypeVideo} {
+		p.expected[kind] = sdpHasMedia(offer, kind)
+	}
+	p.checkReady()
+}
+
+// helper function to release GetLocalAV, called with trackMu held. Tracks
+// added by a later offer, like a camera turned on, are taken as they come
+func (p *PubConn) checkReady() {
+	if p.isReady {
+		return
+	}
+
+	for kind, want := range p.expected {
+		if want && !p.received[kind] {
+			return
+		}
+	}
+
+	p.isReady = true
+	close(p.ready)
+}
+
+// tracks published so far, without waiting for both
+func (p *PubConn) Tracks() domain.PubAV {
+	p.trackMu.RLock()
+	defer p.trackMu.RUnlock()
+	return *p.AV
+}
+
+// raw packets of a published track for in-process consumers like the
+// egress, stop unregisters the queue
+func (p *PubConn) Tap(kind webrtc.RTPCodecType) (<-chan *rtp.Packet, func()) {
+	b := p.audio
+	if kind == webrtc.RTPCodecTypeVideo {
+		b = p.video
+	}
+
+	q := b.Register()
+	return q, func() { b.Unregister(q) }
+}
+
+// answer an offer in one round trip with every ICE candidate in the SDP,
+// for encoders that do not trickle (WHIP)
+func (p *PubConn) Answer(ctx context.Context, offer string) (string, error) {
+	policy := p.codecPolicy()
+	if !offersCodec(offer, policy) {
+		return "", domain.ErrBadOffer
+	}
+
+	pc := p.Conn.GetPC()
+	gathered := webrtc.GatheringCompletePromise(pc)
+
+	p.expectTracks(offer)
+	p.Conn.SetVideoCodecs(policy)
+	err := p.Conn.HandleOffer(&sfu.PeerSignal_Sdp{
+		Sdp: &sfu.Sdp{Pc: sfu.PcType_PUB, Type: sfu.SdpType_OFFER, Sdp: offer},
+	})
+	if err != nil {
+		return "", domain.ErrBadOffer
+	}
+
+	select {
+	case <-gathered:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	return pc.LocalDescription().SDP, nil
 }
 
 func (p *PubConn) EnqueueSdp(sdp *sfu.PeerSignal_Sdp) {
 	select {
 	case p.RecvSdp <- sdp:
 	default:
+		metrics.DroppedSignal("sdp")
 	}
 }
 
@@ -125,36 +265,80 @@ func (p *PubConn) EnqueueIce(ice *sfu.PeerSignal_Ice) {
 	select {
 	case p.RecvIce <- ice:
 	default:
+		metrics.DroppedSignal("ice")
 	}
 }
 
 // set up on track
 func (p *PubConn) handleOnTrack(remote *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
 
+	p.trackMu.Lock()
+	defer p.trackMu.Unlock()
+
 	switch remote.Kind() {
 	case webrtc.RTPCodecTypeVideo:
+		// a camera added back may come with another codec
+		if prev := p.AV.Video; prev != nil && prev.Codec().MimeType != remote.Codec().MimeType {
+			go p.videoCodecChanged()
+		}
 		p.AV.Video = remote
-		p.wg.Done()
+		p.keyframes.SetSSRC(uint32(remote.SSRC()))
+		go p.readTrack(remote, p.video)
 
 	case webrtc.RTPCodecTypeAudio:
 		p.AV.Audio = remote
-		p.wg.Done()
+		go p.readTrack(remote, p.audio)
+
+	default:
+		return
+	}
+
+	p.received[remote.Kind()] = true
+	p.checkReady()
+}
+
+// the only reader of a published track, the subscribers stay registered
+// when it ends so a track added back reaches them
+func (p *PubConn) readTrack(remote *webrtc.TrackRemote, out *Broadcaster) {
+	// a renegotiated codec keeps the track, only the payload type changes
+	mime := remote.Codec().MimeType
+
+	for {
+		pkt, _, err := remote.ReadRTP()
+		if err != nil {
+			p.Log.Info("published track ended", "kind", remote.Kind().String())
+			return
+		}
+
+		if remote.Kind() == webrtc.RTPCodecTypeVideo {
+			if m := remote.Codec().MimeType; m != mime {
+				p.Log.Info("video codec changed", "from", mime, "to", m)
+				mime = m
+				go p.videoCodecChanged()
+			}
+			p.Cache.Push(pkt)
+		}
+		out.Broadcast(pkt)
 	}
 }
 
 func (p *PubConn) PumpAudio(ctx context.Context, local *webrtc.TrackLocalStaticRTP) {
-	remote := p.GetLocalAV().Audio
+	q := p.audio.Register()
+	defer p.audio.Unregister(q)
+
 	for {
+		var pkt *rtp.Packet
+		var ok bool
+
 		select {
 		case <-ctx.Done():
 			p.Log.Info("stop pumping audio")
 			return
-		default:
+		case pkt, ok = <-q:
 		}
-		pkt, _, err := remote.ReadRTP()
 
-		if err != nil {
-			p.Log.Error("unable to read audio RTP packet")
+		if !ok {
+			p.Log.Info("audio track ended")
 			return
 		}
 
@@ -162,69 +346,304 @@ func (p *PubConn) PumpAudio(ctx context.Context, local *webrtc.TrackLocalStaticR
 			p.Log.Error("unable to send audio RTP packet")
 			return
 		}
+		metrics.ForwardedRTP("audio", pkt.MarshalSize())
 	}
 }
 
 // pump video to subcribers
-func (p *PubConn) PumpVideo(ctx context.Context, local *webrtc.TrackLocalStaticRTP, tx *webrtc.RTPTransceiver) {
-	remote := p.GetLocalAV().Video
-
-	go p.checkRTCP(ctx, tx, remote.SSRC())
+func (p *PubConn) PumpVideo(ctx context.Context, local *webrtc.TrackLocalStaticRTP, fwd domain.VideoForwarder) {
+	q := p.video.Register()
+	defer p.video.Unregister(q)
 
 	for {
+		var pkt *rtp.Packet
+		var ok bool
+
 		select {
 		case <-ctx.Done():
 			p.Log.Info("stop pumping video")
 			return
-		default:
+		case pkt, ok = <-q:
 		}
-		pkt, _, err := remote.ReadRTP()
 
-		if err != nil {
-			p.Log.Error("unable to read video RTP packet")
+		if !ok {
+			p.Log.Info("video track ended")
 			return
 		}
 
-		if err := local.WriteRTP(pkt); err != nil {
+		// layer dropped for the subscriber bandwidth
+		out := fwd.Forward(pkt)
+		if out == nil {
+			metrics.DroppedRTP("congestion")
+			continue
+		}
+
+		if err := local.WriteRTP(out); err != nil {
 			p.Log.Error("unable to send video RTP packet")
 			return
 		}
+		metrics.ForwardedRTP("video", out.MarshalSize())
 	}
 }
 
-func (p *PubConn) checkRTCP(ctx context.Context, tx *webrtc.RTPTransceiver, upSRRC webrtc.SSRC) {
+// feedback a subscriber sent about the video it gets from the publisher
+func (p *PubConn) HandleRTCP(pkts []rtcp.Packet, local *webrtc.TrackLocalStaticRTP, fwd domain.VideoForwarder) {
+	for _, pkt := range pkts {
+		if remb, ok := pkt.(*rtcp.ReceiverEstimatedMaximumBitrate); ok {
+			fwd.OnREMB(uint64(remb.Bitrate))
+		}
+
+		if nack, ok := pkt.(*rtcp.TransportLayerNack); ok {
+			p.handleNack(local, fwd, nack)
+		}
+
+		// merged with the other subscribers' requests
+		switch pkt.(type) {
+		case *rtcp.PictureLossIndication:
+			p.RequestKeyframe(domain.KeyframePLI)
+		case *rtcp.FullIntraRequest:
+			p.RequestKeyframe(domain.KeyframeFIR)
+		}
+	}
+}
 
-	var lastPLI time.Time
-	const minInt = 1000 * time.Millisecond
+// uplink quality of the published tracks
+func (p *PubConn) Quality() []*sfu.TrackQuality {
+	tracks := []*sfu.TrackQuality{}
+	av := p.Tracks()
+
+	for kind, remote := range map[string]*webrtc.TrackRemote{"audio": av.Audio, "video": av.Video} {
+		if remote == nil {
+			continue
+		}
+
+		q := p.Conn.Quality(uint32(remote.SSRC()), float64(remote.Codec().ClockRate), true)
+		q.Kind = kind
+		q.Pc = sfu.PcType_PUB
+		tracks = append(tracks, q)
+	}
+
+	return tracks
+}
+
+func (p *PubConn) State() webrtc.PeerConnectionState {
+	return p.Conn.GetPC().ConnectionState()
+}
+
+// ask the publisher for a keyframe, requests of all subscribers share
+// one rate limit
+func (p *PubConn) RequestKeyframe(kind domain.KeyframeKind) {
+	p.keyframes.Request(kind)
+}
+
+// record the bitrate a subscriber can take, 0 removes the subscriber
+func (p *PubConn) ReportBitrate(subscriberID string, bitrate uint64) {
+	p.BudgetMu.Lock()
+	defer p.BudgetMu.Unlock()
+
+	if bitrate == 0 {
+		delete(p.Budgets, subscriberID)
+		return
+	}
+
+	p.Budgets[subscriberID] = domain.BitrateReport{Bitrate: bitrate, At: time.Now()}
+}
+
+// send the best subscriber budget upstream so the encoder adapts,
+// weaker subscribers get temporal layers dropped instead
+func (p *PubConn) rembCycle() {
+	ticker := time.NewTicker(rembInterval)
+	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ctx.Done():
-			p.Log.Info("stop RTCP checking")
+		case <-p.Ctx.Done():
 			return
-		default:
+		case <-ticker.C:
 		}
 
-		pkts, _, err := tx.Sender().ReadRTCP()
+		remote := p.Tracks().Video
+		if remote == nil {
+			continue
+		}
 
-		if err != nil {
-			p.Log.Error("unable to read read RTCP")
-			return
+		var bitrate uint64
+		p.BudgetMu.Lock()
+		for id, b := range p.Budgets {
+			if time.Since(b.At) > rembTTL {
+				delete(p.Budgets, id)
+				continue
+			}
+			bitrate = max(bitrate, b.Bitrate)
 		}
+		p.BudgetMu.Unlock()
 
-		for _, pkt := range pkts {
-			if pli, ok := pkt.(*rtcp.PictureLossIndication); ok {
-				if time.Since(lastPLI) >= minInt {
-					lastPLI = time.Now()
-					pli.MediaSSRC = uint32(upSRRC)
-					if err := p.Conn.GetPC().WriteRTCP([]rtcp.Packet{pli}); err != nil {
-						p.Log.Error("Failed to write pli RTCP")
-					}
+		if bitrate == 0 {
+			continue
+		}
 
-					p.Log.Info("sent key frame")
-				}
+		remb := &rtcp.ReceiverEstimatedMaximumBitrate{
+			Bitrate: float32(max(bitrate, minREMB)),
+			SSRCs:   []uint32{uint32(remote.SSRC())},
+		}
+		if err := p.Conn.GetPC().WriteRTCP([]rtcp.Packet{remb}); err != nil {
+			p.Log.Error("unable to send REMB")
+		}
+	}
+}
+
+// resend lost packets from the cache, the rest are asked from the publisher
+func (p *PubConn) handleNack(local *webrtc.TrackLocalStaticRTP, fwd domain.VideoForwarder, nack *rtcp.TransportLayerNack) {
+	var missing []uint16
+
+	for _, pair := range nack.Nacks {
+		for _, outSeq := range pair.PacketList() {
+			in, ok := fwd.Resolve(outSeq)
+			if !ok {
+				continue
 			}
+
+			pkt := p.Cache.Get(in)
+			if pkt == nil {
+				missing = append(missing, in)
+				continue
+			}
+
+			if err := local.WriteRTP(fwd.Rewrite(pkt, outSeq)); err != nil {
+				p.Log.Error("unable to resend RTP packet")
+				return
+			}
+			metrics.Retransmitted("cache")
+		}
+	}
+
+	p.requestNack(missing)
+}
+
+// NACK the publisher, skipping packets another subscriber just asked for
+func (p *PubConn) requestNack(seqs []uint16) {
+	remote := p.Tracks().Video
+	if len(seqs) == 0 || remote == nil {
+		return
+	}
+
+	now := time.Now()
+	fresh := make([]uint16, 0, len(seqs))
+
+	p.nackMu.Lock()
+	for seq, at := range p.nackSent {
+		if now.Sub(at) > nackInterval {
+			delete(p.nackSent, seq)
+		}
+	}
+	for _, seq := range seqs {
+		if _, ok := p.nackSent[seq]; ok {
+			continue
+		}
+		p.nackSent[seq] = now
+		fresh = append(fresh, seq)
+	}
+	p.nackMu.Unlock()
+
+	if len(fresh) == 0 {
+		return
+	}
+
+	nack := &rtcp.TransportLayerNack{
+		MediaSSRC: uint32(remote.SSRC()),
+		Nacks:     rtcp.NackPairsFromSequenceNumbers(fresh),
+	}
+	if err := p.Conn.GetPC().WriteRTCP([]rtcp.Packet{nack}); err != nil {
+		p.Log.Error("unable to send NACK upstream")
+		return
+	}
+	metrics.Retransmitted("upstream")
+}
+
+// video codecs the publisher may send, in order of preference
+func (p *PubConn) SetCodecs(policy []string) {
+	p.codecMu.Lock()
+	defer p.codecMu.Unlock()
+	p.Codecs = policy
+}
+
+// a subscriber cannot decode the current codec, drop it from the policy
+// and ask the client to offer again
+func (p *PubConn) RejectCodec(decodable map[string]bool) {
+	remote := p.Tracks().Video
+	if remote == nil {
+		return
+	}
+	current := codecName(remote.Codec().MimeType)
+
+	allowed := []string{}
+	for _, name := range p.codecPolicy() {
+		if name != current && decodable[name] {
+			allowed = append(allowed, name)
 		}
+	}
+
+	if len(allowed) == 0 {
+		p.Log.Warn("no video codec left that every subscriber decodes", "codec", current)
+		return
+	}
 
+	p.SetCodecs(allowed)
+	p.sendCodecRejected(allowed)
+	p.Log.Info("video codec rejected", "codec", current, "allowed", allowed)
+}
+
+// f runs every time the published video switches codec
+func (p *PubConn) OnVideoCodec(f func()) {
+	p.codecMu.Lock()
+	defer p.codecMu.Unlock()
+	p.onCodec = f
+}
+
+func (p *PubConn) videoCodecChanged() {
+	p.codecMu.Lock()
+	f := p.onCodec
+	p.codecMu.Unlock()
+
+	if f != nil {
+		f()
+	}
+}
+
+func (p *PubConn) codecPolicy() []string {
+	p.codecMu.Lock()
+	defer p.codecMu.Unlock()
+
+	if len(p.Codecs) == 0 {
+		return DefaultCodecPolicy
+	}
+	return p.Codecs
+}
+
+func (p *PubConn) sendCodecRejected(allowed []string) {
+	p.Conn.EnqueueSend(&sfu.PeerSignal{
+		Payload: &sfu.PeerSignal_Event{
+			Event: &sfu.Event{
+				Type:   sfu.EventType_CODEC_REJECTED,
+				Codecs: allowed,
+			},
+		},
+	})
+}
+
+// whether an offer carries a video codec of the policy, audio only
+// offers always pass
+func offersCodec(raw string, policy []string) bool {
+	offered := sdpVideoCodecs(raw)
+	if len(offered) == 0 {
+		return true
 	}
+
+	for _, name := range policy {
+		if offered[strings.ToLower(name)] {
+			return true
+		}
+	}
+
+	return false
 }
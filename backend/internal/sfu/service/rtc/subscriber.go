@@ -3,22 +3,28 @@ package rtc
 import (
 	"context"
 	"log/slog"
-	"time"
 	sfu "vidcall/api/proto"
 	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/metrics"
+	"vidcall/pkg/config"
+	"vidcall/pkg/tracing"
 
 	"github.com/pion/webrtc/v3"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type SubConn struct {
 	*domain.SubConn
 }
 
-func NewSubscriber(ctx context.Context, sendQ chan *sfu.PeerSignal, log *slog.Logger, poolSize int, debounceInterval time.Duration) (domain.Subscriber, error) {
+func NewSubscriber(ctx context.Context, peerID string, sendQ chan *sfu.PeerSignal, log *slog.Logger, poolSize int, mixAudio bool, c *config.SFU) (domain.Subscriber, error) {
 
-	conn, err := NewPConn(sendQ, log, debounceInterval, false)
+	subCtx, subCancel := context.WithCancel(ctx)
+
+	conn, err := NewPConn(subCtx, sendQ, log, c, false, true)
 
 	if err != nil {
+		subCancel()
 		return nil, err
 	}
 
@@ -38,34 +44,58 @@ func NewSubscriber(ctx context.Context, sendQ chan *sfu.PeerSignal, log *slog.Lo
 		vtx, err := conn.GetPC().AddTransceiverFromKind(webrtc.RTPCodecTypeVideo, direction)
 		if err != nil {
 			log.Error("unable to add new video transceiver")
+			subCancel()
 			return nil, err
 		}
 
+		new_slot := &domain.Slot{
+			VideoTx: vtx,
+		}
+		v.Slots[i] = new_slot
+
+		// mixed audio comes on one transceiver of its own
+		if mixAudio {
+			continue
+		}
+
 		atx, err := conn.GetPC().AddTransceiverFromKind(webrtc.RTPCodecTypeAudio, direction)
 		if err != nil {
 			log.Error("unable to add new audio transceiver")
+			subCancel()
 			return nil, err
 		}
+		new_slot.AudioTx = atx
+	}
 
-		new_slot := &domain.Slot{
-			VideoTx: vtx,
-			AudioTx: atx,
+	var mix domain.AudioMixer
+	if mixAudio {
+		m, err := NewAudioMix(subCtx, peerID, log)
+		if err != nil {
+			log.Error("unable to create audio mix")
+			subCancel()
+			return nil, err
 		}
-		v.Slots[i] = new_slot
-	}
 
-	subCtx, subCancel := context.WithCancel(ctx)
+		if _, err := conn.GetPC().AddTransceiverFromTrack(m.Track(), direction); err != nil {
+			log.Error("unable to add mixed audio transceiver")
+			subCancel()
+			return nil, err
+		}
+		mix = m
+	}
 
 	return &SubConn{
 		SubConn: &domain.SubConn{
+			PeerID: peerID,
 			Log:    log,
 			Conn:   conn,
 			Ctx:    subCtx,
 			Cancel: subCancel,
 			Videos: v,
+			Mix:    mix,
 
-			RecvSdp: make(chan *sfu.PeerSignal_Sdp, 64),
-			RecvIce: make(chan *sfu.PeerSignal_Ice, 64),
+			RecvSdp: make(chan *sfu.PeerSignal_Sdp, c.Peer.Queue),
+			RecvIce: make(chan *sfu.PeerSignal_Ice, c.Peer.Queue),
 		},
 	}, nil
 }
@@ -82,9 +112,23 @@ func (s *SubConn) WireCallBacks() {
 
 // start ice/sdp exchange for pc
 func (s *SubConn) Connect() error {
+	go s.congestionCycle()
+	for slotID := range s.Videos.Slots {
+		go s.readRTCP(slotID)
+	}
+	if s.Mix != nil {
+		go s.Mix.Run()
+	}
+
+	s.Mu.RLock()
+	answered := s.Answered
+	s.Mu.RUnlock()
+
 	// Send an offer to client
-	if err := s.Conn.SendOffer(sfu.PcType_SUB); err != nil {
-		return err
+	if !answered {
+		if err := s.Conn.SendOffer(sfu.PcType_SUB); err != nil {
+			return err
+		}
 	}
 
 	for {
@@ -101,6 +145,10 @@ func (s *SubConn) Connect() error {
 				if err := s.Conn.HandleAnswer(sdp); err != nil {
 					return err
 				}
+
+				s.Mu.Lock()
+				s.Decodes = sdpVideoCodecs(sdp.Sdp.Sdp)
+				s.Mu.Unlock()
 			}
 
 		case ice, ok := <-s.RecvIce:
@@ -115,6 +163,33 @@ func (s *SubConn) Connect() error {
 	}
 }
 
+// answer a client offer instead of offering, for viewers that bring their
+// own receive transceivers (WHEP). Every ICE candidate is in the answer
+func (s *SubConn) Answer(ctx context.Context, offer string) (string, error) {
+	pc := s.Conn.GetPC()
+	gathered := webrtc.GatheringCompletePromise(pc)
+
+	err := s.Conn.HandleOffer(&sfu.PeerSignal_Sdp{
+		Sdp: &sfu.Sdp{Pc: sfu.PcType_SUB, Type: sfu.SdpType_OFFER, Sdp: offer},
+	})
+	if err != nil {
+		return "", domain.ErrBadOffer
+	}
+
+	s.Mu.Lock()
+	s.Decodes = sdpVideoCodecs(offer)
+	s.Answered = true
+	s.Mu.Unlock()
+
+	select {
+	case <-gathered:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	return pc.LocalDescription().SDP, nil
+}
+
 // tear down goroutines and pc
 func (s *SubConn) Disconnect() error {
 	if err := s.Conn.Close(); err != nil {
@@ -135,6 +210,7 @@ func (s *SubConn) EnqueueSdp(sdp *sfu.PeerSignal_Sdp) {
 	select {
 	case s.RecvSdp <- sdp:
 	default:
+		metrics.DroppedSignal("sdp")
 	}
 }
 
@@ -142,6 +218,7 @@ func (s *SubConn) EnqueueIce(ice *sfu.PeerSignal_Ice) {
 	select {
 	case s.RecvIce <- ice:
 	default:
+		metrics.DroppedSignal("ice")
 	}
 }
 
@@ -164,7 +241,9 @@ func (s *SubConn) SubscribeRoom(subscriberID string, room domain.Room) error {
 }
 
 // subscribe to remote peer video track
-func (s *SubConn) Subscribe(peer domain.Peer) error {
+func (s *SubConn) Subscribe(peer domain.Peer) (err error) {
+	_, span := tracing.Start(s.Ctx, "sub.subscribe", attribute.String("publisher.id", peer.GetMetaData().PeerID))
+	defer func() { tracing.End(span, err) }()
 
 	s.Mu.Lock()
 	defer s.Mu.Unlock()
@@ -176,26 +255,41 @@ func (s *SubConn) Subscribe(peer domain.Peer) error {
 		return nil
 	}
 
-	vlocal, err := webrtc.NewTrackLocalStaticRTP(
-		peer.Pub().GetLocalAV().Video.Codec().RTPCodecCapability,
-		"loop"+peerID,
-		"pion",
-	)
+	av := peer.Pub().GetLocalAV()
 
-	if err != nil {
-		s.Log.Error("unable to create local track")
-		return err
+	// audio only publishers have no video track
+	var vlocal *webrtc.TrackLocalStaticRTP
+	if av.Video != nil {
+		vlocal, err = webrtc.NewTrackLocalStaticRTP(
+			av.Video.Codec().RTPCodecCapability,
+			"loop"+peerID,
+			"pion",
+		)
+
+		if err != nil {
+			s.Log.Error("unable to create local track")
+			return err
+		}
 	}
 
-	alocal, err := webrtc.NewTrackLocalStaticRTP(
-		peer.Pub().GetLocalAV().Audio.Codec().RTPCodecCapability,
-		"loop"+peerID,
-		"pion",
-	)
+	// mixed audio takes every peer, with or without a free slot. Video only
+	// publishers, like some WHIP encoders, have no audio track
+	var alocal *webrtc.TrackLocalStaticRTP
+	switch {
+	case av.Audio == nil:
+	case s.Mix != nil:
+		s.Mix.Add(peerID, peer.Pub(), av.Audio.Codec().MimeType)
+	default:
+		alocal, err = webrtc.NewTrackLocalStaticRTP(
+			av.Audio.Codec().RTPCodecCapability,
+			"loop"+peerID,
+			"pion",
+		)
 
-	if err != nil {
-		s.Log.Error("unable to create local track")
-		return err
+		if err != nil {
+			s.Log.Error("unable to create local track")
+			return err
+		}
 	}
 
 	v.IDOrder = append(v.IDOrder, peerID)
@@ -211,14 +305,20 @@ func (s *SubConn) Subscribe(peer domain.Peer) error {
 				v.OwnerToSlot[peerID] = i
 
 				slot := v.Slots[i]
-				slot.VideoTx.Sender().ReplaceTrack(vlocal)
-				slot.AudioTx.Sender().ReplaceTrack(alocal)
 
 				pumpCtx, pumpCancel := context.WithCancel(s.Ctx)
 				slot.PumpCtx = pumpCtx
 				slot.PumpCancel = pumpCancel
-				go peer.Pub().PumpAudio(pumpCtx, alocal)
-				go peer.Pub().PumpVideo(pumpCtx, vlocal, slot.VideoTx)
+				slot.Pub = peer.Pub()
+
+				if alocal != nil {
+					slot.AudioTx.Sender().ReplaceTrack(alocal)
+					go peer.Pub().PumpAudio(pumpCtx, alocal)
+				}
+
+				if vlocal != nil {
+					s.bindVideo(slot, peer, vlocal)
+				}
 				break
 
 			}
@@ -228,13 +328,67 @@ func (s *SubConn) Subscribe(peer domain.Peer) error {
 	return nil
 }
 
+// rebuild the video track of a publisher that switched codec, the old one
+// was built for the previous codec and cannot carry the new payload
+func (s *SubConn) Rebind(peer domain.Peer) (err error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	v := s.Videos
+	peerID := peer.GetMetaData().PeerID
+
+	if _, ok := v.IDToVideoTracks[peerID]; !ok {
+		return nil
+	}
+
+	remote := peer.Pub().Tracks().Video
+	if remote == nil {
+		return nil
+	}
+
+	vlocal, err := webrtc.NewTrackLocalStaticRTP(
+		remote.Codec().RTPCodecCapability,
+		"loop"+peerID,
+		"pion",
+	)
+
+	if err != nil {
+		s.Log.Error("unable to create local track")
+		return err
+	}
+	v.IDToVideoTracks[peerID] = vlocal
+
+	slotID, ok := v.OwnerToSlot[peerID]
+	if !ok {
+		return nil
+	}
+
+	slot := v.Slots[slotID]
+	s.stopVideo(slot)
+	slot.Fwd = nil
+
+	if err := slot.VideoTx.Sender().ReplaceTrack(nil); err != nil {
+		s.Log.Error("unable to detach video track")
+		return err
+	}
+
+	s.bindVideo(slot, peer, vlocal)
+	return nil
+}
+
 // Unsubcribe to remote peers track
-func (s *SubConn) Unsubscribe(peerID string) error {
+func (s *SubConn) Unsubscribe(peerID string) (err error) {
+	_, span := tracing.Start(s.Ctx, "sub.unsubscribe", attribute.String("publisher.id", peerID))
+	defer func() { tracing.End(span, err) }()
 	s.Mu.Lock()
 	defer s.Mu.Unlock()
 
 	v := s.Videos
 
+	if s.Mix != nil {
+		s.Mix.Remove(peerID)
+	}
+
 	slotID, ok := v.OwnerToSlot[peerID]
 	if ok {
 		slot := v.Slots[slotID]
@@ -243,17 +397,25 @@ func (s *SubConn) Unsubscribe(peerID string) error {
 			return err
 		}
 
-		if err := slot.AudioTx.Sender().ReplaceTrack(nil); err != nil {
-			s.Log.Error("unable to detach audio track")
-			return err
+		if slot.AudioTx != nil {
+			if err := slot.AudioTx.Sender().ReplaceTrack(nil); err != nil {
+				s.Log.Error("unable to detach audio track")
+				return err
+			}
 		}
 
 		slot.PumpCancel()
+		slot.Pub.ReportBitrate(s.PeerID, 0)
 
 		delete(v.OwnerToSlot, peerID)
 		delete(v.SlotToOwner, slotID)
 		v.Slots[slotID].PumpCtx = nil
 		v.Slots[slotID].PumpCancel = nil
+		v.Slots[slotID].Pub = nil
+		v.Slots[slotID].Fwd = nil
+		v.Slots[slotID].VideoCtx = nil
+		v.Slots[slotID].VideoCancel = nil
+		v.Slots[slotID].Paused = false
 	}
 
 	delete(v.IDToVideoTracks, peerID)
@@ -269,6 +431,213 @@ func (s *SubConn) Unsubscribe(peerID string) error {
 	return nil
 }
 
+// downlink quality of every forwarded track
+func (s *SubConn) Quality() []*sfu.TrackQuality {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	tracks := []*sfu.TrackQuality{}
+
+	for slotID, owner := range s.Videos.SlotToOwner {
+		slot := s.Videos.Slots[slotID]
+
+		for kind, tx := range map[string]*webrtc.RTPTransceiver{"audio": slot.AudioTx, "video": slot.VideoTx} {
+			if tx == nil {
+				continue
+			}
+
+			enc := tx.Sender().GetParameters().Encodings
+			if len(enc) == 0 {
+				continue
+			}
+
+			q := s.Conn.Quality(uint32(enc[0].SSRC), 0, false)
+			q.PeerID = owner
+			q.Kind = kind
+			q.Pc = sfu.PcType_SUB
+			tracks = append(tracks, q)
+		}
+	}
+
+	return tracks
+}
+
+func (s *SubConn) State() webrtc.PeerConnectionState {
+	return s.Conn.GetPC().ConnectionState()
+}
+
+// stop forwarding one remote peer's video
+func (s *SubConn) PauseVideo(peerID string) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	slot, err := s.videoSlot(peerID)
+	if err != nil {
+		return err
+	}
+
+	slot.Paused = true
+	s.stopVideo(slot)
+
+	return nil
+}
+
+func (s *SubConn) ResumeVideo(peerID string) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	slot, err := s.videoSlot(peerID)
+	if err != nil {
+		return err
+	}
+
+	slot.Paused = false
+	if !s.AudioOnly {
+		s.startVideo(slot, s.Videos.IDToVideoTracks[peerID])
+	}
+
+	return nil
+}
+
+// highest layer forwarded from a remote peer, the congestion policy may
+// still go lower
+func (s *SubConn) SetMaxLayer(peerID string, layer domain.Layer) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	slot, err := s.videoSlot(peerID)
+	if err != nil {
+		return err
+	}
+
+	slot.Fwd.SetMaxLayer(layer)
+
+	return nil
+}
+
+// stop or restart every video, individually paused ones stay paused
+func (s *SubConn) SetAudioOnly(on bool) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if s.AudioOnly == on {
+		return
+	}
+	s.AudioOnly = on
+
+	for slotID, owner := range s.Videos.SlotToOwner {
+		slot := s.Videos.Slots[slotID]
+		if slot.Fwd == nil {
+			continue
+		}
+
+		if on {
+			s.stopVideo(slot)
+		} else if !slot.Paused {
+			s.startVideo(slot, s.Videos.IDToVideoTracks[owner])
+		}
+	}
+}
+
+func (s *SubConn) videoSlot(peerID string) (*domain.Slot, error) {
+	slotID, ok := s.Videos.OwnerToSlot[peerID]
+	if !ok || s.Videos.Slots[slotID].Fwd == nil {
+		return nil, domain.ErrNotSubscribed
+	}
+
+	return s.Videos.Slots[slotID], nil
+}
+
+// helper function to forward a publisher's video into a slot, the slot
+// stays dark while the client cannot decode the codec
+func (s *SubConn) bindVideo(slot *domain.Slot, peer domain.Peer, vlocal *webrtc.TrackLocalStaticRTP) {
+	peerID := peer.GetMetaData().PeerID
+	pub := peer.Pub()
+
+	// the client would get no picture, let the publisher switch codec
+	if !s.decodes(vlocal.Codec().MimeType) {
+		s.Log.Warn("subscriber cannot decode publisher video", "publisher", peerID, "codec", vlocal.Codec().MimeType)
+		go pub.RejectCodec(s.Decodes)
+		return
+	}
+
+	slot.VideoTx.Sender().ReplaceTrack(vlocal)
+
+	// encrypted payloads are forwarded untouched, no VP8
+	// descriptor is read or rewritten
+	mime := vlocal.Codec().MimeType
+	if peer.GetMetaData().E2EE {
+		mime = ""
+	}
+	slot.Fwd = NewForwarder(mime, func() { pub.RequestKeyframe(domain.KeyframePLI) })
+	if !s.AudioOnly && !slot.Paused {
+		s.startVideo(slot, vlocal)
+	}
+}
+
+// pump a slot's video on its own context so it can stop without audio
+func (s *SubConn) startVideo(slot *domain.Slot, local *webrtc.TrackLocalStaticRTP) {
+	if slot.VideoCancel != nil {
+		return
+	}
+
+	videoCtx, videoCancel := context.WithCancel(slot.PumpCtx)
+	slot.VideoCtx = videoCtx
+	slot.VideoCancel = videoCancel
+
+	// a new or rebound slot, or a restarted stream, can only be decoded
+	// from a keyframe
+	slot.Fwd.Resync()
+	slot.Pub.RequestKeyframe(domain.KeyframePLI)
+	go slot.Pub.PumpVideo(videoCtx, local, slot.Fwd)
+}
+
+// the only reader of a slot's RTCP, it lives as long as the sender. The
+// feedback goes to the publisher the slot forwards, and is dropped while
+// its video is stopped
+func (s *SubConn) readRTCP(slotID int) {
+	slot := s.Videos.Slots[slotID]
+
+	for {
+		pkts, _, err := slot.VideoTx.Sender().ReadRTCP()
+		if err != nil {
+			s.Log.Info("stop RTCP reading", "slot", slotID)
+			return
+		}
+
+		s.Mu.RLock()
+		pub, fwd := slot.Pub, slot.Fwd
+		local := s.Videos.IDToVideoTracks[s.Videos.SlotToOwner[slotID]]
+		live := slot.VideoCancel != nil
+		s.Mu.RUnlock()
+
+		if !live || pub == nil || fwd == nil || local == nil {
+			continue
+		}
+
+		pub.HandleRTCP(pkts, local, fwd)
+	}
+}
+
+func (s *SubConn) stopVideo(slot *domain.Slot) {
+	if slot.VideoCancel == nil {
+		return
+	}
+
+	slot.VideoCancel()
+	slot.VideoCtx = nil
+	slot.VideoCancel = nil
+}
+
+// whether the client can decode a codec, unknown before its first answer
+func (s *SubConn) decodes(mime string) bool {
+	if len(s.Decodes) == 0 {
+		return true
+	}
+
+	return s.Decodes[codecName(mime)]
+}
+
 func (s *SubConn) SwitchNext() error {
 	return nil
 }
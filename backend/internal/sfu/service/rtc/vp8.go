@@ -0,0 +1,91 @@
+package rtc
+
+// VP8 payload descriptor (RFC 7741 section 4.2)
+type vp8Desc struct {
+	picID    uint16
+	picIDOff int // offset of the picture ID, 0 when absent
+	picIDLen int // 1 for 7 bit, 2 for 15 bit picture IDs
+	tid      uint8
+	sync     bool // layer sync, depends on the base layer only
+	keyframe bool
+}
+
+func parseVP8(b []byte) (vp8Desc, bool) {
+	var d vp8Desc
+	if len(b) < 1 {
+		return d, false
+	}
+
+	x := b[0]&0x80 != 0
+	start := b[0]&0x10 != 0
+	pid := b[0] & 0x07
+
+	i := 1
+	if x {
+		if len(b) < 2 {
+			return d, false
+		}
+		ext := b[1]
+		i = 2
+
+		// picture ID
+		if ext&0x80 != 0 {
+			if len(b) < i+1 {
+				return d, false
+			}
+			d.picIDOff = i
+			if b[i]&0x80 != 0 {
+				if len(b) < i+2 {
+					return d, false
+				}
+				d.picID = uint16(b[i]&0x7f)<<8 | uint16(b[i+1])
+				d.picIDLen = 2
+				i += 2
+			} else {
+				d.picID = uint16(b[i])
+				d.picIDLen = 1
+				i++
+			}
+		}
+
+		// TL0PICIDX
+		if ext&0x40 != 0 {
+			i++
+		}
+
+		// TID/Y/KEYIDX
+		if ext&0x20 != 0 || ext&0x10 != 0 {
+			if len(b) < i+1 {
+				return d, false
+			}
+			if ext&0x20 != 0 {
+				d.tid = b[i] >> 6
+				d.sync = b[i]&0x20 != 0
+			}
+			i++
+		}
+	}
+
+	// inverse key frame flag of the VP8 payload header
+	if start && pid == 0 && len(b) > i {
+		d.keyframe = b[i]&0x01 == 0
+	}
+
+	return d, true
+}
+
+// write a picture ID back into a copy of the payload
+func (d vp8Desc) withPicID(b []byte, picID uint16) []byte {
+	out := make([]byte, len(b))
+	copy(out, b)
+
+	switch d.picIDLen {
+	case 1:
+		out[d.picIDOff] = byte(picID & 0x7f)
+	case 2:
+		out[d.picIDOff] = 0x80 | byte(picID>>8&0x7f)
+		out[d.picIDOff+1] = byte(picID)
+	}
+
+	return out
+}
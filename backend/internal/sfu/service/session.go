@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"log/slog"
+	"sync"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+	"vidcall/internal/sfu/service/rtc"
+	"vidcall/pkg/config"
+	"vidcall/pkg/utils"
+
+	"google.golang.org/grpc"
+)
+
+// signaling of a peer driven over HTTP (WHIP, WHEP) instead of a client stream,
+// the one offer is answered in the request and nothing is sent back
+type localStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newLocalStream() *localStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &localStream{ctx: ctx, cancel: cancel}
+}
+
+func (s *localStream) Context() context.Context {
+	return s.ctx
+}
+
+// nothing to receive, the stream ends with the session
+func (s *localStream) Recv() (*sfu.PeerSignal, error) {
+	<-s.ctx.Done()
+	return nil, io.EOF
+}
+
+// trickle ICE and events have no one to go to
+func (s *localStream) Send(_ *sfu.PeerSignal) error {
+	return nil
+}
+
+type session struct {
+	roomID string
+	// peer ID of the ingest token that started it
+	owner string
+	// handed to the viewer alone, the view token is shared
+	secret string
+	stream *localStream
+	done   chan struct{}
+}
+
+// receive slots of a viewer, a grid of participants at most
+const maxViewerSlots = 9
+
+var (
+	sessionMu sync.Mutex
+	sessions  = make(map[string]*session)
+)
+
+// StartIngest joins a publisher-only peer to a live room and answers its
+// offer. An encoder reconnecting with the same token replaces its session
+func StartIngest(ctx context.Context, md *domain.PeerMD, offer string, c *config.SFU, log *slog.Logger) (string, error) {
+	if md.Scope != domain.ScopeIngest {
+		return "", domain.ErrNotAllowed
+	}
+
+	r := hub.Hub().GetRoom(md.RoomID)
+	if r == nil || !r.IsLive() {
+		return "", domain.ErrRoomNotLive
+	}
+
+	// encoders cannot take part in the key exchange
+	if r.E2EE() {
+		return "", domain.ErrE2EE
+	}
+
+	_ = endSession(md.PeerID)
+
+	stream := newLocalStream()
+	peer, err := NewPeer(stream.ctx, stream, md, 1, c, log)
+	if err != nil {
+		stream.cancel()
+		return "", err
+	}
+
+	p := peer.(*PeerObj)
+	p.pubOnly = true
+	// a room ending cancels the peer, the stream has to follow
+	context.AfterFunc(p.Ctx, stream.cancel)
+	p.Publisher.SetCodecs(r.CodecPolicy())
+
+	answer, err := p.Publisher.Answer(ctx, offer)
+	if err != nil {
+		stream.cancel()
+		_ = p.Disconnect()
+		return "", err
+	}
+
+	s := &session{roomID: md.RoomID, owner: md.PeerID, stream: stream, done: make(chan struct{})}
+
+	sessionMu.Lock()
+	sessions[md.PeerID] = s
+	sessionMu.Unlock()
+
+	// in the room before the session can end and leave it
+	if err := r.AddPeer(md.PeerID, p); err != nil {
+		sessionMu.Lock()
+		if sessions[md.PeerID] == s {
+			delete(sessions, md.PeerID)
+		}
+		sessionMu.Unlock()
+
+		stream.cancel()
+		_ = p.Disconnect()
+		return "", domain.ErrRoomNotLive
+	}
+	r.Announce(p.createEvent(md.RoomID, sfu.EventType_JOIN_EVENT), nil)
+	log.Info("ingest joined room")
+
+	go func() {
+		defer close(s.done)
+
+		if err := p.Connect(); err != nil && err != io.EOF {
+			log.Warn("ingest session ended", "err", err)
+		}
+		p.Leave()
+		_ = p.Disconnect()
+
+		sessionMu.Lock()
+		if sessions[md.PeerID] == s {
+			delete(sessions, md.PeerID)
+		}
+		sessionMu.Unlock()
+	}()
+
+	return answer, nil
+}
+
+// StartView adds a viewer of a live room, receiving every participant or
+// only target, and answers its offer. The viewer is not a participant: it
+// is not announced and not in the room state. Every view gets its own
+// session and secret so a view token can be shared by many viewers
+func StartView(ctx context.Context, md *domain.PeerMD, target string, offer string, c *config.SFU, log *slog.Logger) (string, string, string, error) {
+	if md.Scope != domain.ScopeView {
+		return "", "", "", domain.ErrNotAllowed
+	}
+
+	r := hub.Hub().GetRoom(md.RoomID)
+	if r == nil || !r.IsLive() {
+		return "", "", "", domain.ErrRoomNotLive
+	}
+
+	// viewers cannot take part in the key exchange
+	if r.E2EE() {
+		return "", "", "", domain.ErrE2EE
+	}
+
+	slots := min(rtc.ReceiveSlots(offer), maxViewerSlots)
+	if slots == 0 {
+		return "", "", "", domain.ErrBadOffer
+	}
+
+	sessionID := "view-" + utils.GenerateMemeberID()
+	vmd := &domain.PeerMD{
+		Name:   md.Name,
+		PeerID: sessionID,
+		RoomID: md.RoomID,
+		Role:   sfu.RoleType_ROLE_VIEWER,
+		Codecs: md.Codecs,
+	}
+	log = log.With("session ID", sessionID)
+
+	stream := newLocalStream()
+	peer, err := NewPeer(stream.ctx, stream, vmd, slots, c, log)
+	if err != nil {
+		stream.cancel()
+		return "", "", "", err
+	}
+
+	p := peer.(*PeerObj)
+	p.viewOnly = true
+	p.target = target
+	context.AfterFunc(p.Ctx, stream.cancel)
+
+	answer, err := p.Subscriber.Answer(ctx, offer)
+	if err != nil {
+		stream.cancel()
+		_ = p.Disconnect()
+		return "", "", "", err
+	}
+
+	s := &session{roomID: md.RoomID, secret: utils.GenerateTokenID(), stream: stream, done: make(chan struct{})}
+
+	sessionMu.Lock()
+	sessions[sessionID] = s
+	sessionMu.Unlock()
+
+	// joins from now on come as events, the current participants are
+	// subscribed here
+	r.AddViewer(sessionID, p)
+	go p.subscribeView(r)
+
+	go func() {
+		defer close(s.done)
+
+		if err := p.Connect(); err != nil && err != io.EOF {
+			log.Warn("view session ended", "err", err)
+		}
+		r.RemoveViewer(sessionID)
+		_ = p.Disconnect()
+
+		sessionMu.Lock()
+		delete(sessions, sessionID)
+		sessionMu.Unlock()
+	}()
+
+	log.Info("viewer added", "target", target, "slots", slots)
+	return answer, sessionID, s.secret, nil
+}
+
+// subscribe a viewer to the participants already in the room
+func (p *PeerObj) subscribeView(r domain.Room) {
+	for id, peer := range r.ListPeers() {
+		if !p.subscribesTo(id) {
+			continue
+		}
+
+		if err := p.Subscriber.Subscribe(peer); err != nil {
+			p.Log.Warn("unable to subscribe viewer", "publisher", id, "err", err)
+		}
+	}
+}
+
+// EndSession ends an HTTP driven session of the caller's room. An encoder
+// ends its own with its ingest token, a viewer with the secret of its
+// session, and a host any of them
+func EndSession(md *domain.PeerMD, sessionID string, secret string) error {
+	sessionMu.Lock()
+	s, ok := sessions[sessionID]
+	sessionMu.Unlock()
+
+	if !ok || s.roomID != md.RoomID {
+		return domain.ErrNoSession
+	}
+
+	if !s.endableBy(md, secret) {
+		return domain.ErrNotAllowed
+	}
+
+	return endSession(sessionID)
+}
+
+func (s *session) endableBy(md *domain.PeerMD, secret string) bool {
+	switch {
+	case md.Role == sfu.RoleType_ROLE_HOST && md.Scope == "":
+		return true
+	case s.secret != "":
+		return subtle.ConstantTimeCompare([]byte(secret), []byte(s.secret)) == 1
+	default:
+		return md.Scope == domain.ScopeIngest && md.PeerID == s.owner
+	}
+}
+
+// helper function to stop a session and wait for its peer to leave
+func endSession(sessionID string) error {
+	sessionMu.Lock()
+	s, ok := sessions[sessionID]
+	sessionMu.Unlock()
+
+	if !ok {
+		return domain.ErrNoSession
+	}
+
+	s.stream.cancel()
+	<-s.done
+
+	return nil
+}
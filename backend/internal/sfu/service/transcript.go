@@ -0,0 +1,22 @@
+package service
+
+import (
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/service/hub"
+)
+
+// live captions of the peer's main room, for hosts only
+func (p *PeerObj) hostTranscriber() (domain.Transcriber, error) {
+	md := p.Metadata
+	if md.Role != sfu.RoleType_ROLE_HOST {
+		return nil, domain.ErrNotAllowed
+	}
+
+	main := hub.Hub().GetRoom(md.RoomID)
+	if main == nil || !main.IsLive() {
+		return nil, domain.ErrRoomNotLive
+	}
+
+	return main.Transcriber(), nil
+}
@@ -0,0 +1,279 @@
+package transcript
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/infra"
+	"vidcall/internal/sfu/metrics"
+	"vidcall/internal/sfu/repo"
+	"vidcall/pkg/audio"
+	"vidcall/pkg/stt"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	// publishers are checked this often, new speakers get a stream
+	membershipInterval = 2 * time.Second
+	// decoded frames a speaker may get ahead of the engine, 5s of Opus
+	pcmQueue = 256
+)
+
+type TranscriberObj struct {
+	*domain.TranscriberObj
+	log *slog.Logger
+}
+
+var (
+	once   sync.Once
+	engine stt.SpeechToText
+)
+
+// speech to text engine of every room, captions cannot be started
+// without one
+func Init(e stt.SpeechToText) {
+	once.Do(func() {
+		engine = e
+	})
+}
+
+func NewTranscriber(roomID string, r domain.Room) domain.Transcriber {
+	return &TranscriberObj{
+		TranscriberObj: &domain.TranscriberObj{
+			Room:   r,
+			RoomID: roomID,
+		},
+		log: slog.Default().With("layer", "transcript", "room ID", roomID),
+	}
+}
+
+func (t *TranscriberObj) Start() error {
+	if engine == nil {
+		return domain.ErrNoSpeechToText
+	}
+
+	// browsers publish Opus, no caption would ever come
+	if !audio.CanDecode(audio.MimeOpus) {
+		return domain.ErrNoOpus
+	}
+
+	t.Mu.Lock()
+	defer t.Mu.Unlock()
+
+	if t.Running {
+		return domain.ErrTranscribing
+	}
+
+	if !t.Room.IsLive() {
+		return domain.ErrRoomNotLive
+	}
+
+	if t.Room.E2EE() {
+		return domain.ErrE2EE
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.Running = true
+	t.Started = time.Now()
+	t.Cancel = cancel
+	t.Done = make(chan struct{})
+
+	go t.run(ctx, t.Done)
+
+	t.Room.BroadCast("", t.event(sfu.EventType_TRANSCRIPTION_STARTED))
+	t.log.Info("transcription started")
+
+	return nil
+}
+
+// stop every speaker stream, captions of the last utterances still go out
+func (t *TranscriberObj) Stop() error {
+	t.Mu.Lock()
+	// Cancel is cleared by a stop in progress
+	if !t.Running || t.Cancel == nil {
+		t.Mu.Unlock()
+		return domain.ErrNoTranscription
+	}
+
+	t.Cancel()
+	t.Cancel = nil
+	done := t.Done
+	t.Mu.Unlock()
+
+	<-done
+
+	t.Mu.Lock()
+	t.Running = false
+	t.Mu.Unlock()
+
+	t.Room.BroadCast("", t.event(sfu.EventType_TRANSCRIPTION_STOPPED))
+	t.log.Info("transcription stopped")
+
+	return nil
+}
+
+func (t *TranscriberObj) Active() bool {
+	t.Mu.Lock()
+	defer t.Mu.Unlock()
+	return t.Running
+}
+
+// follow the publishers of the room, one engine stream per speaker
+func (t *TranscriberObj) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	speakers := make(map[string]context.CancelFunc)
+	// codec of the speakers that cannot be decoded, tried again each tick
+	// and logged once
+	undecodable := make(map[string]string)
+	defer func() {
+		for _, cancel := range speakers {
+			cancel()
+		}
+	}()
+
+	ticker := time.NewTicker(membershipInterval)
+	defer ticker.Stop()
+
+	for {
+		peers := t.Room.ListPeers()
+
+		for id, cancel := range speakers {
+			if _, ok := peers[id]; !ok {
+				cancel()
+				delete(speakers, id)
+			}
+		}
+		for id := range undecodable {
+			if _, ok := peers[id]; !ok {
+				delete(undecodable, id)
+			}
+		}
+
+		for id, peer := range peers {
+			if _, ok := speakers[id]; ok {
+				continue
+			}
+
+			remote := peer.Pub().Tracks().Audio
+			if remote == nil {
+				continue
+			}
+
+			mime := remote.Codec().MimeType
+			dec, err := audio.NewDecoder(mime, stt.Rate)
+			if err != nil {
+				if undecodable[id] != mime {
+					t.log.Warn("unable to transcribe peer audio", "peer ID", id, "codec", mime, "err", err)
+					undecodable[id] = mime
+				}
+				continue
+			}
+			delete(undecodable, id)
+
+			speakerCtx, cancel := context.WithCancel(ctx)
+			speakers[id] = cancel
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				t.transcribe(speakerCtx, peer, dec)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// feed a speaker's decoded audio to the engine and publish what comes out
+func (t *TranscriberObj) transcribe(ctx context.Context, peer domain.Peer, dec audio.Decoder) {
+	md := peer.GetMetaData()
+
+	q, stop := peer.Pub().Tap(webrtc.RTPCodecTypeAudio)
+	defer stop()
+
+	pcm := make(chan []int16, pcmQueue)
+	began := time.Now()
+	segments := engine.Transcribe(ctx, pcm)
+
+	go func() {
+		defer close(pcm)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case pkt, ok := <-q:
+				if !ok {
+					return
+				}
+
+				frame, err := dec.Decode(pkt.Payload)
+				if err != nil {
+					metrics.DroppedRTP("undecodable")
+					continue
+				}
+
+				select {
+				case pcm <- frame:
+				default:
+					metrics.DroppedRTP("transcription")
+				}
+			}
+		}
+	}()
+
+	for seg := range segments {
+		t.publish(md, began, seg)
+	}
+}
+
+// broadcast a caption and add it to the stored transcript
+func (t *TranscriberObj) publish(md *domain.PeerMD, began time.Time, seg stt.Segment) {
+	c := &domain.Caption{
+		RoomID: t.RoomID,
+		PeerID: md.PeerID,
+		Name:   md.Name,
+		Start:  began.Add(seg.Start),
+		End:    began.Add(seg.End),
+		Text:   seg.Text,
+	}
+
+	t.Room.BroadCast("", &sfu.PeerSignal_Event{
+		Event: &sfu.Event{
+			Type:   sfu.EventType_CAPTION,
+			Name:   md.Name,
+			PeerID: md.PeerID,
+			Caption: &sfu.Caption{
+				PeerID:  c.PeerID,
+				Name:    c.Name,
+				StartAt: c.Start.UnixMilli(),
+				EndAt:   c.End.UnixMilli(),
+				Text:    c.Text,
+			},
+		},
+	})
+
+	if db := infra.DB(); db != nil {
+		_ = repo.SaveCaption(context.Background(), db, c)
+	}
+}
+
+func (t *TranscriberObj) event(e sfu.EventType) *sfu.PeerSignal_Event {
+	roomID := t.RoomID
+	return &sfu.PeerSignal_Event{
+		Event: &sfu.Event{Type: e, RoomID: &roomID},
+	}
+}
@@ -1,38 +1,171 @@
 package sfu
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	sfu "vidcall/api/proto"
 	"vidcall/internal/sfu/infra"
+	"vidcall/internal/sfu/metrics"
+	"vidcall/internal/sfu/repo"
+	"vidcall/internal/sfu/security"
+	"vidcall/internal/sfu/service"
+	"vidcall/internal/sfu/service/egress"
 	"vidcall/internal/sfu/service/hub"
+	"vidcall/internal/sfu/service/transcript"
 	"vidcall/internal/sfu/transport"
+	"vidcall/pkg/config"
+	"vidcall/pkg/jwtx"
+	"vidcall/pkg/stt"
+	"vidcall/pkg/tlsx"
+	"vidcall/pkg/tracing"
 
 	_ "github.com/joho/godotenv/autoload"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// streams still open after the rooms are ended are cut
+const stopTimeout = 10 * time.Second
+
 func Execute() {
-	// TODO: add TLS for security + certs
-	hub.Init()
-	addr := os.Getenv("REDIS_URI")
-	pass := os.Getenv("REDIS_PASSWORD")
+	cfg, err := config.LoadSFU(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	shutdown, err := tracing.Init(context.Background(), "vidcall-sfu", cfg.Tracing.Options())
+	if err != nil {
+		log.Fatalf("failed to init tracing: %v", err)
+	}
+	defer shutdown(context.Background())
+
+	hub.Init(cfg.ICE)
+	// live stream output, served from the directory by a web server or CDN
+	egress.Init(cfg.Egress.Dir, cfg.Egress.FFmpeg)
+	// live captions, off unless an engine is configured
+	engine, err := stt.New(cfg.STT.Engine, cfg.STT.Command)
+	if err != nil {
+		log.Fatalf("failed to load speech to text engine: %v", err)
+	}
+	transcript.Init(engine)
 	// Fire up Redis
-	infra.Init(addr, pass, 0)
+	infra.Init(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+	// Mongo keeps call quality summaries and transcripts
+	infra.InitMongo(cfg.Mongo.URI, cfg.Mongo.DB, cfg.Mongo.Pool)
+
+	// every peer and service call carries a signaling token
+	keys, err := jwtx.Load(cfg.JWT.Keys())
+	if err != nil {
+		log.Fatalf("failed to load jwt keys: %v", err)
+	}
+	verifier := security.NewVerifier(keys, jwtx.NewDenylist(infra.C()))
+
+	// expose prometheus metrics
+	metrics.RegisterHub(hub.Hub())
+	if metricsPort := cfg.MetricsPort; metricsPort != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler())
+
+		go func() {
+			log.Println("SFU metrics starting at port " + metricsPort)
+			if err := http.ListenAndServe(metricsPort, mux); err != nil {
+				log.Printf("metrics server stopped: %v", err)
+			}
+		}()
+	}
 
-	port := os.Getenv("SFU_PORT")
+	port := cfg.Port
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
 		log.Fatalf("failed tp listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
-	sfu.RegisterSFUServer(grpcServer, &transport.Server{})
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.StreamInterceptor(transport.AuthStreamInterceptor(verifier)),
+		grpc.UnaryInterceptor(transport.AuthUnaryInterceptor(verifier)),
+	}
+
+	// mutual TLS with the signaling server
+	tlsFiles := cfg.TLS.Files()
+	if tlsFiles.Enabled() {
+		tlsConf, err := tlsx.ServerConfig(tlsFiles)
+		if err != nil {
+			log.Fatalf("failed to load gRPC TLS: %v", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	} else {
+		log.Println("GRPC_TLS_* are not set. Serving gRPC without TLS...")
+	}
+
+	grpcServer := grpc.NewServer(opts...)
+	sfu.RegisterSFUServer(grpcServer, &transport.Server{Config: cfg})
+
+	// gRPC health protocol, ready while Redis answers
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	// a restarted instance takes calls again
+	instance, _ := os.Hostname()
+	_ = repo.ClearDraining(context.Background(), infra.C(), instance)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Println("SFU server starting at port " + port)
+		serveErr <- grpcServer.Serve(lis)
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	log.Println("SFU server starting at port " + port)
-	if err = grpcServer.Serve(lis); err != nil {
+	go transport.WatchHealth(ctx, healthServer)
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("failed to server: %v", err)
+	case <-ctx.Done():
+	}
+
+	// a second signal kills the process
+	stop()
+	healthServer.Shutdown()
+
+	drainTimeout := cfg.DrainTimeout
+	log.Printf("SFU draining, rooms end in %s", drainTimeout)
+	_ = repo.MarkDraining(context.Background(), infra.C(), instance, drainTimeout+stopTimeout)
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	service.Drain(drainCtx, slog.Default().With("layer", "service", "service", "drain"))
+
+	// peers are gone, wait for their streams to return
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(stopTimeout):
+		log.Println("SFU streams still open, stopping")
+		grpcServer.Stop()
 	}
 
+	log.Println("SFU server stopped")
 }
@@ -0,0 +1,177 @@
+package transport
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
+	"vidcall/internal/sfu/security"
+	"vidcall/pkg/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type peerKey struct{}
+
+func peerFrom(ctx context.Context) *domain.PeerMD {
+	md, _ := ctx.Value(peerKey{}).(*domain.PeerMD)
+	return md
+}
+
+// stream with the authenticated peer identity attached to its context
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// AuthStreamInterceptor authenticates every stream before it reaches the
+// handlers. The identity comes from the verified token
+func AuthStreamInterceptor(v *security.Verifier) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		// health checks come from load balancers, not peers
+		if info.FullMethod == healthpb.Health_Watch_FullMethodName {
+			return handler(srv, ss)
+		}
+
+		ctx := ss.Context()
+		log := logger.GetLog(ctx).With("layer", "transport", "method", info.FullMethod)
+
+		peermd, err := identity(ctx, v)
+		if err != nil {
+			log.Warn("rejected peer identity", "err", err)
+			return err
+		}
+
+		ctx = context.WithValue(ctx, peerKey{}, peermd)
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// unary calls made on behalf of a peer, the others come from the
+// signaling server itself and need its service token
+var peerMethods = map[string]bool{
+	sfu.SFU_Whip_FullMethodName:       true,
+	sfu.SFU_Whep_FullMethodName:       true,
+	sfu.SFU_EndSession_FullMethodName: true,
+}
+
+// health checks, open to load balancers
+var openMethods = map[string]bool{
+	healthpb.Health_Check_FullMethodName: true,
+	healthpb.Health_List_FullMethodName:  true,
+}
+
+// AuthUnaryInterceptor authenticates the unary calls made on behalf of a
+// peer, like AuthStreamInterceptor does for streams, and the service token
+// of every other call
+func AuthUnaryInterceptor(v *security.Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if openMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		log := logger.GetLog(ctx).With("layer", "transport", "method", info.FullMethod)
+
+		if !peerMethods[info.FullMethod] {
+			if err := serviceCall(ctx, v); err != nil {
+				log.Warn("rejected service call", "err", err)
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}
+
+		peermd, err := identity(ctx, v)
+		if err != nil {
+			log.Warn("rejected peer identity", "err", err)
+			return nil, err
+		}
+
+		return handler(context.WithValue(ctx, peerKey{}, peermd), req)
+	}
+}
+
+// helper function to read one metadata value
+func getMD(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+// the caller is the signaling server, not a peer
+func serviceCall(ctx context.Context, v *security.Verifier) error {
+	claims, err := v.Verify(ctx, getMD(ctx, "service-token"))
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid service token")
+	}
+
+	if claims.Role != "service" {
+		return status.Error(codes.PermissionDenied, "not a service token")
+	}
+
+	return nil
+}
+
+func identity(ctx context.Context, v *security.Verifier) (*domain.PeerMD, error) {
+	claims, err := v.Verify(ctx, getMD(ctx, "token"))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid identity token")
+	}
+
+	if claims.PeerID == "" || claims.RoomID == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing peer or room id")
+	}
+
+	var r sfu.RoleType
+	switch claims.Role {
+	case "host":
+		r = sfu.RoleType_ROLE_HOST
+	case "guest":
+		r = sfu.RoleType_ROLE_GUEST
+	case "bot":
+		r = sfu.RoleType_ROLE_BOT
+	case "viewer":
+		r = sfu.RoleType_ROLE_VIEWER
+	default:
+		return nil, status.Error(codes.PermissionDenied, "unknown role")
+	}
+
+	// the one choice left to the caller, it only changes what it receives
+	mixAudio, _ := strconv.ParseBool(getMD(ctx, "mix-audio"))
+
+	return &domain.PeerMD{
+		Name:     claims.Name,
+		PeerID:   claims.PeerID,
+		RoomID:   claims.RoomID,
+		Role:     r,
+		Codecs:   normalizeCodecs(claims.Codecs),
+		Slots:    claims.Slots,
+		MixAudio: mixAudio,
+		E2EE:     claims.E2EE,
+		Scope:    claims.Scope,
+	}, nil
+}
+
+// room codec policy in lowercase, e.g. vp9, vp8
+func normalizeCodecs(raw []string) []string {
+	var codecs []string
+	for _, c := range raw {
+		if c = strings.TrimSpace(c); c != "" {
+			codecs = append(codecs, strings.ToLower(c))
+		}
+	}
+
+	return codecs
+}
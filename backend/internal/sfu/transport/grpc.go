@@ -1,34 +1,103 @@
 package transport
 
 import (
-	"fmt"
+	"context"
 	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/domain"
 	"vidcall/internal/sfu/service"
+	"vidcall/internal/sfu/service/hub"
+	"vidcall/pkg/audio"
+	"vidcall/pkg/config"
 	"vidcall/pkg/logger"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
 	sfu.UnimplementedSFUServer
+	Config *config.SFU
+}
+
+// receive slots a bot may ask for
+const maxBotSlots = 9
+
+// helper function to turn new peers away while the SFU shuts down
+func draining() error {
+	if hub.Hub().IsDraining() {
+		return status.Error(codes.Unavailable, "server is draining")
+	}
+	return nil
 }
 
 func (s *Server) Signal(stream sfu.SFU_SignalServer) error {
+	if err := draining(); err != nil {
+		return err
+	}
 
 	ctx := stream.Context()
 
+	// identity is set by AuthStreamInterceptor
+	peermd := peerFrom(ctx)
+	if peermd == nil {
+		return status.Error(codes.Unauthenticated, "unauthenticated stream")
+	}
+
+	// viewers only watch over WHEP
+	if peermd.Role == sfu.RoleType_ROLE_VIEWER {
+		return status.Error(codes.PermissionDenied, "viewers cannot join")
+	}
+
+	// ingest and view tokens only start their HTTP session
+	if peermd.Scope != "" {
+		return status.Error(codes.PermissionDenied, "scoped token cannot join")
+	}
+
+	// the SFU cannot decode encrypted audio to mix it
+	if peermd.E2EE && peermd.MixAudio {
+		return status.Error(codes.PermissionDenied, domain.ErrE2EE.Error())
+	}
+
+	// the mix decodes every publisher, browsers publish Opus
+	if peermd.MixAudio && !audio.CanDecode(audio.MimeOpus) {
+		return status.Error(codes.FailedPrecondition, domain.ErrNoOpus.Error())
+	}
+
 	// Temoporary: max 4 people in a meeting, for demo
+	poolSize := 1
+	if peermd.Role == sfu.RoleType_ROLE_BOT && peermd.Slots > 1 {
+		poolSize = min(peermd.Slots, maxBotSlots)
+	}
+
 	log := logger.GetLog(ctx)
-	newPeer, err := service.NewPeer(ctx, stream, 1, log)
+	newPeer, err := service.NewPeer(ctx, stream, peermd, poolSize, s.Config, log)
 	if err != nil {
 		return nil
 	}
 	log.Info("new peer created")
 
-	// auto cut peer connections by manual/error
-	defer newPeer.Disconnect()
+	// auto cut peer connections by manual/error, a peer that drops without
+	// LEAVE is taken out of its room first so nothing is sent to it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			newPeer.Leave()
+			_ = newPeer.Disconnect()
+		}()
+
+		if err := newPeer.Connect(); err != nil {
+			log.Error("peer unable to connect", "err", err)
+		}
+	}()
 
-	if err := newPeer.Connect(); err != nil {
-		errMsg := fmt.Sprint("Peer unable to connect: %w", err)
-		log.Error(errMsg)
+	// a kicked peer is blocked in Recv, only returning ends its stream
+	select {
+	case <-done:
+	case <-newPeer.Kicked():
+		newPeer.Leave()
+		log.Info("peer kicked")
+		return status.Error(codes.PermissionDenied, domain.ErrKicked.Error())
 	}
 
 	log.Info("peer disconnected")
@@ -36,3 +105,209 @@ func (s *Server) Signal(stream sfu.SFU_SignalServer) error {
 	return nil
 
 }
+
+// current quality of a room, or of one peer when peerID is set
+func (s *Server) GetStats(ctx context.Context, req *sfu.StatsRequest) (*sfu.StatsResponse, error) {
+	r := hub.Hub().GetRoom(req.RoomID)
+	if r == nil {
+		return nil, status.Error(codes.NotFound, "room not found")
+	}
+
+	res := &sfu.StatsResponse{}
+	for id, peer := range r.ListPeers() {
+		if req.PeerID != "" && req.PeerID != id {
+			continue
+		}
+
+		res.Peers = append(res.Peers, peer.Quality())
+	}
+
+	if req.PeerID != "" && len(res.Peers) == 0 {
+		return nil, status.Error(codes.NotFound, "peer not found")
+	}
+
+	return res, nil
+}
+
+// stream a live room to HLS, and RTMP when a URL is given
+func (s *Server) StartEgress(ctx context.Context, req *sfu.EgressRequest) (*sfu.EgressInfo, error) {
+	r := hub.Hub().GetRoom(req.RoomID)
+	if r == nil {
+		return nil, status.Error(codes.NotFound, "room not found")
+	}
+
+	e := r.Egress()
+	err := e.Start(domain.EgressConfig{
+		Layout:   req.Layout,
+		TargetID: req.TargetID,
+		RTMPURL:  req.RtmpURL,
+	})
+	if err != nil {
+		return nil, egressStatus(err)
+	}
+
+	return e.Info(), nil
+}
+
+func (s *Server) StopEgress(ctx context.Context, req *sfu.EgressRequest) (*sfu.EgressInfo, error) {
+	r := hub.Hub().GetRoom(req.RoomID)
+	if r == nil {
+		return nil, status.Error(codes.NotFound, "room not found")
+	}
+
+	e := r.Egress()
+	if err := e.Stop(); err != nil {
+		return nil, egressStatus(err)
+	}
+
+	return e.Info(), nil
+}
+
+func (s *Server) GetEgress(ctx context.Context, req *sfu.EgressRequest) (*sfu.EgressInfo, error) {
+	r := hub.Hub().GetRoom(req.RoomID)
+	if r == nil {
+		return nil, status.Error(codes.NotFound, "room not found")
+	}
+
+	return r.Egress().Info(), nil
+}
+
+// every room of the SFU with its peers and their connections
+func (s *Server) ListRooms(ctx context.Context, req *sfu.ListRoomsRequest) (*sfu.ListRoomsResponse, error) {
+	return &sfu.ListRoomsResponse{Rooms: service.ListRooms()}, nil
+}
+
+// force-close a room, its peers get ROOM_ENDED
+func (s *Server) CloseRoom(ctx context.Context, req *sfu.CloseRoomRequest) (*sfu.CloseRoomResponse, error) {
+	if domain.IsBreakout(req.RoomID) {
+		return nil, status.Error(codes.InvalidArgument, "breakout rooms end with their main room")
+	}
+
+	switch err := service.EndRoom(req.RoomID); err {
+	case nil:
+	case domain.ErrNotFound:
+		return nil, status.Error(codes.NotFound, err.Error())
+	default:
+		return nil, status.Error(codes.Internal, "unable to close room")
+	}
+
+	logger.GetLog(ctx).Info("room closed by admin", "room ID", req.RoomID)
+
+	return &sfu.CloseRoomResponse{}, nil
+}
+
+// remove a member from its room, asked by the host through signaling
+func (s *Server) KickPeer(ctx context.Context, req *sfu.KickPeerRequest) (*sfu.KickPeerResponse, error) {
+	switch err := service.KickPeer(req.RoomID, req.PeerID); err {
+	case nil:
+	case domain.ErrNoPeer:
+		return nil, status.Error(codes.NotFound, err.Error())
+	default:
+		return nil, status.Error(codes.Internal, "unable to kick peer")
+	}
+
+	logger.GetLog(ctx).Info("peer kicked", "room ID", req.RoomID, "peer ID", req.PeerID)
+	return &sfu.KickPeerResponse{}, nil
+}
+
+// helper function to map an egress error to its gRPC status
+func egressStatus(err error) error {
+	switch err {
+	case domain.ErrRoomNotLive:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case domain.ErrEgressRunning:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case domain.ErrNoEgress:
+		return status.Error(codes.NotFound, err.Error())
+	case domain.ErrBadEgress:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case domain.ErrE2EE:
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, "unable to run egress")
+	}
+}
+
+// WHIP ingest: join the peer of the call as a publisher and answer its offer
+func (s *Server) Whip(ctx context.Context, req *sfu.WhipRequest) (*sfu.WhipResponse, error) {
+	if err := draining(); err != nil {
+		return nil, err
+	}
+
+	peermd := peerFrom(ctx)
+	if peermd == nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated call")
+	}
+
+	md := *peermd
+	if req.Name != "" {
+		md.Name = req.Name
+	}
+
+	log := logger.GetLog(ctx).With("peer ID", md.PeerID, "room ID", md.RoomID)
+
+	answer, err := service.StartIngest(ctx, &md, req.Sdp, s.Config, log)
+	switch err {
+	case nil:
+	case domain.ErrRoomNotLive:
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case domain.ErrNotAllowed, domain.ErrE2EE:
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case domain.ErrBadOffer:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		log.Error("unable to start ingest", "err", err)
+		return nil, status.Error(codes.Internal, "unable to start ingest")
+	}
+
+	return &sfu.WhipResponse{Sdp: answer, SessionID: md.PeerID}, nil
+}
+
+// WHEP playback: add a receive-only viewer of the room and answer its offer
+func (s *Server) Whep(ctx context.Context, req *sfu.WhepRequest) (*sfu.WhepResponse, error) {
+	if err := draining(); err != nil {
+		return nil, err
+	}
+
+	peermd := peerFrom(ctx)
+	if peermd == nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated call")
+	}
+
+	log := logger.GetLog(ctx).With("peer ID", peermd.PeerID, "room ID", peermd.RoomID)
+
+	answer, sessionID, secret, err := service.StartView(ctx, peermd, req.PeerID, req.Sdp, s.Config, log)
+	switch err {
+	case nil:
+	case domain.ErrRoomNotLive:
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case domain.ErrBadOffer:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case domain.ErrNotAllowed, domain.ErrE2EE:
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	default:
+		log.Error("unable to start view", "err", err)
+		return nil, status.Error(codes.Internal, "unable to start view")
+	}
+
+	return &sfu.WhepResponse{Sdp: answer, SessionID: sessionID, Secret: secret}, nil
+}
+
+func (s *Server) EndSession(ctx context.Context, req *sfu.SessionRequest) (*sfu.SessionResponse, error) {
+	peermd := peerFrom(ctx)
+	if peermd == nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated call")
+	}
+
+	err := service.EndSession(peermd, req.SessionID, req.Secret)
+	switch err {
+	case nil:
+		return &sfu.SessionResponse{}, nil
+	case domain.ErrNoSession:
+		return nil, status.Error(codes.NotFound, err.Error())
+	case domain.ErrNotAllowed:
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return nil, status.Error(codes.Internal, "unable to end session")
+	}
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/sfu/infra"
+	"vidcall/internal/sfu/service/hub"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// Redis is pinged this often
+	healthInterval = 5 * time.Second
+	healthTimeout  = 2 * time.Second
+)
+
+// WatchHealth keeps the gRPC health status of the SFU up to date, serving
+// only while Redis answers and the SFU is not draining. It returns with ctx
+func WatchHealth(ctx context.Context, hs *health.Server) {
+	log := slog.Default().With("layer", "transport", "service", "health")
+	last := healthpb.HealthCheckResponse_UNKNOWN
+
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+
+	for {
+		st := healthpb.HealthCheckResponse_SERVING
+		if err := ping(ctx); err != nil {
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+			if st != last {
+				log.Warn("SFU not ready, Redis unreachable", "err", err)
+			}
+		}
+		if hub.Hub().IsDraining() {
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		if st != last {
+			hs.SetServingStatus("", st)
+			hs.SetServingStatus(sfu.SFU_ServiceDesc.ServiceName, st)
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// helper function to ping Redis with a deadline
+func ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	return infra.Ping(ctx)
+}
@@ -11,10 +11,30 @@ type Room struct {
 	Pin      string
 	Date     time.Time
 	Duration time.Duration
+	// video codecs allowed in the room, in order of preference
+	Codecs []string
+	// numeric room code of phone callers
+	DialIn string
+	// frames are encrypted by the clients, nothing that needs plaintext
+	// media is allowed
+	E2EE bool
 }
 
+// video codecs the SFU can forward
+var VideoCodecs = []string{"vp8", "vp9", "av1", "h264"}
+
 var (
 	ErrNotFound  = errors.New("room not found")
 	ErrBadPin    = errors.New("invalid pin")
 	ErrForbidden = errors.New("not permitted")
+	ErrBadCodec  = errors.New("unsupported codec")
+
+	ErrRoomNotLive   = errors.New("room is not live")
+	ErrEgressRunning = errors.New("room is already streamed")
+	ErrNoEgress      = errors.New("room is not streamed")
+	ErrBadEgress     = errors.New("invalid egress request")
+	ErrBadOffer      = errors.New("invalid offer")
+	ErrNoSession     = errors.New("session not found")
+	ErrE2EE          = errors.New("not available in an end-to-end encrypted room")
+	ErrUnavailable   = errors.New("server unavailable")
 )
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// One utterance of a speaker, stored by the SFU while captions are on
+type Caption struct {
+	PeerID string
+	Name   string
+	Start  time.Time
+	End    time.Time
+	Text   string
+}
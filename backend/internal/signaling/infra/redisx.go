@@ -0,0 +1,39 @@
+package infra
+
+import (
+	"context"
+	"sync"
+	"time"
+	"vidcall/pkg/logger"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+var (
+	redisOnce sync.Once
+	rdb       *goredis.Client
+)
+
+func InitRedis(addr string, password string, db int) {
+	redisOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+		defer cancel()
+
+		rdb = goredis.NewClient(&goredis.Options{
+			Addr:        addr,
+			Password:    password,
+			DB:          db,
+			DialTimeout: 5 * time.Second,
+		})
+
+		log := logger.GetLog(ctx).With("layer", "infra", "service", "redis")
+		err := rdb.Ping(ctx).Err()
+		if err != nil {
+			log.Error("Unable to connect to Redis")
+			return
+		}
+	})
+}
+
+func Redis() *goredis.Client { return rdb }
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "vidcall_signaling"
+
+var (
+	wsActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_sessions_active",
+		Help:      "WebSocket sessions currently open.",
+	})
+
+	wsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_sessions_total",
+		Help:      "WebSocket sessions opened.",
+	})
+
+	auth = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_total",
+		Help:      "Authentication attempts by method (pin, token) and result (success, failure).",
+	}, []string{"method", "result"})
+)
+
+// track a websocket session, call the returned func when it closes
+func WSSessionOpened() func() {
+	wsTotal.Inc()
+	wsActive.Inc()
+	return wsActive.Dec
+}
+
+func AuthSuccess(method string) { auth.WithLabelValues(method, "success").Inc() }
+
+func AuthFailure(method string) { auth.WithLabelValues(method, "failure").Inc() }
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,382 @@
+// Package protocol is the JSON signaling protocol spoken over the
+// websocket. Messages are the PeerSignal of sfu.proto in a
+// {type, id, payload} envelope: type is the name of the PeerSignal payload
+// field, id the client request ID and payload the message itself with
+// enums as lowercase value names. The schema and TypeScript types are
+// generated from the same descriptors, see cmd/protoschema.
+package protocol
+
+//go:generate go run ../../../cmd/protoschema -schema ../../../api/schema/signal.schema.json -ts ../../../../frontend/src/types/protocol.gen.ts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	sfu "vidcall/api/proto"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// bumped on breaking changes, negotiated as the websocket subprotocol
+const Version = 1
+
+var Subprotocol = "vidcall.v" + strconv.Itoa(Version)
+
+// request IDs travel in the envelope, not in the payload
+const requestIDField = "requestID"
+
+var (
+	ErrBadMessage    = errors.New("malformed message")
+	ErrUnknownType   = errors.New("unknown message type")
+	ErrUnknownValue  = errors.New("unknown enum value")
+	ErrUnknownAction = errors.New("unknown action")
+)
+
+var actionTypes = sfu.ActionType(0).Descriptor()
+
+type envelope struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+var payloads = (&sfu.PeerSignal{}).ProtoReflect().Descriptor().Oneofs().ByName("payload")
+
+// decode a client message, the request ID is returned even when the
+// payload is invalid so the error can be answered
+func Decode(raw []byte) (*sfu.PeerSignal, string, error) {
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrBadMessage, err)
+	}
+
+	fd := payloads.Fields().ByName(protoreflect.Name(env.Type))
+	if fd == nil {
+		return nil, env.ID, fmt.Errorf("%w: %q", ErrUnknownType, env.Type)
+	}
+
+	sig := &sfu.PeerSignal{}
+	msg := sig.ProtoReflect().NewField(fd).Message()
+
+	payload := env.Payload
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+	if err := unmarshalMessage(payload, msg); err != nil {
+		return nil, env.ID, err
+	}
+
+	if id := msg.Descriptor().Fields().ByJSONName(requestIDField); id != nil && env.ID != "" {
+		msg.Set(id, protoreflect.ValueOfString(env.ID))
+	}
+	sig.ProtoReflect().Set(fd, protoreflect.ValueOfMessage(msg))
+
+	return sig, env.ID, nil
+}
+
+func Encode(sig *sfu.PeerSignal) ([]byte, error) {
+	m := sig.ProtoReflect()
+
+	fd := m.WhichOneof(payloads)
+	if fd == nil {
+		return nil, ErrUnknownType
+	}
+
+	env := envelope{Type: string(fd.Name())}
+	msg := m.Get(fd).Message()
+
+	if id := msg.Descriptor().Fields().ByJSONName(requestIDField); id != nil {
+		env.ID = msg.Get(id).String()
+	}
+
+	obj := marshalMessage(msg)
+	if len(obj) > 0 {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		env.Payload = raw
+	}
+
+	return json.Marshal(env)
+}
+
+// error message answering a client request
+func NewError(requestID string, code sfu.ErrorCode, msg string) *sfu.PeerSignal {
+	return &sfu.PeerSignal{Payload: &sfu.PeerSignal_Error{
+		Error: &sfu.Error{RequestID: requestID, Code: code, Message: msg},
+	}}
+}
+
+// protocol code of a decode error
+func Code(err error) sfu.ErrorCode {
+	if errors.Is(err, ErrUnknownAction) {
+		return sfu.ErrorCode_UNKNOWN_ACTION
+	}
+	return sfu.ErrorCode_BAD_REQUEST
+}
+
+// wire name of an enum value, lowercase and without the prefix shared by
+// all values of the enum, e.g. LAYER_BASE is "base" and PUB is "pub"
+func EnumName(ed protoreflect.EnumDescriptor, n protoreflect.EnumNumber) string {
+	v := ed.Values().ByNumber(n)
+	if v == nil {
+		return strconv.Itoa(int(n))
+	}
+
+	return strings.ToLower(strings.TrimPrefix(string(v.Name()), enumPrefix(ed)))
+}
+
+func ParseEnum(ed protoreflect.EnumDescriptor, name string) (protoreflect.EnumNumber, bool) {
+	values := ed.Values()
+	for i := range values.Len() {
+		v := values.Get(i)
+		if EnumName(ed, v.Number()) == name {
+			return v.Number(), true
+		}
+	}
+
+	return 0, false
+}
+
+// wire names of all the values of an enum
+func EnumNames(ed protoreflect.EnumDescriptor) []string {
+	values := ed.Values()
+	names := make([]string, 0, values.Len())
+	for i := range values.Len() {
+		names = append(names, EnumName(ed, values.Get(i).Number()))
+	}
+
+	return names
+}
+
+func enumPrefix(ed protoreflect.EnumDescriptor) string {
+	values := ed.Values()
+	if values.Len() < 2 {
+		return ""
+	}
+
+	first := string(values.Get(0).Name())
+	i := strings.IndexByte(first, '_')
+	if i < 0 {
+		return ""
+	}
+
+	prefix := first[:i+1]
+	for j := 1; j < values.Len(); j++ {
+		if !strings.HasPrefix(string(values.Get(j).Name()), prefix) {
+			return ""
+		}
+	}
+
+	return prefix
+}
+
+// fields of a message as they appear in a payload
+func payloadFields(md protoreflect.MessageDescriptor) []protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	res := make([]protoreflect.FieldDescriptor, 0, fields.Len())
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if fd.JSONName() == requestIDField {
+			continue
+		}
+		res = append(res, fd)
+	}
+
+	return res
+}
+
+// optional fields are left out of a payload when unset
+func omittable(fd protoreflect.FieldDescriptor) bool {
+	return fd.IsList() || fd.HasPresence()
+}
+
+// enums without an UNSPECIFIED zero value must be given, a missing action
+// type must not turn into START_ROOM
+func required(fd protoreflect.FieldDescriptor) bool {
+	if fd.Kind() != protoreflect.EnumKind || omittable(fd) {
+		return false
+	}
+
+	zero := fd.Enum().Values().ByNumber(0)
+	return zero != nil && !strings.HasSuffix(string(zero.Name()), "UNSPECIFIED")
+}
+
+func unknownValue(fd protoreflect.FieldDescriptor, name string) error {
+	if fd.Enum() == actionTypes {
+		return fmt.Errorf("%w: %q", ErrUnknownAction, name)
+	}
+	return fmt.Errorf("%w: %s: %q", ErrUnknownValue, fd.JSONName(), name)
+}
+
+func marshalMessage(m protoreflect.Message) map[string]any {
+	obj := map[string]any{}
+
+	for _, fd := range payloadFields(m.Descriptor()) {
+		if omittable(fd) && !m.Has(fd) {
+			continue
+		}
+
+		v := m.Get(fd)
+		if fd.IsList() {
+			list := v.List()
+			items := make([]any, 0, list.Len())
+			for i := range list.Len() {
+				items = append(items, marshalValue(fd, list.Get(i)))
+			}
+			obj[fd.JSONName()] = items
+			continue
+		}
+
+		obj[fd.JSONName()] = marshalValue(fd, v)
+	}
+
+	return obj
+}
+
+func marshalValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		return EnumName(fd.Enum(), v.Enum())
+	case protoreflect.MessageKind:
+		return marshalMessage(v.Message())
+	default:
+		return v.Interface()
+	}
+}
+
+func unmarshalMessage(raw json.RawMessage, m protoreflect.Message) error {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadMessage, err)
+	}
+
+	for _, fd := range payloadFields(m.Descriptor()) {
+		rawField, ok := obj[fd.JSONName()]
+		if !ok || string(rawField) == "null" {
+			if required(fd) {
+				return unknownValue(fd, "")
+			}
+			continue
+		}
+
+		if !fd.IsList() {
+			v, err := unmarshalValue(rawField, fd, m)
+			if err != nil {
+				return err
+			}
+			m.Set(fd, v)
+			continue
+		}
+
+		var items []json.RawMessage
+		if err := json.Unmarshal(rawField, &items); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrBadMessage, fd.JSONName(), err)
+		}
+
+		list := m.Mutable(fd).List()
+		for _, item := range items {
+			v, err := unmarshalValue(item, fd, m)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalValue(raw json.RawMessage, fd protoreflect.FieldDescriptor, parent protoreflect.Message) (protoreflect.Value, error) {
+	bad := func(err error) (protoreflect.Value, error) {
+		return protoreflect.Value{}, fmt.Errorf("%w: %s: %v", ErrBadMessage, fd.JSONName(), err)
+	}
+
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		var name string
+		if err := json.Unmarshal(raw, &name); err != nil {
+			return bad(err)
+		}
+
+		n, ok := ParseEnum(fd.Enum(), name)
+		if !ok {
+			return protoreflect.Value{}, unknownValue(fd, name)
+		}
+		return protoreflect.ValueOfEnum(n), nil
+
+	case protoreflect.MessageKind:
+		var msg protoreflect.Message
+		if fd.IsList() {
+			msg = parent.Mutable(fd).List().NewElement().Message()
+		} else {
+			msg = parent.NewField(fd).Message()
+		}
+
+		if err := unmarshalMessage(raw, msg); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfMessage(msg), nil
+
+	case protoreflect.StringKind:
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return bad(err)
+		}
+		return protoreflect.ValueOfString(v), nil
+
+	case protoreflect.BoolKind:
+		var v bool
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return bad(err)
+		}
+		return protoreflect.ValueOfBool(v), nil
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var v int32
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return bad(err)
+		}
+		return protoreflect.ValueOfInt32(v), nil
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var v uint32
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return bad(err)
+		}
+		return protoreflect.ValueOfUint32(v), nil
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var v int64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return bad(err)
+		}
+		return protoreflect.ValueOfInt64(v), nil
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var v uint64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return bad(err)
+		}
+		return protoreflect.ValueOfUint64(v), nil
+
+	case protoreflect.DoubleKind:
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return bad(err)
+		}
+		return protoreflect.ValueOfFloat64(v), nil
+
+	case protoreflect.FloatKind:
+		var v float32
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return bad(err)
+		}
+		return protoreflect.ValueOfFloat32(v), nil
+	}
+
+	return bad(fmt.Errorf("unsupported kind %s", fd.Kind()))
+}
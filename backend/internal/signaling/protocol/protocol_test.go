@@ -0,0 +1,215 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	sfu "vidcall/api/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDecode(t *testing.T) {
+	target := "peer-2"
+	base := sfu.VideoLayer_LAYER_BASE
+
+	tests := []struct {
+		name string
+		raw  string
+		want *sfu.PeerSignal
+		id   string
+		err  error
+	}{
+		{
+			name: "sdp with enums by name",
+			raw:  `{"type": "sdp", "payload": {"pc": "pub", "type": "offer", "sdp": "v=0"}}`,
+			want: &sfu.PeerSignal{Payload: &sfu.PeerSignal_Sdp{Sdp: &sfu.Sdp{Pc: sfu.PcType_PUB, Type: sfu.SdpType_OFFER, Sdp: "v=0"}}},
+		},
+		{
+			name: "ice with its json name",
+			raw:  `{"type": "ice", "payload": {"pc": "sub", "candidate": "candidate:1", "sdpMid": "0", "sdpMLineIndex": 1}}`,
+			want: &sfu.PeerSignal{Payload: &sfu.PeerSignal_Ice{Ice: &sfu.IceCandidate{Pc: sfu.PcType_SUB, Candidate: "candidate:1", SdpMid: "0", SdpMlineIndex: 1}}},
+		},
+		{
+			name: "action takes the envelope id",
+			raw:  `{"type": "action", "id": "r1", "payload": {"type": "set_max_layer", "targetID": "peer-2", "maxLayer": "base"}}`,
+			want: &sfu.PeerSignal{Payload: &sfu.PeerSignal_Action{Action: &sfu.Action{
+				Type: sfu.ActionType_SET_MAX_LAYER, TargetID: &target, MaxLayer: &base, RequestID: "r1",
+			}}},
+			id: "r1",
+		},
+		{
+			name: "action without payload fields",
+			raw:  `{"type": "action", "id": "r2", "payload": {"type": "leave"}}`,
+			want: &sfu.PeerSignal{Payload: &sfu.PeerSignal_Action{Action: &sfu.Action{Type: sfu.ActionType_LEAVE, RequestID: "r2"}}},
+			id:   "r2",
+		},
+		{name: "not JSON", raw: `{"type": `, err: ErrBadMessage},
+		{name: "unknown type", raw: `{"type": "chat", "id": "r3"}`, id: "r3", err: ErrUnknownType},
+		{name: "unknown action", raw: `{"type": "action", "id": "r4", "payload": {"type": "fly"}}`, id: "r4", err: ErrUnknownAction},
+		{name: "action type is required", raw: `{"type": "action", "id": "r5", "payload": {}}`, id: "r5", err: ErrUnknownAction},
+		{name: "unknown enum value", raw: `{"type": "sdp", "payload": {"pc": "side"}}`, err: ErrUnknownValue},
+		{name: "enum by number", raw: `{"type": "sdp", "payload": {"pc": 1}}`, err: ErrBadMessage},
+		{name: "wrong field type", raw: `{"type": "sdp", "payload": {"type": "offer", "sdp": 5}}`, err: ErrBadMessage},
+		{name: "payload not an object", raw: `{"type": "sdp", "payload": []}`, err: ErrBadMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, id, err := Decode([]byte(tt.raw))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if id != tt.id {
+				t.Errorf("id: got %q, want %q", id, tt.id)
+			}
+			if err == nil && !proto.Equal(sig, tt.want) {
+				t.Errorf("got %v, want %v", sig, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		sig  *sfu.PeerSignal
+		typ  string
+		id   string
+		// payload fields checked, as JSON. No payload at all when nil
+		payload map[string]string
+	}{
+		{
+			name: "request id moves to the envelope",
+			sig:  &sfu.PeerSignal{Payload: &sfu.PeerSignal_Ack{Ack: &sfu.Ack{RequestID: "r1"}}},
+			typ:  "ack",
+			id:   "r1",
+		},
+		{
+			name:    "enums by name",
+			sig:     NewError("r2", sfu.ErrorCode_NOT_ALLOWED, "host only"),
+			typ:     "error",
+			id:      "r2",
+			payload: map[string]string{"code": `"not_allowed"`, "message": `"host only"`},
+		},
+		{
+			name: "nested messages and lists",
+			sig: &sfu.PeerSignal{Payload: &sfu.PeerSignal_Event{Event: &sfu.Event{
+				Type:    sfu.EventType_CODEC_REJECTED,
+				PeerID:  "peer-1",
+				Quality: &sfu.Quality{PeerID: "peer-1", Score: 3},
+				Codecs:  []string{"VP8"},
+			}}},
+			typ: "event",
+			payload: map[string]string{
+				"type":    `"codec_rejected"`,
+				"name":    `""`,
+				"quality": `{"peerID":"peer-1","score":3}`,
+				"codecs":  `["VP8"]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := Encode(tt.sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var env struct {
+				Type    string                     `json:"type"`
+				ID      string                     `json:"id"`
+				Payload map[string]json.RawMessage `json:"payload"`
+			}
+			if err := json.Unmarshal(raw, &env); err != nil {
+				t.Fatal(err)
+			}
+
+			if env.Type != tt.typ || env.ID != tt.id {
+				t.Errorf("got type %q id %q, want %q %q", env.Type, env.ID, tt.typ, tt.id)
+			}
+			if tt.payload == nil && env.Payload != nil {
+				t.Errorf("got payload %s, want none", raw)
+			}
+			if _, ok := env.Payload[requestIDField]; ok {
+				t.Errorf("request id in the payload: %s", raw)
+			}
+
+			for key, want := range tt.payload {
+				if got := string(env.Payload[key]); got != want {
+					t.Errorf("%s: got %s, want %s", key, got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := Encode(&sfu.PeerSignal{}); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("empty signal: got %v, want %v", err, ErrUnknownType)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	target := "peer-9"
+	sigs := []*sfu.PeerSignal{
+		{Payload: &sfu.PeerSignal_Sdp{Sdp: &sfu.Sdp{Pc: sfu.PcType_SUB, Type: sfu.SdpType_ANSWER, Sdp: "v=0\r\n"}}},
+		{Payload: &sfu.PeerSignal_Action{Action: &sfu.Action{Type: sfu.ActionType_PAUSE_VIDEO, TargetID: &target, RequestID: "r7"}}},
+		{Payload: &sfu.PeerSignal_Event{Event: &sfu.Event{Type: sfu.EventType_JOIN_EVENT, Name: "Ann", PeerID: "peer-1"}}},
+	}
+
+	for _, sig := range sigs {
+		raw, err := Encode(sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, _, err := Decode(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+		if !proto.Equal(got, sig) {
+			t.Errorf("got %v, want %v", got, sig)
+		}
+	}
+}
+
+func TestEnumNames(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+	}{
+		// the prefix every value shares is dropped
+		{"base", EnumName(sfu.VideoLayer(0).Descriptor(), 1)},
+		{"full", EnumName(sfu.VideoLayer(0).Descriptor(), 0)},
+		// values without a shared prefix stay whole
+		{"pub", EnumName(sfu.PcType(0).Descriptor(), 1)},
+		{"pc_unspecified", EnumName(sfu.PcType(0).Descriptor(), 0)},
+		{"start_room", EnumName(sfu.ActionType(0).Descriptor(), 0)},
+		{"99", EnumName(sfu.ActionType(0).Descriptor(), 99)},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.name {
+			t.Errorf("got %q, want %q", tt.got, tt.name)
+		}
+	}
+
+	if n, ok := ParseEnum(sfu.ActionType(0).Descriptor(), "audio_only_on"); !ok || n != 13 {
+		t.Errorf("audio_only_on: got %d %v", n, ok)
+	}
+	if _, ok := ParseEnum(sfu.ActionType(0).Descriptor(), "AUDIO_ONLY_ON"); ok {
+		t.Error("names are lowercase")
+	}
+}
+
+func TestCode(t *testing.T) {
+	_, _, err := Decode([]byte(`{"type": "action", "payload": {"type": "fly"}}`))
+	if c := Code(err); c != sfu.ErrorCode_UNKNOWN_ACTION {
+		t.Errorf("unknown action: got %v", c)
+	}
+
+	_, _, err = Decode([]byte(`nope`))
+	if c := Code(err); c != sfu.ErrorCode_BAD_REQUEST {
+		t.Errorf("bad message: got %v", c)
+	}
+}
@@ -0,0 +1,209 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// messages and enums reachable from the PeerSignal payloads, in
+// declaration order
+type definitions struct {
+	messages []protoreflect.MessageDescriptor
+	enums    []protoreflect.EnumDescriptor
+}
+
+func collect() *definitions {
+	d := &definitions{}
+
+	fields := payloads.Fields()
+	for i := range fields.Len() {
+		d.addMessage(fields.Get(i).Message())
+	}
+
+	return d
+}
+
+func (d *definitions) addMessage(md protoreflect.MessageDescriptor) {
+	if slices.Contains(d.messages, md) {
+		return
+	}
+	d.messages = append(d.messages, md)
+
+	for _, fd := range payloadFields(md) {
+		switch fd.Kind() {
+		case protoreflect.MessageKind:
+			d.addMessage(fd.Message())
+		case protoreflect.EnumKind:
+			if !slices.Contains(d.enums, fd.Enum()) {
+				d.enums = append(d.enums, fd.Enum())
+			}
+		}
+	}
+}
+
+// JSON Schema (2020-12) of the messages exchanged over the websocket
+func Schema() ([]byte, error) {
+	d := collect()
+	defs := map[string]any{}
+
+	for _, ed := range d.enums {
+		defs[string(ed.Name())] = map[string]any{
+			"type": "string",
+			"enum": EnumNames(ed),
+		}
+	}
+
+	for _, md := range d.messages {
+		if len(payloadFields(md)) == 0 {
+			continue
+		}
+
+		props := map[string]any{}
+		req := []string{}
+
+		for _, fd := range payloadFields(md) {
+			props[fd.JSONName()] = schemaField(fd)
+			if !omittable(fd) {
+				req = append(req, fd.JSONName())
+			}
+		}
+
+		defs[string(md.Name())] = map[string]any{
+			"type":       "object",
+			"properties": props,
+			"required":   req,
+		}
+	}
+
+	signals := []any{}
+	fields := payloads.Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+
+		props := map[string]any{
+			"type": map[string]any{"const": string(fd.Name())},
+			"id":   map[string]any{"type": "string"},
+		}
+		req := []string{"type"}
+
+		if len(payloadFields(fd.Message())) > 0 {
+			props["payload"] = map[string]any{"$ref": "#/$defs/" + string(fd.Message().Name())}
+			req = append(req, "payload")
+		} else {
+			req = append(req, "id")
+		}
+
+		signals = append(signals, map[string]any{
+			"type":       "object",
+			"properties": props,
+			"required":   req,
+		})
+	}
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     Subprotocol,
+		"title":   fmt.Sprintf("vidcall signaling protocol v%d", Version),
+		"oneOf":   signals,
+		"$defs":   defs,
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func schemaField(fd protoreflect.FieldDescriptor) map[string]any {
+	var item map[string]any
+
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		item = map[string]any{"$ref": "#/$defs/" + string(fd.Enum().Name())}
+	case protoreflect.MessageKind:
+		item = map[string]any{"$ref": "#/$defs/" + string(fd.Message().Name())}
+	case protoreflect.StringKind:
+		item = map[string]any{"type": "string"}
+	case protoreflect.BoolKind:
+		item = map[string]any{"type": "boolean"}
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		item = map[string]any{"type": "number"}
+	default:
+		item = map[string]any{"type": "integer"}
+	}
+
+	if fd.IsList() {
+		return map[string]any{"type": "array", "items": item}
+	}
+	return item
+}
+
+// TypeScript types of the messages exchanged over the websocket
+func TypeScript() string {
+	d := collect()
+
+	var b strings.Builder
+	b.WriteString("// Code generated by protoschema from sfu.proto. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "export const PROTOCOL_VERSION = %d\n", Version)
+	fmt.Fprintf(&b, "export const SUBPROTOCOL = %q\n", Subprotocol)
+
+	for _, ed := range d.enums {
+		names := EnumNames(ed)
+		for i, n := range names {
+			names[i] = fmt.Sprintf("%q", n)
+		}
+		fmt.Fprintf(&b, "\nexport type %s = %s\n", ed.Name(), strings.Join(names, " | "))
+	}
+
+	for _, md := range d.messages {
+		if len(payloadFields(md)) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\nexport interface %s {\n", md.Name())
+		for _, fd := range payloadFields(md) {
+			opt := ""
+			if omittable(fd) {
+				opt = "?"
+			}
+			fmt.Fprintf(&b, "    %s%s: %s\n", fd.JSONName(), opt, tsType(fd))
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString("\nexport type Signal =\n")
+	fields := payloads.Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if len(payloadFields(fd.Message())) > 0 {
+			fmt.Fprintf(&b, "    | {type: %q, id?: string, payload: %s}\n", fd.Name(), fd.Message().Name())
+		} else {
+			fmt.Fprintf(&b, "    | {type: %q, id: string}\n", fd.Name())
+		}
+	}
+
+	return b.String()
+}
+
+func tsType(fd protoreflect.FieldDescriptor) string {
+	var t string
+
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		t = string(fd.Enum().Name())
+	case protoreflect.MessageKind:
+		t = string(fd.Message().Name())
+	case protoreflect.StringKind:
+		t = "string"
+	case protoreflect.BoolKind:
+		t = "boolean"
+	default:
+		t = "number"
+	}
+
+	if fd.IsList() {
+		return t + "[]"
+	}
+	return t
+}
@@ -16,6 +16,9 @@ type roomDoc struct {
 	Pin      string    `bson:"pin"`
 	Date     time.Time `bson:"date"`
 	Duration string    `bson:"duration"`
+	Codecs   []string  `bson:"codecs,omitempty"`
+	DialIn   string    `bson:"dialIn,omitempty"`
+	E2EE     bool      `bson:"e2ee,omitempty"`
 }
 
 func toRoomDoc(r domain.Room) roomDoc {
@@ -25,6 +28,9 @@ func toRoomDoc(r domain.Room) roomDoc {
 		Pin:      r.Pin,
 		Date:     r.Date,
 		Duration: r.Duration.String(),
+		Codecs:   r.Codecs,
+		DialIn:   r.DialIn,
+		E2EE:     r.E2EE,
 	}
 }
 
@@ -37,6 +43,9 @@ func fromRoomDoc(rd roomDoc) domain.Room {
 		Pin:      rd.Pin,
 		Date:     rd.Date,
 		Duration: dur,
+		Codecs:   rd.Codecs,
+		DialIn:   rd.DialIn,
+		E2EE:     rd.E2EE,
 	}
 }
 
@@ -83,4 +92,29 @@ func GetRoomDoc(ctx context.Context, db *mongo.Database, roomID string) (*domain
 	}
 }
 
+func GetRoomByDialIn(ctx context.Context, db *mongo.Database, dialIn string) (*domain.Room, error) {
+	log := logger.GetLog(ctx).With("layer", "repo", "service", "monogodb")
+
+	col := db.Collection("rooms")
+
+	opCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var d roomDoc
+	err := col.FindOne(opCtx, bson.M{"dialIn": dialIn}).Decode(&d)
+
+	switch err {
+	case nil:
+		room := fromRoomDoc(d)
+		return &room, nil
+
+	case mongo.ErrNoDocuments:
+		return nil, err
+
+	default:
+		log.Error("network error")
+		return nil, err
+	}
+}
+
 func RemoveRoomDoc(ctx context.Context, db *mongo.Database, roomID string) {}
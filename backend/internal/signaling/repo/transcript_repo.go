@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"vidcall/internal/signaling/domain"
+	"vidcall/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type captionDoc struct {
+	RoomID string    `bson:"roomID"`
+	PeerID string    `bson:"peerID"`
+	Name   string    `bson:"name"`
+	Start  time.Time `bson:"start"`
+	End    time.Time `bson:"end"`
+	Text   string    `bson:"text"`
+}
+
+// captions of a room in the order they were spoken
+func GetTranscript(ctx context.Context, db *mongo.Database, roomID string) ([]domain.Caption, error) {
+	log := logger.GetLog(ctx).With("layer", "repo", "service", "monogodb", "roomID", roomID)
+
+	col := db.Collection("transcripts")
+
+	opCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cur, err := col.Find(opCtx, bson.M{"roomID": roomID}, options.Find().SetSort(bson.D{{Key: "start", Value: 1}}))
+	if err != nil {
+		log.Error("network error")
+		return nil, err
+	}
+
+	var docs []captionDoc
+	if err := cur.All(opCtx, &docs); err != nil {
+		log.Error("unable to decode transcript")
+		return nil, err
+	}
+
+	captions := make([]domain.Caption, 0, len(docs))
+	for _, d := range docs {
+		captions = append(captions, domain.Caption{
+			PeerID: d.PeerID,
+			Name:   d.Name,
+			Start:  d.Start,
+			End:    d.End,
+			Text:   d.Text,
+		})
+	}
+
+	return captions, nil
+}
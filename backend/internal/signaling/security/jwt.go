@@ -1,7 +1,10 @@
 package security
 
 import (
+	"context"
 	"time"
+	"vidcall/pkg/jwtx"
+	"vidcall/pkg/utils"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -11,50 +14,105 @@ type Claims struct {
 	PeerID string
 	RoomID string
 	Role   string
+	// room policy and bot slots, signed so the SFU does not take them from
+	// the caller
+	Codecs []string `json:",omitempty"`
+	E2EE   bool     `json:",omitempty"`
+	Slots  int      `json:",omitempty"`
+	// ingest or view, the token only starts that kind of session
+	Scope string `json:",omitempty"`
 	jwt.RegisteredClaims
 }
 
+// scopes of the tokens handed to encoders and WHEP players
+const (
+	ScopeIngest = "ingest"
+	ScopeView   = "view"
+)
+
+// what a token allows on top of its role
+type Grant struct {
+	// codec policy and encryption of the room
+	Codecs []string
+	E2EE   bool
+	// remote peers received at once, for bots
+	Slots int
+	// ScopeIngest or ScopeView, empty for members of the call
+	Scope string
+}
+
 type Issuer struct {
-	secret []byte
-	ttl    time.Duration
+	keys *jwtx.Keyring
+	deny *jwtx.Denylist
+	ttl  time.Duration
 }
 
-func NewIssuer(secret string) *Issuer {
+func NewIssuer(keys *jwtx.Keyring, deny *jwtx.Denylist, ttl time.Duration) *Issuer {
 	//  TODO: sync with meeting duration somehow???
-	exp := 2 * time.Hour
-	return &Issuer{secret: []byte(secret), ttl: exp}
+	return &Issuer{keys: keys, deny: deny, ttl: ttl}
 }
 
-func (i *Issuer) Issue(roomID string, memberID string, name string, role string) (string, error) {
+func (i *Issuer) Issue(ctx context.Context, roomID string, memberID string, name string, role string, g Grant) (string, error) {
 	now := time.Now()
 	c := Claims{
 		Name:   name,
 		PeerID: memberID,
 		RoomID: roomID,
 		Role:   role,
+		Codecs: g.Codecs,
+		E2EE:   g.E2EE,
+		Slots:  g.Slots,
+		Scope:  g.Scope,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.GenerateTokenID(),
 			Subject:   memberID,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(i.ttl)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
+	token, err := i.keys.Sign(c)
+	if err != nil {
+		return "", err
+	}
 
-	return token.SignedString(i.secret)
+	// remember the jti so the member can be kicked later
+	if i.deny != nil {
+		if err := i.deny.Track(ctx, roomID, memberID, c.ID, c.ExpiresAt.Time); err != nil {
+			return "", err
+		}
+	}
+
+	return token, nil
 }
 
 func (i *Issuer) Parse(raw string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(
-		raw,
-		&Claims{},
-		func(_ *jwt.Token) (any, error) { return i.secret, nil },
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
-	)
-
-	if err != nil || !token.Valid {
+	var c Claims
+	if err := i.keys.Parse(raw, &c); err != nil {
 		return nil, err
 	}
 
-	return token.Claims.(*Claims), nil
+	return &c, nil
+}
+
+// check the token has not been revoked
+func (i *Issuer) Revoked(ctx context.Context, c *Claims) (bool, error) {
+	if i.deny == nil {
+		return false, nil
+	}
+
+	return i.deny.IsRevoked(ctx, c.ID)
+}
+
+// revoke the token held by a room member
+func (i *Issuer) RevokePeer(ctx context.Context, roomID string, peerID string) error {
+	if i.deny == nil {
+		return nil
+	}
+
+	return i.deny.RevokePeer(ctx, roomID, peerID)
+}
+
+func (i *Issuer) JWKS() jwtx.JWKSet {
+	return i.keys.JWKS()
 }
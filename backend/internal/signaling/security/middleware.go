@@ -2,11 +2,17 @@ package security
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
+	"slices"
+	"strings"
+	"vidcall/internal/signaling/metrics"
 	"vidcall/pkg/utils"
 )
 
 type ctxKey struct{}
+type issuerKey struct{}
+type tokenKey struct{}
 
 func ClaimsFrom(ctx context.Context) *Claims {
 	c, _ := ctx.Value(ctxKey{}).(*Claims)
@@ -14,41 +20,107 @@ func ClaimsFrom(ctx context.Context) *Claims {
 }
 
 func IssuerFrom(ctx context.Context) *Issuer {
-	i, _ := ctx.Value(ctxKey{}).(*Issuer)
+	i, _ := ctx.Value(issuerKey{}).(*Issuer)
 	return i
 }
 
-func RequireAuth(i *Issuer) func(http.HandlerFunc) http.HandlerFunc {
+// raw token of the authenticated request
+func TokenFrom(ctx context.Context) string {
+	t, _ := ctx.Value(tokenKey{}).(string)
+	return t
+}
+
+// context of a caller authenticated outside of HTTP, like a phone bridged
+// by the SIP gateway
+func ClaimsContext(ctx context.Context, c *Claims, raw string) context.Context {
+	ctx = context.WithValue(ctx, ctxKey{}, c)
+	return context.WithValue(ctx, tokenKey{}, raw)
+}
+
+func IssuerContext(ctx context.Context, i *Issuer) context.Context {
+	return context.WithValue(ctx, issuerKey{}, i)
+}
+
+// RequireAuth lets through the callers holding a valid token. Scoped tokens,
+// like the ingest token of an encoder, only pass where their scope is listed
+func RequireAuth(i *Issuer, scopes ...string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie("session_id")
-			if err != nil {
+			raw, ok := rawToken(r)
+			if !ok {
+				metrics.AuthFailure("token")
 				utils.Error(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 
-			raw := cookie.Value
-
-			// TODO: add header token here for ws bot access
-
 			claims, err := i.Parse(raw)
 			if err != nil {
+				metrics.AuthFailure("token")
 				utils.Error(w, http.StatusUnauthorized, "unathorized")
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), ctxKey{}, claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			revoked, err := i.Revoked(r.Context(), claims)
+			if err != nil {
+				utils.Error(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+
+			if revoked {
+				metrics.AuthFailure("token")
+				utils.Error(w, http.StatusUnauthorized, "unathorized")
+				return
+			}
+
+			if claims.Scope != "" && !slices.Contains(scopes, claims.Scope) {
+				metrics.AuthFailure("scope")
+				utils.Error(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			metrics.AuthSuccess("token")
+
+			next.ServeHTTP(w, r.WithContext(ClaimsContext(r.Context(), claims, raw)))
 
 		})
 	}
 }
 
+// bearer token of encoders and bots, else the session cookie
+func rawToken(r *http.Request) (string, bool) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		return token, true
+	}
+
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		return "", false
+	}
+
+	return cookie.Value, true
+}
+
 func WithIssuer(i *Issuer) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			ctx := context.WithValue(r.Context(), ctxKey{}, i)
-			next(w, r.WithContext(ctx))
+			next(w, r.WithContext(IssuerContext(r.Context(), i)))
+		}
+	}
+}
+
+// RequireAdmin lets through the operators holding the static admin token,
+// sent as a bearer token
+func RequireAdmin(token string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(raw), []byte(token)) != 1 {
+				metrics.AuthFailure("admin")
+				utils.Error(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			metrics.AuthSuccess("admin")
+
+			next(w, r)
 		}
 	}
 }
@@ -0,0 +1,65 @@
+package security
+
+import (
+	"context"
+	"sync"
+	"time"
+	"vidcall/pkg/jwtx"
+	"vidcall/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// lifetime of the token the signaling server calls the SFU with
+	serviceTTL = 5 * time.Minute
+	// a new token is signed this long before the old one expires
+	serviceRefresh = time.Minute
+)
+
+// ServiceCredentials signs the calls the signaling server makes to the SFU
+// on its own behalf, like ListRooms or StartEgress. The SFU only takes
+// those from a "service" token
+type ServiceCredentials struct {
+	keys *jwtx.Keyring
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func NewServiceCredentials(keys *jwtx.Keyring) *ServiceCredentials {
+	return &ServiceCredentials{keys: keys}
+}
+
+func (s *ServiceCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Until(s.expiry) < serviceRefresh {
+		now := time.Now()
+		c := Claims{
+			Role: "service",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        utils.GenerateTokenID(),
+				Subject:   "signaling",
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(serviceTTL)),
+			},
+		}
+
+		token, err := s.keys.Sign(c)
+		if err != nil {
+			return nil, err
+		}
+
+		s.token, s.expiry = token, c.ExpiresAt.Time
+	}
+
+	return map[string]string{"service-token": s.token}, nil
+}
+
+// the token is a bearer one, mutual TLS is optional between the servers
+func (s *ServiceCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/domain"
+	"vidcall/pkg/logger"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListRooms returns the rooms live on the SFU with their peers and
+// connections, for operators
+func ListRooms(ctx context.Context, client sfu.SFUClient) ([]*sfu.RoomInfo, error) {
+	log := logger.GetLog(ctx).With("layer", "service")
+
+	res, err := client.ListRooms(ctx, &sfu.ListRoomsRequest{})
+	if err != nil {
+		return nil, adminError(log, err)
+	}
+
+	return res.Rooms, nil
+}
+
+// CloseRoom ends a room on the SFU, its peers are disconnected
+func CloseRoom(ctx context.Context, client sfu.SFUClient, roomID string) error {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	if _, err := client.CloseRoom(ctx, &sfu.CloseRoomRequest{RoomID: roomID}); err != nil {
+		return adminError(log, err)
+	}
+
+	log.Info("room closed by admin")
+	return nil
+}
+
+// helper function to map an SFU status to a domain error
+func adminError(log *slog.Logger, err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return domain.ErrNotFound
+	case codes.InvalidArgument:
+		return domain.ErrForbidden
+	case codes.Unavailable:
+		return domain.ErrUnavailable
+	default:
+		log.Error("unable to reach the SFU", "err", err)
+		return err
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"vidcall/internal/signaling/domain"
 	mongox "vidcall/internal/signaling/infra"
+	"vidcall/internal/signaling/metrics"
 	mongorepo "vidcall/internal/signaling/repo"
 	"vidcall/internal/signaling/security"
 	"vidcall/pkg/logger"
@@ -29,12 +30,14 @@ func Auth(ctx context.Context, roomID string, pin string, name string) (string,
 
 	// Verify Pin
 	if ok := security.VerifyPin(pin, room.Pin); !ok {
+		metrics.AuthFailure("pin")
 		return "", domain.ErrBadPin
 	}
+	metrics.AuthSuccess("pin")
 
 	// JWT Token
 	issuer := security.IssuerFrom(ctx)
-	member_token, err := issuer.Issue(roomID, memberID, name, "guest")
+	member_token, err := issuer.Issue(ctx, roomID, memberID, name, "guest", security.Grant{Codecs: room.Codecs, E2EE: room.E2EE})
 	if err != nil {
 		log.Error("unable to tokenize")
 		return "", err
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/infra"
+	"vidcall/internal/signaling/metrics"
+	"vidcall/internal/signaling/repo"
+	"vidcall/internal/signaling/security"
+	"vidcall/pkg/logger"
+	"vidcall/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// remote peers the bot receives and mixes for the caller
+const PhoneSlots = 9
+
+// DialIn checks the room code and PIN a phone caller keyed in, and issues
+// the token of the bot bridging the call into the room
+func DialIn(ctx context.Context, dialIn string, pin string, name string) (string, error) {
+	log := logger.GetLog(ctx).With("layer", "service")
+
+	room, err := repo.GetRoomByDialIn(ctx, infra.DB(), dialIn)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", domain.ErrNotFound
+		}
+		return "", err
+	}
+
+	if ok := security.VerifyPin(pin, room.Pin); !ok {
+		metrics.AuthFailure("pin")
+		return "", domain.ErrBadPin
+	}
+	metrics.AuthSuccess("pin")
+
+	issuer := security.IssuerFrom(ctx)
+	token, err := issuer.Issue(ctx, room.RoomID, utils.GenerateMemeberID(), name, "bot", security.Grant{
+		Codecs: room.Codecs,
+		Slots:  PhoneSlots,
+	})
+	if err != nil {
+		log.Error("unable to tokenize")
+		return "", err
+	}
+
+	log.Info("phone caller dialed in", "roomID", room.RoomID)
+	return token, nil
+}
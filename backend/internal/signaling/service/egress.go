@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/security"
+	"vidcall/pkg/logger"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StartEgress streams the room to HLS on the SFU, and to rtmpURL when set
+func StartEgress(ctx context.Context, client sfu.SFUClient, roomID string, layout sfu.EgressLayout, targetID string, rtmpURL string) (*sfu.EgressInfo, error) {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	if !isHost(ctx, roomID) {
+		return nil, domain.ErrForbidden
+	}
+
+	info, err := client.StartEgress(ctx, &sfu.EgressRequest{
+		RoomID:   roomID,
+		Layout:   layout,
+		TargetID: targetID,
+		RtmpURL:  rtmpURL,
+	})
+	if err != nil {
+		return nil, egressError(log, err)
+	}
+
+	log.Info("egress started", "layout", layout.String())
+	return info, nil
+}
+
+func StopEgress(ctx context.Context, client sfu.SFUClient, roomID string) (*sfu.EgressInfo, error) {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	if !isHost(ctx, roomID) {
+		return nil, domain.ErrForbidden
+	}
+
+	info, err := client.StopEgress(ctx, &sfu.EgressRequest{RoomID: roomID})
+	if err != nil {
+		return nil, egressError(log, err)
+	}
+
+	log.Info("egress stopped")
+	return info, nil
+}
+
+func GetEgress(ctx context.Context, client sfu.SFUClient, roomID string) (*sfu.EgressInfo, error) {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	if !isHost(ctx, roomID) {
+		return nil, domain.ErrForbidden
+	}
+
+	info, err := client.GetEgress(ctx, &sfu.EgressRequest{RoomID: roomID})
+	if err != nil {
+		return nil, egressError(log, err)
+	}
+
+	return info, nil
+}
+
+// helper function to check the caller hosts the room
+func isHost(ctx context.Context, roomID string) bool {
+	claims := security.ClaimsFrom(ctx)
+	return claims != nil && claims.Role == "host" && claims.RoomID == roomID
+}
+
+// helper function to map an SFU status to a domain error
+func egressError(log *slog.Logger, err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		// the room is unknown to the SFU, or is not streamed
+		if status.Convert(err).Message() == domain.ErrNotFound.Error() {
+			return domain.ErrNotFound
+		}
+		return domain.ErrNoEgress
+	case codes.FailedPrecondition:
+		return domain.ErrRoomNotLive
+	case codes.AlreadyExists:
+		return domain.ErrEgressRunning
+	case codes.InvalidArgument:
+		return domain.ErrBadEgress
+	case codes.PermissionDenied:
+		return domain.ErrE2EE
+	default:
+		log.Error("unable to reach the SFU egress", "err", err)
+		return err
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/infra"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// longest a dependency may take to answer a readiness check
+const readyTimeout = 2 * time.Second
+
+// Ready checks the dependencies the signaling server needs to take calls,
+// by name. A nil error is healthy
+func Ready(ctx context.Context, sfuHealth healthpb.HealthClient) map[string]error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	return map[string]error{
+		"mongodb": pingMongo(ctx),
+		"sfu":     checkSFU(ctx, sfuHealth),
+	}
+}
+
+// helper function to ping MongoDB
+func pingMongo(ctx context.Context) error {
+	db := infra.DB()
+	if db == nil {
+		return errors.New("not connected")
+	}
+	return db.Client().Ping(ctx, nil)
+}
+
+// helper function to ask the SFU whether it serves, it does not while
+// draining or without Redis
+func checkSFU(ctx context.Context, sfuHealth healthpb.HealthClient) error {
+	res, err := sfuHealth.Check(ctx, &healthpb.HealthCheckRequest{Service: sfu.SFU_ServiceDesc.ServiceName})
+	if err != nil {
+		return err
+	}
+
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return errors.New(res.Status.String())
+	}
+	return nil
+}
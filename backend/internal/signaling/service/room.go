@@ -2,20 +2,31 @@ package service
 
 import (
 	"context"
+	"slices"
 	"time"
 
+	sfu "vidcall/api/proto"
 	"vidcall/internal/signaling/domain"
 	"vidcall/internal/signaling/infra"
 	"vidcall/internal/signaling/repo"
 	"vidcall/internal/signaling/security"
 	"vidcall/pkg/logger"
 	"vidcall/pkg/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-func NewRoom(ctx context.Context, duration time.Duration, name string) (*domain.Room, string, error) {
+func NewRoom(ctx context.Context, duration time.Duration, name string, codecs []string, e2ee bool) (*domain.Room, string, error) {
 
 	log := logger.GetLog(ctx).With("layer", "service")
 
+	for _, c := range codecs {
+		if !slices.Contains(domain.VideoCodecs, c) {
+			return nil, "", domain.ErrBadCodec
+		}
+	}
+
 	pin := security.GeneratePin(ctx)
 	roomID := utils.GenerateRoomID()
 	hostID := utils.GenerateHostID()
@@ -26,6 +37,13 @@ func NewRoom(ctx context.Context, duration time.Duration, name string) (*domain.
 		Pin:      security.PinHash(ctx, pin),
 		Date:     time.Now().UTC(),
 		Duration: duration,
+		Codecs:   codecs,
+		E2EE:     e2ee,
+	}
+
+	// phone callers would be bridged in plaintext
+	if !e2ee {
+		room.DialIn = utils.GenerateDialIn()
 	}
 
 	// Save room data
@@ -36,7 +54,7 @@ func NewRoom(ctx context.Context, duration time.Duration, name string) (*domain.
 
 	// Tokenize
 	issuer := security.IssuerFrom(ctx)
-	host_token, err := issuer.Issue(roomID, hostID, name, "host")
+	host_token, err := issuer.Issue(ctx, roomID, hostID, name, "host", security.Grant{Codecs: codecs, E2EE: e2ee})
 	if err != nil {
 		log.Error("unable to tokenize")
 		return nil, "", err
@@ -48,3 +66,43 @@ func NewRoom(ctx context.Context, duration time.Duration, name string) (*domain.
 
 	return &room, host_token, nil
 }
+
+// Kick revokes the member's token so it can not be used to rejoin, and
+// takes the member out of the call on the SFU
+func Kick(ctx context.Context, client sfu.SFUClient, roomID string, peerID string) error {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	claims := security.ClaimsFrom(ctx)
+	if claims == nil || claims.Role != "host" || claims.RoomID != roomID {
+		return domain.ErrForbidden
+	}
+
+	issuer := security.IssuerFrom(ctx)
+	if err := issuer.RevokePeer(ctx, roomID, peerID); err != nil {
+		log.Error("unable to revoke member token")
+		return err
+	}
+
+	// a member not connected right now only needed the revocation
+	_, err := client.KickPeer(ctx, &sfu.KickPeerRequest{RoomID: roomID, PeerID: peerID})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return adminError(log, err)
+	}
+
+	log.Info("kicked member", "peerID", peerID)
+	return nil
+}
+
+// codec policy of a room, empty when the room leaves it to the SFU, and
+// whether it is end-to-end encrypted
+func RoomPolicy(ctx context.Context, roomID string) ([]string, bool) {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	room, err := repo.GetRoomDoc(ctx, infra.DB(), roomID)
+	if err != nil {
+		log.Warn("unable to load room policy")
+		return nil, false
+	}
+
+	return room.Codecs, room.E2EE
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/infra"
+	"vidcall/internal/signaling/repo"
+	"vidcall/internal/signaling/security"
+)
+
+// Transcript returns the captions stored for a room, to any member of it,
+// during or after the meeting
+func Transcript(ctx context.Context, roomID string) ([]domain.Caption, error) {
+	claims := security.ClaimsFrom(ctx)
+	if claims == nil || claims.RoomID != roomID {
+		return nil, domain.ErrForbidden
+	}
+
+	return repo.GetTranscript(ctx, infra.DB(), roomID)
+}
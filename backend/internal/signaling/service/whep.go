@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/security"
+	"vidcall/pkg/logger"
+	"vidcall/pkg/utils"
+)
+
+// ViewToken issues the token of the room's WHEP viewers, it can be shared
+// as every playback gets its own session
+func ViewToken(ctx context.Context, roomID string) (string, error) {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	if !isHost(ctx, roomID) {
+		return "", domain.ErrForbidden
+	}
+
+	// viewers cannot take part in the key exchange
+	codecs, e2ee := RoomPolicy(ctx, roomID)
+	if e2ee {
+		return "", domain.ErrE2EE
+	}
+
+	issuer := security.IssuerFrom(ctx)
+	token, err := issuer.Issue(ctx, roomID, utils.GenerateMemeberID(), "Viewer", "viewer", security.Grant{Codecs: codecs, Scope: security.ScopeView})
+	if err != nil {
+		log.Error("unable to tokenize")
+		return "", err
+	}
+
+	log.Info("issued view token")
+	return token, nil
+}
+
+// Whep adds a receive-only viewer of the room, of one participant when
+// peerID is set, answering its offer with a complete SDP. The session is
+// ended with its secret
+func Whep(ctx context.Context, client sfu.SFUClient, roomID string, peerID string, offer string) (*sfu.WhepResponse, error) {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	claims := security.ClaimsFrom(ctx)
+	if claims == nil || claims.RoomID != roomID {
+		return nil, domain.ErrForbidden
+	}
+
+	res, err := client.Whep(PeerContext(ctx), &sfu.WhepRequest{Sdp: offer, PeerID: peerID})
+	if err != nil {
+		return nil, sessionError(log, err)
+	}
+
+	log.Info("whep session started", "sessionID", res.SessionID)
+	return res, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/security"
+	"vidcall/pkg/logger"
+	"vidcall/pkg/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// PeerContext carries the caller's token to the SFU in the gRPC metadata,
+// the identity and room policy are read from its claims
+func PeerContext(ctx context.Context) context.Context {
+	md := metadata.Pairs("token", security.TokenFrom(ctx))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// IngestToken issues the bearer token of an encoder publishing into the
+// room, it joins as a guest under the given name
+func IngestToken(ctx context.Context, roomID string, name string) (string, error) {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	if !isHost(ctx, roomID) {
+		return "", domain.ErrForbidden
+	}
+
+	// encoders cannot take part in the key exchange
+	codecs, e2ee := RoomPolicy(ctx, roomID)
+	if e2ee {
+		return "", domain.ErrE2EE
+	}
+
+	issuer := security.IssuerFrom(ctx)
+	token, err := issuer.Issue(ctx, roomID, utils.GenerateMemeberID(), name, "guest", security.Grant{Codecs: codecs, Scope: security.ScopeIngest})
+	if err != nil {
+		log.Error("unable to tokenize")
+		return "", err
+	}
+
+	log.Info("issued ingest token")
+	return token, nil
+}
+
+// Whip publishes the caller into the room, answering its offer with a
+// complete SDP. The session ID is the caller's peer ID
+func Whip(ctx context.Context, client sfu.SFUClient, roomID string, name string, offer string) (string, string, error) {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	claims := security.ClaimsFrom(ctx)
+	if claims == nil || claims.RoomID != roomID {
+		return "", "", domain.ErrForbidden
+	}
+
+	res, err := client.Whip(PeerContext(ctx), &sfu.WhipRequest{Sdp: offer, Name: name})
+	if err != nil {
+		return "", "", sessionError(log, err)
+	}
+
+	log.Info("whip session started", "peerID", claims.PeerID)
+	return res.Sdp, res.SessionID, nil
+}
+
+// EndSession ends a WHIP session, by its peer or a host of the room, or a
+// WHEP one with its secret
+func EndSession(ctx context.Context, client sfu.SFUClient, roomID string, sessionID string, secret string) error {
+	log := logger.GetLog(ctx).With("layer", "service", "roomID", roomID)
+
+	claims := security.ClaimsFrom(ctx)
+	if claims == nil || claims.RoomID != roomID {
+		return domain.ErrForbidden
+	}
+
+	_, err := client.EndSession(PeerContext(ctx), &sfu.SessionRequest{SessionID: sessionID, Secret: secret})
+	if err != nil {
+		return sessionError(log, err)
+	}
+
+	log.Info("session ended", "sessionID", sessionID)
+	return nil
+}
+
+// helper function to map an SFU status to a domain error
+func sessionError(log *slog.Logger, err error) error {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return domain.ErrBadOffer
+	case codes.FailedPrecondition:
+		return domain.ErrRoomNotLive
+	case codes.NotFound:
+		return domain.ErrNoSession
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return domain.ErrForbidden
+	case codes.Unavailable:
+		return domain.ErrUnavailable
+	default:
+		log.Error("unable to reach the SFU", "err", err)
+		return err
+	}
+}
@@ -1,49 +1,125 @@
 package signaling
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	sfu "vidcall/api/proto"
 	"vidcall/internal/signaling/infra"
+	"vidcall/internal/signaling/metrics"
 	"vidcall/internal/signaling/security"
 	"vidcall/internal/signaling/transport/httpx"
+	"vidcall/internal/signaling/transport/sipx"
 	"vidcall/internal/signaling/transport/wsx"
+	"vidcall/pkg/config"
+	"vidcall/pkg/jwtx"
 	"vidcall/pkg/logger"
+	"vidcall/pkg/tlsx"
+	"vidcall/pkg/tracing"
 
 	_ "github.com/joho/godotenv/autoload"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func Execute() {
+	cfg, err := config.LoadSignaling(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	issuer := security.NewIssuer(os.Getenv("JWT_SECRET"))
+	shutdown, err := tracing.Init(context.Background(), "vidcall-signaling", cfg.Tracing.Options())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
 
 	mux := http.NewServeMux()
 
 	// Fire up infra: MongoDB and Redis
-	infra.Init(os.Getenv("MONGODB_URI"), os.Getenv("DB_NAME"), 10)
+	infra.Init(cfg.Mongo.URI, cfg.Mongo.DB, cfg.Mongo.Pool)
+	infra.InitRedis(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+
+	keys, err := jwtx.Load(cfg.JWT.Keys())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	issuer := security.NewIssuer(keys, jwtx.NewDenylist(infra.Redis()), cfg.JWT.TTL)
 
 	// fire a gRPC connection between signaling and sfu
-	sfu_host := os.Getenv("SFU_HOST")
-	if sfu_host == "" {
-		sfu_host = "localhost" + os.Getenv("SFU_PORT")
+	creds := insecure.NewCredentials()
+	tlsFiles := cfg.TLS.Files()
+	if tlsFiles.Enabled() {
+		tlsConf, err := tlsx.ClientConfig(tlsFiles, cfg.TLSServerName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		creds = credentials.NewTLS(tlsConf)
+	} else {
+		log.Printf("GRPC_TLS_* are not set. Dialing SFU without TLS...")
 	}
 
-	sfuConn, err := grpc.Dial(sfu_host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	sfuConn, err := grpc.Dial(cfg.SFUAddr(),
+		grpc.WithTransportCredentials(creds),
+		// admin, egress and stats calls are made as the signaling server
+		grpc.WithPerRPCCredentials(security.NewServiceCredentials(keys)),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
 
 	sfuClient := sfu.NewSFUClient(sfuConn)
+	sfuHealth := healthpb.NewHealthClient(sfuConn)
+
+	// expose prometheus metrics, away from the public mux
+	if metricsPort := cfg.MetricsPort; metricsPort != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler())
+
+		go func() {
+			log.Println("Signaling metrics starting at port " + metricsPort)
+			if err := http.ListenAndServe(metricsPort, metricsMux); err != nil {
+				log.Printf("metrics server stopped: %v", err)
+			}
+		}()
+	}
+
+	// probes of the orchestrator
+	mux.HandleFunc("GET /healthz", httpx.HandleHealthz)
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleReadyz(w, r, sfuHealth)
+	})
+
+	// operator API, off unless ADMIN_TOKEN is set
+	if adminToken := cfg.AdminToken; adminToken != "" {
+		mux.HandleFunc("GET /api/admin/rooms", security.RequireAdmin(adminToken)(func(w http.ResponseWriter, r *http.Request) {
+			httpx.HandleAdminRooms(w, r, sfuClient)
+		}))
+		mux.HandleFunc("DELETE /api/admin/rooms/{room_id}", security.RequireAdmin(adminToken)(func(w http.ResponseWriter, r *http.Request) {
+			httpx.HandleAdminCloseRoom(w, r, sfuClient)
+		}))
+	}
 
 	// create new room and auth
 	mux.HandleFunc("GET /api/rooms/new/{duration}", security.WithIssuer(issuer)(httpx.HandleCreateRoom))
 	mux.HandleFunc("POST /api/rooms/{room_id}/auth", security.WithIssuer(issuer)(httpx.HandleAuth))
+	mux.HandleFunc("GET /.well-known/jwks.json", security.WithIssuer(issuer)(httpx.HandleJWKS))
 
 	// secured endpoints
 	mux.HandleFunc("GET /api/me", security.RequireAuth(issuer)(func(w http.ResponseWriter, r *http.Request) {
@@ -52,8 +128,57 @@ func Execute() {
 	mux.HandleFunc("GET /ws", security.RequireAuth(issuer)(func(w http.ResponseWriter, r *http.Request) {
 		wsx.HandleWS(w, r, sfuClient)
 	}))
+	mux.HandleFunc("DELETE /api/rooms/{room_id}/peers/{peer_id}", security.RequireAuth(issuer)(security.WithIssuer(issuer)(func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleKick(w, r, sfuClient)
+	})))
 
-	port := os.Getenv("SIGNALING_PORT")
+	// live stream of a room, host only
+	mux.HandleFunc("POST /api/rooms/{room_id}/egress", security.RequireAuth(issuer)(func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleStartEgress(w, r, sfuClient)
+	}))
+	mux.HandleFunc("GET /api/rooms/{room_id}/egress", security.RequireAuth(issuer)(func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleGetEgress(w, r, sfuClient)
+	}))
+	mux.HandleFunc("DELETE /api/rooms/{room_id}/egress", security.RequireAuth(issuer)(func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleStopEgress(w, r, sfuClient)
+	}))
+
+	// stored captions of a room, for its members
+	mux.HandleFunc("GET /api/rooms/{room_id}/transcript", security.RequireAuth(issuer)(httpx.HandleTranscript))
+
+	// WHIP ingest for encoders, authenticated with a bearer token from the
+	// host's ingest endpoint
+	mux.HandleFunc("POST /api/rooms/{room_id}/ingest", security.RequireAuth(issuer)(security.WithIssuer(issuer)(httpx.HandleIngestToken)))
+	mux.HandleFunc("POST /api/rooms/{room_id}/whip", security.RequireAuth(issuer, security.ScopeIngest)(func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleWhip(w, r, sfuClient)
+	}))
+	mux.HandleFunc("DELETE /api/rooms/{room_id}/whip/{session_id}", security.RequireAuth(issuer, security.ScopeIngest)(func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleEndWhip(w, r, sfuClient)
+	}))
+
+	// WHEP playback for viewers, authenticated with a view token from the
+	// host. Sessions end the same way as WHIP ones
+	mux.HandleFunc("POST /api/rooms/{room_id}/view", security.RequireAuth(issuer)(security.WithIssuer(issuer)(httpx.HandleViewToken)))
+	mux.HandleFunc("POST /api/rooms/{room_id}/whep", security.RequireAuth(issuer, security.ScopeView)(func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleWhep(w, r, sfuClient)
+	}))
+	mux.HandleFunc("DELETE /api/rooms/{room_id}/whep/{session_id}", security.RequireAuth(issuer, security.ScopeView)(func(w http.ResponseWriter, r *http.Request) {
+		httpx.HandleEndWhip(w, r, sfuClient)
+	}))
+
+	// SIP gateway for phone callers, off unless SIP_ADDR is set. Calls are
+	// hung up on shutdown
+	sipCtx, sipCancel := context.WithCancel(context.Background())
+	defer sipCancel()
+	if addr := cfg.SIP.Addr; addr != "" {
+		go func() {
+			if err := sipx.ListenAndServe(sipCtx, addr, cfg.SIP.PublicIP, issuer, sfuClient); err != nil {
+				log.Printf("SIP gateway stopped: %v", err)
+			}
+		}()
+	}
+
+	port := cfg.Port
 	log.Println("Signaling server starting at port " + port)
 
 	server := &http.Server{
@@ -65,14 +190,42 @@ func Execute() {
 	}
 
 	// Load TLS cert and key
-	cert := os.Getenv("TLS_CERT")
-	key := os.Getenv("TLS_KEY")
+	cert := cfg.TLSCert
+	key := cfg.TLSKey
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if cert == "" || key == "" {
+			log.Printf("TLS_CERT or TLS_KEY are not set. Serving HTTP...")
+			serveErr <- server.ListenAndServe()
+		} else {
+			serveErr <- server.ListenAndServeTLS(cert, key)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
 
-	if cert == "" || key == "" {
-		log.Printf("TLS_CERT or TLS_KEY are not set. Serving HTTP...")
-		log.Fatal(server.ListenAndServe())
-	} else {
-		log.Fatal(server.ListenAndServeTLS(cert, key))
+	// a second signal kills the process
+	stop()
+
+	drainTimeout := cfg.DrainTimeout
+	log.Printf("Signaling server draining, sessions close in %s", drainTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	// websockets are hijacked, Shutdown only waits for plain requests
+	if err := server.Shutdown(drainCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
 	}
+	wsx.Drain(drainCtx)
+	sipCancel()
 
+	log.Println("Signaling server stopped")
 }
@@ -0,0 +1,119 @@
+package httpx
+
+import (
+	"net/http"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/protocol"
+	"vidcall/internal/signaling/service"
+	"vidcall/pkg/utils"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type adminRoomResp struct {
+	RoomID    string          `json:"roomID"`
+	Live      bool            `json:"live"`
+	Encrypted bool            `json:"encrypted"`
+	Viewers   uint32          `json:"viewers"`
+	Peers     []adminPeerResp `json:"peers"`
+}
+
+type adminPeerResp struct {
+	PeerID      string           `json:"peerID"`
+	Name        string           `json:"name"`
+	Role        string           `json:"role"`
+	Audio       bool             `json:"audio"`
+	Video       bool             `json:"video"`
+	ScreenShare bool             `json:"screenShare"`
+	JoinedAt    int64            `json:"joinedAt,omitempty"`
+	PubState    string           `json:"pubState"`
+	SubState    string           `json:"subState"`
+	Tracks      []adminTrackResp `json:"tracks"`
+}
+
+type adminTrackResp struct {
+	Kind  string `json:"kind"`
+	Codec string `json:"codec"`
+	SSRC  uint32 `json:"ssrc"`
+}
+
+var roleTypes = sfu.RoleType(0).Descriptor()
+
+func HandleAdminRooms(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	rooms, err := service.ListRooms(r.Context(), client)
+	if !adminError(w, err) {
+		return
+	}
+
+	res := make([]adminRoomResp, 0, len(rooms))
+	for _, room := range rooms {
+		res = append(res, toAdminRoomResp(room))
+	}
+
+	utils.Respond(w, http.StatusOK, map[string]any{"rooms": res})
+}
+
+func HandleAdminCloseRoom(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	err := service.CloseRoom(r.Context(), client, r.PathValue("room_id"))
+	if !adminError(w, err) {
+		return
+	}
+
+	utils.Respond(w, http.StatusNoContent, nil)
+}
+
+// helper function to answer an admin error, false when one was sent
+func adminError(w http.ResponseWriter, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case domain.ErrNotFound:
+		utils.Error(w, http.StatusNotFound, "room not found")
+	case domain.ErrForbidden:
+		utils.Error(w, http.StatusBadRequest, "breakout rooms end with their main room")
+	case domain.ErrUnavailable:
+		w.Header().Set("Retry-After", "5")
+		utils.Error(w, http.StatusServiceUnavailable, "sfu unavailable, retry later")
+	default:
+		utils.Error(w, http.StatusBadGateway, "sfu unavailable")
+	}
+
+	return false
+}
+
+// helper function to convert a room of the SFU
+func toAdminRoomResp(room *sfu.RoomInfo) adminRoomResp {
+	res := adminRoomResp{
+		RoomID:    room.RoomID,
+		Live:      room.Live,
+		Encrypted: room.Encrypted,
+		Viewers:   room.Viewers,
+		Peers:     make([]adminPeerResp, 0, len(room.Peers)),
+	}
+
+	for _, p := range room.Peers {
+		st := p.GetState()
+		peer := adminPeerResp{
+			PeerID:      st.GetPeerID(),
+			Name:        st.GetName(),
+			Role:        protocol.EnumName(roleTypes, protoreflect.EnumNumber(st.GetRole())),
+			Audio:       st.GetAudio(),
+			Video:       st.GetVideo(),
+			ScreenShare: st.GetScreenShare(),
+			JoinedAt:    st.GetJoinedAt(),
+			PubState:    p.PubState,
+			SubState:    p.SubState,
+			Tracks:      make([]adminTrackResp, 0, len(p.Tracks)),
+		}
+
+		for _, t := range p.Tracks {
+			peer.Tracks = append(peer.Tracks, adminTrackResp{Kind: t.Kind, Codec: t.Codec, SSRC: t.Ssrc})
+		}
+
+		res.Peers = append(res.Peers, peer)
+	}
+
+	return res
+}
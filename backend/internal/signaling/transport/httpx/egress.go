@@ -0,0 +1,120 @@
+package httpx
+
+import (
+	"net/http"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/protocol"
+	"vidcall/internal/signaling/service"
+	"vidcall/pkg/logger"
+	"vidcall/pkg/utils"
+)
+
+type egressResp struct {
+	RoomID    string   `json:"roomID"`
+	Layout    string   `json:"layout"`
+	Active    bool     `json:"active"`
+	Playlist  string   `json:"playlist"`
+	StartedAt int64    `json:"startedAt,omitempty"`
+	Peers     []string `json:"peers"`
+	RTMP      bool     `json:"rtmp"`
+}
+
+var egressLayouts = sfu.EgressLayout(0).Descriptor()
+
+func HandleStartEgress(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	var req struct {
+		Layout   string `json:"layout"`
+		TargetID string `json:"targetID"`
+		RTMPURL  string `json:"rtmpURL"`
+	}
+
+	ctx := r.Context()
+	log := logger.GetLog(ctx).With("layer", "transport")
+
+	if err := utils.Decode(r, &req); err != nil {
+		log.Warn("unable to decode request payload")
+		utils.Error(w, http.StatusBadRequest, "invalid payload format")
+		return
+	}
+
+	// grid unless told otherwise
+	layout := sfu.EgressLayout_GRID
+	if req.Layout != "" {
+		n, ok := protocol.ParseEnum(egressLayouts, req.Layout)
+		if !ok {
+			utils.Error(w, http.StatusBadRequest, "unknown layout")
+			return
+		}
+		layout = sfu.EgressLayout(n)
+	}
+
+	info, err := service.StartEgress(ctx, client, r.PathValue("room_id"), layout, req.TargetID, req.RTMPURL)
+	if !egressError(w, err) {
+		return
+	}
+
+	utils.Respond(w, http.StatusCreated, toEgressResp(info))
+}
+
+func HandleStopEgress(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	info, err := service.StopEgress(r.Context(), client, r.PathValue("room_id"))
+	if !egressError(w, err) {
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, toEgressResp(info))
+}
+
+func HandleGetEgress(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	info, err := service.GetEgress(r.Context(), client, r.PathValue("room_id"))
+	if !egressError(w, err) {
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, toEgressResp(info))
+}
+
+// helper function to answer an egress error, false when one was sent
+func egressError(w http.ResponseWriter, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case domain.ErrForbidden:
+		utils.Error(w, http.StatusForbidden, "forbidden")
+	case domain.ErrNotFound:
+		utils.Error(w, http.StatusNotFound, "room not found")
+	case domain.ErrNoEgress:
+		utils.Error(w, http.StatusNotFound, "room is not streamed")
+	case domain.ErrRoomNotLive:
+		utils.Error(w, http.StatusConflict, "room is not live")
+	case domain.ErrEgressRunning:
+		utils.Error(w, http.StatusConflict, "room is already streamed")
+	case domain.ErrBadEgress:
+		utils.Error(w, http.StatusBadRequest, "invalid egress request")
+	case domain.ErrE2EE:
+		utils.Error(w, http.StatusConflict, "room is end-to-end encrypted")
+	default:
+		utils.Error(w, http.StatusBadGateway, "sfu unavailable")
+	}
+
+	return false
+}
+
+func toEgressResp(info *sfu.EgressInfo) *egressResp {
+	peers := info.Peers
+	if peers == nil {
+		peers = []string{}
+	}
+
+	return &egressResp{
+		RoomID:    info.RoomID,
+		Layout:    protocol.EnumName(egressLayouts, info.Layout.Number()),
+		Active:    info.Active,
+		Playlist:  info.Playlist,
+		StartedAt: info.StartedAt,
+		Peers:     peers,
+		RTMP:      info.Rtmp,
+	}
+}
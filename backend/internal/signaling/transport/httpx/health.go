@@ -0,0 +1,38 @@
+package httpx
+
+import (
+	"net/http"
+
+	"vidcall/internal/signaling/service"
+	"vidcall/pkg/utils"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// liveness, the process serves requests
+func HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	utils.Respond(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readiness, MongoDB and the SFU answer
+func HandleReadyz(w http.ResponseWriter, r *http.Request, sfuHealth healthpb.HealthClient) {
+	type resp struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+
+	res := &resp{Status: "ok", Checks: make(map[string]string)}
+	code := http.StatusOK
+
+	for name, err := range service.Ready(r.Context(), sfuHealth) {
+		if err != nil {
+			res.Status = "unavailable"
+			res.Checks[name] = err.Error()
+			code = http.StatusServiceUnavailable
+			continue
+		}
+		res.Checks[name] = "ok"
+	}
+
+	utils.Respond(w, code, res)
+}
@@ -2,8 +2,10 @@ package httpx
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
+	sfu "vidcall/api/proto"
 	"vidcall/internal/signaling/domain"
 	"vidcall/internal/signaling/security"
 	"vidcall/internal/signaling/service"
@@ -14,8 +16,11 @@ import (
 func HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 
 	type resp struct {
-		RoomID string `json:"roomID"`
-		Pin    string `json:"pin"`
+		RoomID string   `json:"roomID"`
+		Pin    string   `json:"pin"`
+		Codecs []string `json:"codecs,omitempty"`
+		DialIn string   `json:"dialIn,omitempty"`
+		E2EE   bool     `json:"e2ee"`
 	}
 
 	ctx := r.Context()
@@ -30,8 +35,22 @@ func HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	room, host_token, err := service.NewRoom(ctx, duration, name)
-	if err != nil {
+	// optional codec policy, e.g. ?codecs=vp9,vp8
+	var codecs []string
+	if raw := r.URL.Query().Get("codecs"); raw != "" {
+		codecs = strings.Split(strings.ToLower(raw), ",")
+	}
+
+	// clients encrypt their frames, e.g. ?e2ee=true
+	e2ee := r.URL.Query().Get("e2ee") == "true"
+
+	room, host_token, err := service.NewRoom(ctx, duration, name, codecs, e2ee)
+	switch err {
+	case nil:
+	case domain.ErrBadCodec:
+		utils.Error(w, http.StatusBadRequest, "unsupported codec")
+		return
+	default:
 		utils.Error(w, http.StatusInternalServerError, "internal error")
 		return
 	}
@@ -42,6 +61,9 @@ func HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 		&resp{
 			RoomID: room.RoomID,
 			Pin:    room.Pin,
+			Codecs: room.Codecs,
+			DialIn: room.DialIn,
+			E2EE:   room.E2EE,
 		})
 }
 
@@ -99,3 +121,24 @@ func HandleClaims(w http.ResponseWriter, r *http.Request) {
 			Role:   claims.Role,
 		})
 }
+
+func HandleKick(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	ctx := r.Context()
+
+	err := service.Kick(ctx, client, r.PathValue("room_id"), r.PathValue("peer_id"))
+	switch err {
+	case nil:
+		utils.Respond(w, http.StatusNoContent, nil)
+	case domain.ErrForbidden:
+		utils.Error(w, http.StatusForbidden, "forbidden")
+	case domain.ErrUnavailable:
+		utils.Error(w, http.StatusServiceUnavailable, "sfu unavailable")
+	default:
+		utils.Error(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	issuer := security.IssuerFrom(r.Context())
+	utils.Respond(w, http.StatusOK, issuer.JWKS())
+}
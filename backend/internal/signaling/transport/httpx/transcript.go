@@ -0,0 +1,50 @@
+package httpx
+
+import (
+	"net/http"
+
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/service"
+	"vidcall/pkg/utils"
+)
+
+type captionResp struct {
+	PeerID  string `json:"peerID"`
+	Name    string `json:"name"`
+	StartAt int64  `json:"startAt"`
+	EndAt   int64  `json:"endAt"`
+	Text    string `json:"text"`
+}
+
+func HandleTranscript(w http.ResponseWriter, r *http.Request) {
+	type resp struct {
+		RoomID   string        `json:"roomID"`
+		Captions []captionResp `json:"captions"`
+	}
+
+	roomID := r.PathValue("room_id")
+
+	captions, err := service.Transcript(r.Context(), roomID)
+	switch err {
+	case nil:
+	case domain.ErrForbidden:
+		utils.Error(w, http.StatusForbidden, "forbidden")
+		return
+	default:
+		utils.Error(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	res := &resp{RoomID: roomID, Captions: make([]captionResp, 0, len(captions))}
+	for _, c := range captions {
+		res.Captions = append(res.Captions, captionResp{
+			PeerID:  c.PeerID,
+			Name:    c.Name,
+			StartAt: c.Start.UnixMilli(),
+			EndAt:   c.End.UnixMilli(),
+			Text:    c.Text,
+		})
+	}
+
+	utils.Respond(w, http.StatusOK, res)
+}
@@ -0,0 +1,57 @@
+package httpx
+
+import (
+	"net/http"
+	"net/url"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/service"
+	"vidcall/pkg/utils"
+)
+
+func HandleViewToken(w http.ResponseWriter, r *http.Request) {
+	type resp struct {
+		Token   string `json:"token"`
+		WhepURL string `json:"whepURL"`
+	}
+
+	roomID := r.PathValue("room_id")
+	token, err := service.ViewToken(r.Context(), roomID)
+	switch err {
+	case nil:
+	case domain.ErrForbidden:
+		utils.Error(w, http.StatusForbidden, "forbidden")
+		return
+	case domain.ErrE2EE:
+		utils.Error(w, http.StatusConflict, "room is end-to-end encrypted")
+		return
+	default:
+		utils.Error(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.Respond(w, http.StatusCreated, &resp{
+		Token:   token,
+		WhepURL: "/api/rooms/" + roomID + "/whep",
+	})
+}
+
+// WHEP: like WHIP the other way round, the offer receives the room, or
+// only the participant given in ?peer=
+func HandleWhep(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	offer, ok := readOffer(w, r)
+	if !ok {
+		return
+	}
+
+	roomID := r.PathValue("room_id")
+	res, err := service.Whep(r.Context(), client, roomID, r.URL.Query().Get("peer"), offer)
+	if !sessionError(w, err) {
+		return
+	}
+
+	// the player deletes the session URL as is, the secret goes with it
+	location := "/api/rooms/" + roomID + "/whep/" + res.SessionID + "?" + url.Values{"secret": {res.Secret}}.Encode()
+	writeAnswer(w, location, res.Sdp)
+}
@@ -0,0 +1,139 @@
+package httpx
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/service"
+	"vidcall/pkg/logger"
+	"vidcall/pkg/utils"
+)
+
+// SDP offers are a few KB, this leaves room for many candidates
+const maxOfferSize = 64 << 10
+
+func HandleIngestToken(w http.ResponseWriter, r *http.Request) {
+	type resp struct {
+		Token   string `json:"token"`
+		WhipURL string `json:"whipURL"`
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+
+	ctx := r.Context()
+	log := logger.GetLog(ctx).With("layer", "transport")
+
+	if err := utils.Decode(r, &req); err != nil && err != io.EOF {
+		log.Warn("unable to decode request payload")
+		utils.Error(w, http.StatusBadRequest, "invalid payload format")
+		return
+	}
+
+	if req.Name == "" {
+		req.Name = "Stream"
+	}
+
+	roomID := r.PathValue("room_id")
+	token, err := service.IngestToken(ctx, roomID, req.Name)
+	switch err {
+	case nil:
+	case domain.ErrForbidden:
+		utils.Error(w, http.StatusForbidden, "forbidden")
+		return
+	case domain.ErrE2EE:
+		utils.Error(w, http.StatusConflict, "room is end-to-end encrypted")
+		return
+	default:
+		utils.Error(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.Respond(w, http.StatusCreated, &resp{
+		Token:   token,
+		WhipURL: "/api/rooms/" + roomID + "/whip",
+	})
+}
+
+// WHIP (RFC 9725): the offer is the body, the answer comes back with the
+// session URL in Location. Candidates are all in the answer, trickle ICE
+// is not supported
+func HandleWhip(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	offer, ok := readOffer(w, r)
+	if !ok {
+		return
+	}
+
+	roomID := r.PathValue("room_id")
+	answer, sessionID, err := service.Whip(r.Context(), client, roomID, r.URL.Query().Get("name"), offer)
+	if !sessionError(w, err) {
+		return
+	}
+
+	writeAnswer(w, "/api/rooms/"+roomID+"/whip/"+sessionID, answer)
+}
+
+func HandleEndWhip(w http.ResponseWriter, r *http.Request, client sfu.SFUClient) {
+	err := service.EndSession(r.Context(), client, r.PathValue("room_id"), r.PathValue("session_id"), r.URL.Query().Get("secret"))
+	if !sessionError(w, err) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// helper function to read the SDP offer body, false when an error was sent
+func readOffer(w http.ResponseWriter, r *http.Request) (string, bool) {
+	log := logger.GetLog(r.Context()).With("layer", "transport")
+
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mt != "application/sdp" {
+		utils.Error(w, http.StatusUnsupportedMediaType, "expected application/sdp")
+		return "", false
+	}
+
+	offer, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOfferSize))
+	if err != nil || len(offer) == 0 {
+		log.Warn("unable to read offer")
+		utils.Error(w, http.StatusBadRequest, "invalid offer")
+		return "", false
+	}
+
+	return string(offer), true
+}
+
+// helper function to send the SDP answer of a new session
+func writeAnswer(w http.ResponseWriter, location string, answer string) {
+	w.Header().Set("Content-Type", "application/sdp")
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.WriteString(w, answer)
+}
+
+// helper function to answer a session error, false when one was sent
+func sessionError(w http.ResponseWriter, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case domain.ErrForbidden:
+		utils.Error(w, http.StatusForbidden, "forbidden")
+	case domain.ErrBadOffer:
+		utils.Error(w, http.StatusBadRequest, "offer has no codec allowed in the room")
+	case domain.ErrRoomNotLive:
+		utils.Error(w, http.StatusConflict, "room is not live")
+	case domain.ErrNoSession:
+		utils.Error(w, http.StatusNotFound, "session not found")
+	case domain.ErrUnavailable:
+		// the SFU is draining or restarting
+		w.Header().Set("Retry-After", "5")
+		utils.Error(w, http.StatusServiceUnavailable, "sfu unavailable, retry later")
+	default:
+		utils.Error(w, http.StatusBadGateway, "sfu unavailable")
+	}
+
+	return false
+}
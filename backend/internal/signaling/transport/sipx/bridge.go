@@ -0,0 +1,399 @@
+package sipx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/service"
+	"vidcall/pkg/audio"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	// time the SFU gets to remove the peer after LEAVE
+	leaveTimeout = 2 * time.Second
+)
+
+var errRoomEnded = errors.New("room ended")
+
+// bot peer on the SFU carrying one phone call: the caller's G.711 is
+// published as is, the room's audio is decoded and mixed for the caller
+type bridge struct {
+	stream sfu.SFU_SignalClient
+	sendMu sync.Mutex
+	// the stream outlives the call by the LEAVE, a peer that just drops
+	// its stream stays in the room
+	cancel context.CancelFunc
+
+	pub   *webrtc.PeerConnection
+	sub   *webrtc.PeerConnection
+	track *webrtc.TrackLocalStaticRTP
+	mixer *audio.Mixer
+	media *media
+	log   *slog.Logger
+
+	// remote candidates wait for the remote description
+	iceMu      sync.Mutex
+	pendingIce map[sfu.PcType][]webrtc.ICECandidateInit
+
+	joined    bool
+	waiting   bool
+	published bool
+}
+
+// ctx carries the bot's claims, see security.ClaimsContext
+func newBridge(ctx context.Context, client sfu.SFUClient, m *media, log *slog.Logger) (*bridge, error) {
+	api, err := newAPI()
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := api.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := api.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		_ = pub.Close()
+		return nil, err
+	}
+
+	b := &bridge{
+		pub:        pub,
+		sub:        sub,
+		mixer:      audio.NewMixer(frameLength),
+		media:      m,
+		log:        log,
+		pendingIce: make(map[sfu.PcType][]webrtc.ICECandidateInit),
+	}
+
+	b.track, err = webrtc.NewTrackLocalStaticRTP(
+		webrtc.RTPCodecCapability{MimeType: m.offer.mime, ClockRate: sampleRate},
+		"audio", "phone",
+	)
+	if err != nil {
+		b.close()
+		return nil, err
+	}
+
+	if _, err := pub.AddTransceiverFromTrack(b.track, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly}); err != nil {
+		b.close()
+		return nil, err
+	}
+
+	pub.OnICECandidate(func(c *webrtc.ICECandidate) { b.sendIce(c, sfu.PcType_PUB) })
+	sub.OnICECandidate(func(c *webrtc.ICECandidate) { b.sendIce(c, sfu.PcType_SUB) })
+	sub.OnTrack(b.handleTrack)
+
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	b.cancel = cancel
+
+	b.stream, err = client.Signal(service.PeerContext(streamCtx))
+	if err != nil {
+		b.close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// audio codecs only, video m-lines of the SFU are rejected
+func newAPI() (*webrtc.API, error) {
+	m := &webrtc.MediaEngine{}
+
+	codecs := []webrtc.RTPCodecParameters{
+		{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2}, PayloadType: 111},
+		{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMU, ClockRate: sampleRate}, PayloadType: 0},
+		{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMA, ClockRate: sampleRate}, PayloadType: 8},
+	}
+	for _, c := range codecs {
+		if err := m.RegisterCodec(c, webrtc.RTPCodecTypeAudio); err != nil {
+			return nil, err
+		}
+	}
+
+	i := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(m, i); err != nil {
+		return nil, err
+	}
+
+	return webrtc.NewAPI(webrtc.WithMediaEngine(m), webrtc.WithInterceptorRegistry(i)), nil
+}
+
+// join the room and carry the call until the room ends or ctx is done
+func (b *bridge) run(ctx context.Context) error {
+	defer b.close()
+
+	b.media.bridge(b.mix, b.forward)
+	defer b.media.bridge(nil, nil)
+
+	if err := b.action(sfu.ActionType_JOIN); err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = b.action(sfu.ActionType_LEAVE)
+		_ = b.stream.CloseSend()
+		time.AfterFunc(leaveTimeout, b.cancel)
+	}()
+
+	for {
+		msg, err := b.stream.Recv()
+		if err == io.EOF || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch pl := msg.Payload.(type) {
+		case *sfu.PeerSignal_Sdp:
+			err = b.handleSdp(pl.Sdp)
+		case *sfu.PeerSignal_Ice:
+			err = b.handleIce(pl.Ice)
+		case *sfu.PeerSignal_Event:
+			err = b.handleEvent(pl.Event)
+		case *sfu.PeerSignal_Error:
+			b.log.Warn("sfu refused action", "code", pl.Error.Code.String(), "msg", pl.Error.Message)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (b *bridge) handleEvent(evt *sfu.Event) error {
+	switch evt.Type {
+	case sfu.EventType_ROOM_INACTIVE:
+		// in the lobby until the host starts the room
+		b.waiting = true
+		b.log.Info("waiting for the host")
+
+	case sfu.EventType_ROOM_ACTIVE:
+		if b.waiting {
+			b.waiting = false
+			return b.action(sfu.ActionType_JOIN)
+		}
+
+	case sfu.EventType_ROOM_STATE:
+		if !b.joined {
+			b.joined = true
+			b.log.Info("phone joined room")
+			// video is never looked at
+			if err := b.action(sfu.ActionType_AUDIO_ONLY_ON); err != nil {
+				return err
+			}
+		}
+
+		if !b.published {
+			b.published = true
+			return b.offer()
+		}
+
+	case sfu.EventType_ROOM_ENDED:
+		return errRoomEnded
+	}
+
+	return nil
+}
+
+// publish the caller's audio
+func (b *bridge) offer() error {
+	offer, err := b.pub.CreateOffer(nil)
+	if err != nil {
+		return err
+	}
+
+	if err := b.pub.SetLocalDescription(offer); err != nil {
+		return err
+	}
+
+	return b.send(&sfu.PeerSignal{Payload: &sfu.PeerSignal_Sdp{
+		Sdp: &sfu.Sdp{Pc: sfu.PcType_PUB, Type: sfu.SdpType_OFFER, Sdp: offer.SDP},
+	}})
+}
+
+func (b *bridge) handleSdp(desc *sfu.Sdp) error {
+	switch {
+	case desc.Pc == sfu.PcType_PUB && desc.Type == sfu.SdpType_ANSWER:
+		if err := b.pub.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: desc.Sdp}); err != nil {
+			return err
+		}
+		return b.flushIce(sfu.PcType_PUB)
+
+	case desc.Pc == sfu.PcType_SUB && desc.Type == sfu.SdpType_OFFER:
+		if err := b.sub.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: desc.Sdp}); err != nil {
+			return err
+		}
+
+		answer, err := b.sub.CreateAnswer(nil)
+		if err != nil {
+			return err
+		}
+		if err := b.sub.SetLocalDescription(answer); err != nil {
+			return err
+		}
+
+		if err := b.flushIce(sfu.PcType_SUB); err != nil {
+			return err
+		}
+
+		return b.send(&sfu.PeerSignal{Payload: &sfu.PeerSignal_Sdp{
+			Sdp: &sfu.Sdp{Pc: sfu.PcType_SUB, Type: sfu.SdpType_ANSWER, Sdp: answer.SDP},
+		}})
+	}
+
+	return nil
+}
+
+func (b *bridge) handleIce(ice *sfu.IceCandidate) error {
+	mline := uint16(ice.SdpMlineIndex)
+	init := webrtc.ICECandidateInit{
+		Candidate:        ice.Candidate,
+		SDPMid:           &ice.SdpMid,
+		SDPMLineIndex:    &mline,
+		UsernameFragment: &ice.UsernameFragment,
+	}
+
+	pc := b.pc(ice.Pc)
+	if pc == nil {
+		return nil
+	}
+
+	b.iceMu.Lock()
+	defer b.iceMu.Unlock()
+
+	if pc.RemoteDescription() == nil {
+		b.pendingIce[ice.Pc] = append(b.pendingIce[ice.Pc], init)
+		return nil
+	}
+
+	return pc.AddICECandidate(init)
+}
+
+func (b *bridge) flushIce(pcType sfu.PcType) error {
+	b.iceMu.Lock()
+	defer b.iceMu.Unlock()
+
+	pc := b.pc(pcType)
+	for _, init := range b.pendingIce[pcType] {
+		if err := pc.AddICECandidate(init); err != nil {
+			return err
+		}
+	}
+	delete(b.pendingIce, pcType)
+
+	return nil
+}
+
+func (b *bridge) pc(pcType sfu.PcType) *webrtc.PeerConnection {
+	switch pcType {
+	case sfu.PcType_PUB:
+		return b.pub
+	case sfu.PcType_SUB:
+		return b.sub
+	default:
+		return nil
+	}
+}
+
+func (b *bridge) sendIce(c *webrtc.ICECandidate, pcType sfu.PcType) {
+	if c == nil {
+		return
+	}
+
+	init := c.ToJSON()
+	ice := &sfu.IceCandidate{Pc: pcType, Candidate: init.Candidate}
+	if init.SDPMid != nil {
+		ice.SdpMid = *init.SDPMid
+	}
+	if init.SDPMLineIndex != nil {
+		ice.SdpMlineIndex = uint32(*init.SDPMLineIndex)
+	}
+
+	if err := b.send(&sfu.PeerSignal{Payload: &sfu.PeerSignal_Ice{Ice: ice}}); err != nil {
+		b.log.Warn("unable to send ice candidate", "err", err)
+	}
+}
+
+func (b *bridge) action(t sfu.ActionType) error {
+	return b.send(&sfu.PeerSignal{Payload: &sfu.PeerSignal_Action{
+		Action: &sfu.Action{Type: t},
+	}})
+}
+
+// gRPC streams take one sender at a time
+func (b *bridge) send(msg *sfu.PeerSignal) error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	return b.stream.Send(msg)
+}
+
+// decode a remote peer's audio into the mix, the SFU reuses the track
+// when the slot changes hands
+func (b *bridge) handleTrack(remote *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+	if remote.Kind() != webrtc.RTPCodecTypeAudio {
+		return
+	}
+
+	mime := remote.Codec().MimeType
+	dec, err := audio.NewDecoder(mime, sampleRate)
+	if err != nil {
+		b.log.Warn("cannot decode room audio", "codec", mime)
+		// keep reading so the receiver is drained
+		dec = nil
+	}
+
+	// one track per slot of the bot
+	id := strconv.FormatUint(uint64(remote.SSRC()), 10)
+	defer b.mixer.Remove(id)
+
+	for {
+		pkt, _, err := remote.ReadRTP()
+		if err != nil {
+			return
+		}
+
+		if dec == nil || len(pkt.Payload) == 0 {
+			continue
+		}
+
+		pcm, err := dec.Decode(pkt.Payload)
+		if err != nil {
+			continue
+		}
+		b.mixer.Push(id, pcm)
+	}
+}
+
+// one frame of the room for the caller
+func (b *bridge) mix() []int16 {
+	return audio.Sum(b.mixer.Next(), "", frameLength)
+}
+
+// the caller's packets go out unchanged, the track rewrites SSRC and
+// payload type
+func (b *bridge) forward(pkt *rtp.Packet) {
+	if err := b.track.WriteRTP(pkt); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		b.log.Warn("unable to forward phone audio", "err", err)
+	}
+}
+
+func (b *bridge) close() {
+	if b.cancel != nil {
+		time.AfterFunc(leaveTimeout, b.cancel)
+	}
+	_ = b.pub.Close()
+	_ = b.sub.Close()
+}
@@ -0,0 +1,407 @@
+package sipx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"vidcall/internal/signaling/domain"
+	"vidcall/internal/signaling/security"
+	"vidcall/internal/signaling/service"
+	"vidcall/pkg/audio"
+)
+
+const (
+	maxAttempts  = 3
+	maxDigits    = 16
+	digitTimeout = 15 * time.Second
+)
+
+var (
+	errNoDigits     = errors.New("no digits entered")
+	errTooManyTries = errors.New("too many wrong entries")
+)
+
+// prompts are tones, there are no recorded voices: one beep asks for the
+// room code, two for the PIN, both ended with #
+var (
+	promptCode = audio.Tone(sampleRate, 440, 300*time.Millisecond)
+	promptPin  = slices.Concat(
+		audio.Tone(sampleRate, 440, 200*time.Millisecond), audio.Silence(sampleRate, 150*time.Millisecond),
+		audio.Tone(sampleRate, 440, 200*time.Millisecond),
+	)
+	toneWrong = slices.Concat(
+		audio.Tone(sampleRate, 300, 150*time.Millisecond), audio.Silence(sampleRate, 100*time.Millisecond),
+		audio.Tone(sampleRate, 300, 150*time.Millisecond), audio.Silence(sampleRate, 100*time.Millisecond),
+		audio.Tone(sampleRate, 300, 150*time.Millisecond), audio.Silence(sampleRate, 500*time.Millisecond),
+	)
+	toneJoined = slices.Concat(
+		audio.Tone(sampleRate, 660, 150*time.Millisecond),
+		audio.Tone(sampleRate, 880, 250*time.Millisecond),
+	)
+)
+
+// one answered INVITE dialog
+type call struct {
+	srv      *Server
+	id       string
+	remote   *net.UDPAddr
+	invite   *message
+	localTag string
+	media    *media
+	log      *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+
+	mu sync.Mutex
+	// CSeq of the INVITE being answered, its 200 is sent until the ACK
+	inviteCSeq uint32
+	ok         *message
+	acked      chan struct{}
+	byeDone    chan struct{}
+}
+
+func newCall(s *Server, req *message, from *net.UDPAddr, m *media) *call {
+	ctx, cancel := context.WithCancel(context.Background())
+	n, _ := req.cseq()
+
+	return &call{
+		srv:        s,
+		id:         req.get("Call-ID"),
+		remote:     from,
+		invite:     req,
+		localTag:   randomToken(8),
+		media:      m,
+		log:        s.log.With("call ID", req.get("Call-ID"), "from", uriUser(addrURI(req.get("From")))),
+		ctx:        ctx,
+		cancel:     cancel,
+		inviteCSeq: n,
+		byeDone:    make(chan struct{}),
+	}
+}
+
+// 200 OK with the SDP answer, sent until it is acknowledged
+func (c *call) answer() {
+	res := newResponse(c.invite, 200, "OK")
+	res.set("To", c.localURI())
+	res.add("Contact", c.contact())
+	res.add("Allow", allowed)
+	res.add("Content-Type", "application/sdp")
+	res.body = c.media.answer()
+
+	acked := make(chan struct{})
+
+	c.mu.Lock()
+	c.ok = res
+	c.acked = acked
+	c.mu.Unlock()
+
+	go c.retransmit(res, acked)
+}
+
+// the 200 goes again after T1, doubling up to T2, until the ACK; the call
+// is over when none comes
+func (c *call) retransmit(res *message, acked chan struct{}) {
+	c.srv.send(res, c.remote)
+
+	interval := timerT1
+	deadline := time.After(64 * timerT1)
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-acked:
+			return
+		case <-deadline:
+			c.log.Warn("answer was never acknowledged")
+			c.end(true)
+			return
+		case <-time.After(interval):
+			c.srv.send(res, c.remote)
+			interval = min(2*interval, timerT2)
+		}
+	}
+}
+
+func (c *call) ack() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.acked:
+	default:
+		close(c.acked)
+	}
+}
+
+// a retransmitted INVITE gets the same answer, a re-INVITE may move the
+// phone's media but the codec stays
+func (c *call) reinvite(req *message, from *net.UDPAddr) {
+	n, _ := req.cseq()
+
+	c.mu.Lock()
+	current, ok := c.inviteCSeq, c.ok
+	c.mu.Unlock()
+
+	if n == current {
+		c.srv.send(ok, from)
+		return
+	}
+
+	if len(req.body) > 0 {
+		o, err := negotiate(req.body)
+		if err != nil || o.mime != c.media.offer.mime {
+			c.srv.send(newResponse(req, 488, "Not Acceptable Here"), from)
+			return
+		}
+		c.media.update(o)
+	}
+
+	c.mu.Lock()
+	c.inviteCSeq = n
+	c.invite = req
+	c.remote = from
+	c.mu.Unlock()
+
+	c.answer()
+}
+
+// DTMF sent as SIP INFO instead of RTP events
+func (c *call) info(req *message) {
+	body := strings.TrimSpace(string(req.body))
+
+	switch strings.ToLower(req.get("Content-Type")) {
+	case "application/dtmf-relay":
+		for _, line := range strings.Split(body, "\n") {
+			if k, v, ok := strings.Cut(line, "="); ok && strings.EqualFold(strings.TrimSpace(k), "signal") {
+				body = strings.TrimSpace(v)
+				break
+			}
+		}
+	case "application/dtmf":
+	default:
+		return
+	}
+
+	if len(body) == 1 && strings.ContainsAny(body, "0123456789*#") {
+		c.media.digit(body[0])
+	}
+}
+
+func (c *call) run() {
+	defer c.end(true)
+	go c.media.run(c.ctx)
+
+	token, err := c.authenticate()
+	if err != nil {
+		c.log.Info("caller not admitted", "err", err)
+		// let the last tone play out
+		c.media.flush(c.ctx)
+		return
+	}
+
+	claims, err := c.srv.issuer.Parse(token)
+	if err != nil {
+		c.log.Error("unable to parse bot token", "err", err)
+		return
+	}
+
+	log := c.log.With("room ID", claims.RoomID, "peer ID", claims.PeerID)
+
+	b, err := newBridge(security.ClaimsContext(c.ctx, claims, token), c.srv.client, c.media, log)
+	if err != nil {
+		log.Error("unable to reach the SFU", "err", err)
+		return
+	}
+
+	c.media.play(toneJoined)
+
+	switch err := b.run(c.ctx); err {
+	case nil:
+	case errRoomEnded:
+		log.Info("room ended, hanging up")
+	default:
+		log.Warn("bridge failed", "err", err)
+	}
+}
+
+// room code and PIN, keyed in by the caller
+func (c *call) authenticate() (string, error) {
+	ctx := security.IssuerContext(c.ctx, c.srv.issuer)
+	name := callerName(c.invite)
+
+	for attempt := range maxAttempts {
+		c.media.play(promptCode)
+		code, err := c.collect()
+		if err != nil {
+			return "", err
+		}
+
+		c.media.play(promptPin)
+		pin, err := c.collect()
+		if err != nil {
+			return "", err
+		}
+
+		token, err := service.DialIn(ctx, code, pin, name)
+		switch err {
+		case nil:
+			return token, nil
+		case domain.ErrNotFound, domain.ErrBadPin:
+			c.log.Warn("wrong room code or pin", "attempt", attempt+1)
+			c.media.play(toneWrong)
+		default:
+			return "", err
+		}
+	}
+
+	return "", errTooManyTries
+}
+
+// digits up to #, * starts over
+func (c *call) collect() (string, error) {
+	entry := []byte{}
+	timer := time.NewTimer(digitTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return "", c.ctx.Err()
+
+		case <-timer.C:
+			return "", errNoDigits
+
+		case d := <-c.media.digits:
+			timer.Reset(digitTimeout)
+
+			switch d {
+			case '#':
+				if len(entry) > 0 {
+					return string(entry), nil
+				}
+			case '*':
+				entry = entry[:0]
+			default:
+				if len(entry) < maxDigits {
+					entry = append(entry, d)
+				}
+			}
+		}
+	}
+}
+
+// end the call, with a BYE when the phone did not hang up itself
+func (c *call) end(sendBye bool) {
+	c.once.Do(func() {
+		c.cancel()
+		c.media.close()
+
+		if !sendBye {
+			c.srv.remove(c)
+			return
+		}
+
+		go func() {
+			c.bye()
+			c.srv.remove(c)
+		}()
+	})
+}
+
+func (c *call) byeAnswered() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.byeDone:
+	default:
+		close(c.byeDone)
+	}
+}
+
+// BYE within the dialog, sent until answered or the transaction times out
+func (c *call) bye() {
+	c.mu.Lock()
+	invite, remote := c.invite, c.remote
+	c.mu.Unlock()
+
+	target := addrURI(invite.get("Contact"))
+	if target == "" {
+		target = addrURI(invite.get("From"))
+	}
+
+	req := &message{method: "BYE", uri: target}
+	req.add("Via", fmt.Sprintf("SIP/2.0/UDP %s;branch=z9hG4bK%s;rport", c.hostPort(), randomToken(8)))
+	req.add("Max-Forwards", "70")
+	// the route set of the UAS is the Record-Route of the INVITE, in order
+	for _, rr := range invite.getAll("Record-Route") {
+		req.add("Route", rr)
+	}
+	req.add("From", c.localURI())
+	req.add("To", invite.get("From"))
+	req.add("Call-ID", c.id)
+	req.add("CSeq", "1 BYE")
+
+	interval := timerT1
+	deadline := time.After(64 * timerT1)
+
+	for {
+		c.srv.send(req, remote)
+
+		select {
+		case <-c.byeDone:
+			return
+		case <-deadline:
+			return
+		case <-time.After(interval):
+			interval = min(2*interval, timerT2)
+		}
+	}
+}
+
+// our side of the dialog, the To of the INVITE with our tag
+func (c *call) localURI() string {
+	to := c.invite.get("To")
+	if param(to, "tag") != "" {
+		return to
+	}
+	return to + ";tag=" + c.localTag
+}
+
+func (c *call) contact() string {
+	return fmt.Sprintf("<sip:vidcall@%s>", c.hostPort())
+}
+
+func (c *call) hostPort() string {
+	return net.JoinHostPort(c.media.ip.String(), fmt.Sprint(c.srv.port()))
+}
+
+// participant name of the caller, the last digits of its number
+func callerName(req *message) string {
+	user := uriUser(addrURI(req.get("From")))
+	if len(user) > 4 {
+		user = user[len(user)-4:]
+	}
+
+	if user == "" {
+		return "Phone"
+	}
+	return "Phone " + user
+}
+
+func randomToken(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
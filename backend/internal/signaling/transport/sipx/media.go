@@ -0,0 +1,351 @@
+package sipx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"vidcall/pkg/audio"
+
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+)
+
+const (
+	// G.711 runs at 8 kHz, 20 ms per packet
+	sampleRate  = 8000
+	packetTime  = 20 * time.Millisecond
+	frameLength = sampleRate / 50
+)
+
+var errNoCodec = errors.New("offer has no G.711 audio")
+
+// what the phone offered
+type offer struct {
+	addr *net.UDPAddr
+	pt   uint8
+	mime string
+	// RFC 4733 telephone-event payload type, -1 without
+	dtmfPT int
+}
+
+// pick G.711 from the phone's SDP, PCMU first
+func negotiate(raw []byte) (*offer, error) {
+	desc := sdp.SessionDescription{}
+	if err := desc.Unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	for _, media := range desc.MediaDescriptions {
+		if media.MediaName.Media != "audio" || media.MediaName.Port.Value == 0 {
+			continue
+		}
+
+		conn := desc.ConnectionInformation
+		if media.ConnectionInformation != nil {
+			conn = media.ConnectionInformation
+		}
+		if conn == nil || conn.Address == nil {
+			return nil, errNoCodec
+		}
+
+		ip := net.ParseIP(conn.Address.Address)
+		if ip == nil {
+			return nil, errNoCodec
+		}
+
+		o := &offer{
+			addr:   &net.UDPAddr{IP: ip, Port: media.MediaName.Port.Value},
+			dtmfPT: -1,
+		}
+
+		for _, format := range media.MediaName.Formats {
+			pt, err := strconv.ParseUint(format, 10, 8)
+			if err != nil {
+				continue
+			}
+
+			name := ""
+			if codec, err := desc.GetCodecForPayloadType(uint8(pt)); err == nil {
+				name = strings.ToLower(codec.Name)
+			}
+
+			switch {
+			case name == "telephone-event":
+				o.dtmfPT = int(pt)
+			case o.mime == "" && (pt == 0 || name == "pcmu"):
+				o.pt, o.mime = uint8(pt), audio.MimePCMU
+			case o.mime == "" && (pt == 8 || name == "pcma"):
+				o.pt, o.mime = uint8(pt), audio.MimePCMA
+			}
+		}
+
+		if o.mime == "" {
+			return nil, errNoCodec
+		}
+		return o, nil
+	}
+
+	return nil, errNoCodec
+}
+
+// RTP session with the phone. Until the call is bridged it plays prompts
+// and collects DTMF, then it carries the room audio both ways
+type media struct {
+	conn   *net.UDPConn
+	ip     net.IP
+	offer  *offer
+	enc    audio.Encoder
+	digits chan byte
+
+	mu     sync.Mutex
+	remote *net.UDPAddr
+	prompt []int16
+	// mixed room audio, one frame per call
+	source func() []int16
+	// phone audio for the room
+	sink func(*rtp.Packet)
+
+	// RFC 4733 events repeat, a digit is taken once per event timestamp
+	lastEvent uint32
+	inEvent   bool
+
+	ssrc uint32
+	seq  uint16
+	ts   uint32
+}
+
+func newMedia(ip net.IP, o *offer) (*media, error) {
+	enc, err := audio.NewEncoder(o.mime, sampleRate)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &media{
+		conn:   conn,
+		ip:     ip,
+		offer:  o,
+		enc:    enc,
+		digits: make(chan byte, 32),
+		remote: o.addr,
+		ssrc:   rand.Uint32(),
+		seq:    uint16(rand.Uint32()),
+		ts:     rand.Uint32(),
+	}, nil
+}
+
+// SDP answer with the chosen codec and telephone-event when offered
+func (m *media) answer() []byte {
+	port := m.conn.LocalAddr().(*net.UDPAddr).Port
+	codec := strings.TrimPrefix(m.offer.mime, "audio/")
+	session := time.Now().Unix()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "v=0\r\n")
+	fmt.Fprintf(&b, "o=vidcall %d %d IN %s %s\r\n", session, session, ipVersion(m.ip), m.ip)
+	fmt.Fprintf(&b, "s=vidcall\r\n")
+	fmt.Fprintf(&b, "c=IN %s %s\r\n", ipVersion(m.ip), m.ip)
+	fmt.Fprintf(&b, "t=0 0\r\n")
+
+	if m.offer.dtmfPT >= 0 {
+		fmt.Fprintf(&b, "m=audio %d RTP/AVP %d %d\r\n", port, m.offer.pt, m.offer.dtmfPT)
+		fmt.Fprintf(&b, "a=rtpmap:%d %s/%d\r\n", m.offer.pt, codec, sampleRate)
+		fmt.Fprintf(&b, "a=rtpmap:%d telephone-event/%d\r\n", m.offer.dtmfPT, sampleRate)
+		fmt.Fprintf(&b, "a=fmtp:%d 0-15\r\n", m.offer.dtmfPT)
+	} else {
+		fmt.Fprintf(&b, "m=audio %d RTP/AVP %d\r\n", port, m.offer.pt)
+		fmt.Fprintf(&b, "a=rtpmap:%d %s/%d\r\n", m.offer.pt, codec, sampleRate)
+	}
+	fmt.Fprintf(&b, "a=ptime:%d\r\n", packetTime.Milliseconds())
+	fmt.Fprintf(&b, "a=sendrecv\r\n")
+
+	return []byte(b.String())
+}
+
+func ipVersion(ip net.IP) string {
+	if ip.To4() == nil {
+		return "IP6"
+	}
+	return "IP4"
+}
+
+// a re-INVITE may move the phone's media
+func (m *media) update(o *offer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remote = o.addr
+}
+
+// queue a prompt, played before any room audio
+func (m *media) play(pcm []int16) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompt = append(m.prompt, pcm...)
+}
+
+// wait for the queued prompts to be sent
+func (m *media) flush(ctx context.Context) {
+	ticker := time.NewTicker(packetTime)
+	defer ticker.Stop()
+
+	for {
+		m.mu.Lock()
+		left := len(m.prompt)
+		m.mu.Unlock()
+
+		if left == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *media) bridge(source func() []int16, sink func(*rtp.Packet)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.source, m.sink = source, sink
+}
+
+func (m *media) run(ctx context.Context) {
+	go m.readLoop()
+	m.writeLoop(ctx)
+}
+
+func (m *media) close() {
+	_ = m.conn.Close()
+}
+
+// until the socket is closed
+func (m *media) readLoop() {
+	buf := make([]byte, 1500)
+
+	for {
+		n, from, err := m.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		pkt := &rtp.Packet{}
+		if err := pkt.Unmarshal(buf[:n]); err != nil {
+			continue
+		}
+
+		m.mu.Lock()
+		// symmetric RTP, phones behind NAT are answered where they send from
+		m.remote = from
+		sink := m.sink
+		m.mu.Unlock()
+
+		switch {
+		case m.offer.dtmfPT >= 0 && int(pkt.PayloadType) == m.offer.dtmfPT:
+			m.handleEvent(pkt)
+		case pkt.PayloadType == m.offer.pt && sink != nil:
+			sink(pkt)
+		}
+	}
+}
+
+// RFC 4733 event: event code, end bit and volume, duration
+func (m *media) handleEvent(pkt *rtp.Packet) {
+	if len(pkt.Payload) < 4 {
+		return
+	}
+
+	if m.inEvent && pkt.Timestamp == m.lastEvent {
+		return
+	}
+	m.inEvent, m.lastEvent = true, pkt.Timestamp
+
+	if d, ok := dtmfDigit(pkt.Payload[0]); ok {
+		m.digit(d)
+	}
+}
+
+func (m *media) digit(d byte) {
+	select {
+	case m.digits <- d:
+	default:
+	}
+}
+
+// send one frame every packet time, prompts first, then the room
+func (m *media) writeLoop(ctx context.Context) {
+	ticker := time.NewTicker(packetTime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		var frame []int16
+		switch {
+		case len(m.prompt) > 0:
+			frame = make([]int16, frameLength)
+			n := copy(frame, m.prompt)
+			m.prompt = m.prompt[n:]
+		case m.source != nil:
+			frame = m.source()
+		default:
+			frame = make([]int16, frameLength)
+		}
+		remote := m.remote
+		m.mu.Unlock()
+
+		payload, err := m.enc.Encode(frame)
+		if err != nil {
+			continue
+		}
+
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    m.offer.pt,
+				SequenceNumber: m.seq,
+				Timestamp:      m.ts,
+				SSRC:           m.ssrc,
+			},
+			Payload: payload,
+		}
+		m.seq++
+		m.ts += frameLength
+
+		buf, err := pkt.Marshal()
+		if err != nil {
+			continue
+		}
+		_, _ = m.conn.WriteToUDP(buf, remote)
+	}
+}
+
+// digit of an RFC 4733 event code
+func dtmfDigit(event byte) (byte, bool) {
+	switch {
+	case event <= 9:
+		return '0' + event, true
+	case event == 10:
+		return '*', true
+	case event == 11:
+		return '#', true
+	default:
+		return 0, false
+	}
+}
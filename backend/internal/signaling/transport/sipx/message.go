@@ -0,0 +1,228 @@
+package sipx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var errBadMessage = errors.New("malformed SIP message")
+
+type header struct {
+	name  string
+	value string
+}
+
+// one SIP request or response, only what a UDP user agent needs
+type message struct {
+	// request line
+	method string
+	uri    string
+	// status line
+	status int
+	reason string
+
+	headers []header
+	body    []byte
+}
+
+func (m *message) isRequest() bool {
+	return m.method != ""
+}
+
+// compact header forms (RFC 3261 7.3.3)
+var compact = map[string]string{
+	"i": "call-id",
+	"f": "from",
+	"t": "to",
+	"v": "via",
+	"m": "contact",
+	"l": "content-length",
+	"c": "content-type",
+}
+
+func canonical(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if long, ok := compact[name]; ok {
+		return long
+	}
+	return name
+}
+
+func (m *message) get(name string) string {
+	name = canonical(name)
+	for _, h := range m.headers {
+		if canonical(h.name) == name {
+			return h.value
+		}
+	}
+	return ""
+}
+
+func (m *message) getAll(name string) []string {
+	name = canonical(name)
+
+	var values []string
+	for _, h := range m.headers {
+		if canonical(h.name) == name {
+			values = append(values, h.value)
+		}
+	}
+	return values
+}
+
+func (m *message) add(name string, value string) {
+	m.headers = append(m.headers, header{name: name, value: value})
+}
+
+// replace every header of the name
+func (m *message) set(name string, value string) {
+	c := canonical(name)
+
+	kept := m.headers[:0]
+	for _, h := range m.headers {
+		if canonical(h.name) != c {
+			kept = append(kept, h)
+		}
+	}
+	m.headers = append(kept, header{name: name, value: value})
+}
+
+// CSeq number and method
+func (m *message) cseq() (uint32, string) {
+	num, method, _ := strings.Cut(m.get("CSeq"), " ")
+	n, _ := strconv.ParseUint(strings.TrimSpace(num), 10, 32)
+	return uint32(n), strings.TrimSpace(method)
+}
+
+func parseMessage(b []byte) (*message, error) {
+	head, body, ok := bytes.Cut(b, []byte("\r\n\r\n"))
+	if !ok {
+		return nil, errBadMessage
+	}
+
+	lines := strings.Split(string(head), "\r\n")
+	m := &message{}
+
+	first := strings.SplitN(lines[0], " ", 3)
+	if len(first) != 3 {
+		return nil, errBadMessage
+	}
+
+	if strings.HasPrefix(first[0], "SIP/") {
+		status, err := strconv.Atoi(first[1])
+		if err != nil {
+			return nil, errBadMessage
+		}
+		m.status, m.reason = status, first[2]
+	} else {
+		if first[2] != "SIP/2.0" {
+			return nil, errBadMessage
+		}
+		m.method, m.uri = first[0], first[1]
+	}
+
+	for _, line := range lines[1:] {
+		// folded continuation of the previous header
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(m.headers) > 0 {
+			m.headers[len(m.headers)-1].value += " " + strings.TrimSpace(line)
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, errBadMessage
+		}
+		m.add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	if raw := m.get("Content-Length"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > len(body) {
+			return nil, errBadMessage
+		}
+		body = body[:n]
+	}
+	m.body = body
+
+	return m, nil
+}
+
+func (m *message) bytes() []byte {
+	var b bytes.Buffer
+
+	if m.isRequest() {
+		fmt.Fprintf(&b, "%s %s SIP/2.0\r\n", m.method, m.uri)
+	} else {
+		fmt.Fprintf(&b, "SIP/2.0 %d %s\r\n", m.status, m.reason)
+	}
+
+	for _, h := range m.headers {
+		if canonical(h.name) == "content-length" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\r\n", h.name, h.value)
+	}
+	fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n", len(m.body))
+	b.Write(m.body)
+
+	return b.Bytes()
+}
+
+// response to req, the dialog headers are copied over (RFC 3261 8.2.6)
+func newResponse(req *message, status int, reason string) *message {
+	res := &message{status: status, reason: reason}
+
+	for _, via := range req.getAll("Via") {
+		res.add("Via", via)
+	}
+	res.add("From", req.get("From"))
+	res.add("To", req.get("To"))
+	res.add("Call-ID", req.get("Call-ID"))
+	res.add("CSeq", req.get("CSeq"))
+
+	if req.method == "INVITE" && status/100 == 2 {
+		for _, rr := range req.getAll("Record-Route") {
+			res.add("Record-Route", rr)
+		}
+	}
+
+	return res
+}
+
+// value of a ;name=value parameter of a header
+func param(value string, name string) string {
+	for _, p := range strings.Split(value, ";")[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// URI of a name-addr like "Bob" <sip:bob@host>;tag=1
+func addrURI(value string) string {
+	if start := strings.Index(value, "<"); start >= 0 {
+		if end := strings.Index(value[start:], ">"); end > 0 {
+			return value[start+1 : start+end]
+		}
+	}
+
+	uri, _, _ := strings.Cut(value, ";")
+	return strings.TrimSpace(uri)
+}
+
+// user part of a sip: URI
+func uriUser(uri string) string {
+	uri = strings.TrimPrefix(strings.TrimPrefix(uri, "sips:"), "sip:")
+	user, _, ok := strings.Cut(uri, "@")
+	if !ok {
+		return ""
+	}
+
+	user, _, _ = strings.Cut(user, ";")
+	return user
+}
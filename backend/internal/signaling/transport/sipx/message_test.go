@@ -0,0 +1,237 @@
+package sipx
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// helper function to join SIP lines with CRLF
+func sipText(lines ...string) []byte {
+	return []byte(strings.Join(lines, "\r\n"))
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     []byte
+		method  string
+		uri     string
+		status  int
+		reason  string
+		headers map[string]string
+		body    string
+		err     error
+	}{
+		{
+			name: "request",
+			raw: sipText(
+				"INVITE sip:1000@example.com SIP/2.0",
+				"Via: SIP/2.0/UDP 10.0.0.1:5060;branch=z9hG4bK1",
+				"From: \"Bob\" <sip:bob@example.com>;tag=1",
+				"Call-ID: abc",
+				"CSeq: 1 INVITE",
+				"Content-Length: 4",
+				"",
+				"v=0\n",
+			),
+			method:  "INVITE",
+			uri:     "sip:1000@example.com",
+			headers: map[string]string{"call-id": "abc", "CSeq": "1 INVITE"},
+			body:    "v=0\n",
+		},
+		{
+			name: "response",
+			raw: sipText(
+				"SIP/2.0 180 Ringing",
+				"Call-ID: abc",
+				"",
+				"",
+			),
+			status:  180,
+			reason:  "Ringing",
+			headers: map[string]string{"Call-ID": "abc"},
+		},
+		{
+			name: "compact and folded headers",
+			raw: sipText(
+				"BYE sip:bob@example.com SIP/2.0",
+				"i: abc",
+				"f: <sip:alice@example.com>",
+				"  ;tag=2",
+				"",
+				"",
+			),
+			method:  "BYE",
+			uri:     "sip:bob@example.com",
+			headers: map[string]string{"Call-ID": "abc", "From": "<sip:alice@example.com> ;tag=2"},
+		},
+		{
+			name: "body cut at Content-Length",
+			raw: sipText(
+				"SIP/2.0 200 OK",
+				"l: 2",
+				"",
+				"okextra",
+			),
+			status: 200,
+			reason: "OK",
+			body:   "ok",
+		},
+		{
+			name: "no end of headers",
+			raw:  sipText("OPTIONS sip:a@b SIP/2.0", "Call-ID: abc"),
+			err:  errBadMessage,
+		},
+		{
+			name: "wrong version",
+			raw:  sipText("OPTIONS sip:a@b SIP/3.0", "", ""),
+			err:  errBadMessage,
+		},
+		{
+			name: "bad status",
+			raw:  sipText("SIP/2.0 OK fine", "", ""),
+			err:  errBadMessage,
+		},
+		{
+			name: "header without colon",
+			raw:  sipText("OPTIONS sip:a@b SIP/2.0", "Call-ID abc", "", ""),
+			err:  errBadMessage,
+		},
+		{
+			name: "Content-Length past the body",
+			raw:  sipText("OPTIONS sip:a@b SIP/2.0", "Content-Length: 10", "", "short"),
+			err:  errBadMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseMessage(tt.raw)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if m.method != tt.method || m.uri != tt.uri || m.status != tt.status || m.reason != tt.reason {
+				t.Errorf("got %q %q %d %q, want %q %q %d %q", m.method, m.uri, m.status, m.reason, tt.method, tt.uri, tt.status, tt.reason)
+			}
+			for name, want := range tt.headers {
+				if got := m.get(name); got != want {
+					t.Errorf("header %s: got %q, want %q", name, got, want)
+				}
+			}
+			if string(m.body) != tt.body {
+				t.Errorf("body: got %q, want %q", m.body, tt.body)
+			}
+		})
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	req := &message{method: "INVITE", uri: "sip:1000@example.com"}
+	req.add("Via", "SIP/2.0/UDP 10.0.0.1:5060;branch=z9hG4bK1")
+	req.add("Via", "SIP/2.0/UDP 10.0.0.2:5060;branch=z9hG4bK2")
+	req.add("From", "<sip:bob@example.com>;tag=1")
+	req.add("To", "<sip:1000@example.com>")
+	req.add("Call-ID", "abc")
+	req.add("CSeq", "7 INVITE")
+	req.add("Content-Length", "999")
+	req.body = []byte("v=0\r\n")
+
+	got, err := parseMessage(req.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.method != req.method || got.uri != req.uri || string(got.body) != string(req.body) {
+		t.Errorf("got %q %q %q", got.method, got.uri, got.body)
+	}
+	// the stale length is replaced by the real one
+	if l := got.get("Content-Length"); l != "5" {
+		t.Errorf("Content-Length: got %q, want 5", l)
+	}
+	if n, method := got.cseq(); n != 7 || method != "INVITE" {
+		t.Errorf("CSeq: got %d %q", n, method)
+	}
+
+	res := newResponse(got, 200, "OK")
+	if !slices.Equal(res.getAll("Via"), req.getAll("Via")) {
+		t.Errorf("Via: got %v, want %v", res.getAll("Via"), req.getAll("Via"))
+	}
+	if !strings.HasPrefix(string(res.bytes()), "SIP/2.0 200 OK\r\n") {
+		t.Errorf("status line: got %q", res.bytes())
+	}
+}
+
+func TestAddr(t *testing.T) {
+	tests := []struct {
+		value string
+		uri   string
+		user  string
+		tag   string
+	}{
+		{`"Bob" <sip:bob@example.com>;tag=1`, "sip:bob@example.com", "bob", "1"},
+		{`<sips:+15551234;user=phone@example.com>`, "sips:+15551234;user=phone@example.com", "+15551234", ""},
+		{`sip:alice@example.com;tag=x`, "sip:alice@example.com", "alice", "x"},
+		{`sip:example.com`, "sip:example.com", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			uri := addrURI(tt.value)
+			if uri != tt.uri {
+				t.Errorf("uri: got %q, want %q", uri, tt.uri)
+			}
+			if user := uriUser(uri); user != tt.user {
+				t.Errorf("user: got %q, want %q", user, tt.user)
+			}
+			if tag := param(tt.value, "tag"); tag != tt.tag {
+				t.Errorf("tag: got %q, want %q", tag, tt.tag)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name   string
+		digits string
+		want   string
+		err    error
+	}{
+		{name: "ends at #", digits: "1234#", want: "1234"},
+		{name: "* starts over", digits: "12*34#", want: "34"},
+		{name: "empty entry is not taken", digits: "#56#", want: "56"},
+		{name: "long entry is cut", digits: strings.Repeat("9", maxDigits+4) + "#", want: strings.Repeat("9", maxDigits)},
+		{name: "caller hangs up", err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			c := &call{ctx: ctx, media: &media{digits: make(chan byte, len(tt.digits))}}
+			for _, d := range []byte(tt.digits) {
+				c.media.digits <- d
+			}
+			// nothing left to read, only the hang up is ready
+			if tt.err != nil {
+				cancel()
+			}
+
+			got, err := c.collect()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package sipx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/security"
+	"vidcall/pkg/audio"
+)
+
+const (
+	// RFC 3261 timers: retransmissions start at T1 and double up to T2,
+	// a transaction gives up after 64*T1
+	timerT1 = 500 * time.Millisecond
+	timerT2 = 4 * time.Second
+
+	allowed = "INVITE, ACK, BYE, CANCEL, OPTIONS, INFO"
+)
+
+var errNoOpus = errors.New("the SIP gateway decodes the room's Opus audio, build with -tags opus")
+
+// Server is a SIP user agent over UDP answering phone calls, typically
+// from a trunk. Each call is bridged into its room by a bot peer on the SFU
+type Server struct {
+	conn     *net.UDPConn
+	publicIP net.IP
+	issuer   *security.Issuer
+	client   sfu.SFUClient
+	log      *slog.Logger
+
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// ListenAndServe answers calls on addr until ctx is done. publicIP goes in
+// the SDP and Contact, the local address toward the caller when empty
+func ListenAndServe(ctx context.Context, addr string, publicIP string, issuer *security.Issuer, client sfu.SFUClient) error {
+	// callers would hear nothing of the room
+	if !audio.CanDecode(audio.MimeOpus) {
+		return errNoOpus
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+
+	s := &Server{
+		conn:     conn,
+		publicIP: net.ParseIP(publicIP),
+		issuer:   issuer,
+		client:   client,
+		log:      slog.Default().With("layer", "transport", "transport", "sip"),
+		calls:    make(map[string]*call),
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.hangupAll()
+		_ = conn.Close()
+	}()
+
+	s.log.Info("SIP gateway listening", "addr", conn.LocalAddr().String())
+	return s.serve()
+}
+
+func (s *Server) serve() error {
+	buf := make([]byte, 65535)
+
+	for {
+		n, from, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return err
+		}
+
+		// keep-alives of NAT bindings
+		if n <= 4 {
+			continue
+		}
+
+		msg, err := parseMessage(buf[:n])
+		if err != nil {
+			s.log.Warn("dropped malformed message", "from", from.String())
+			continue
+		}
+
+		if msg.isRequest() {
+			s.handleRequest(msg, from)
+		} else {
+			s.handleResponse(msg)
+		}
+	}
+}
+
+func (s *Server) handleRequest(req *message, from *net.UDPAddr) {
+	callID := req.get("Call-ID")
+	if callID == "" || len(req.getAll("Via")) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	c := s.calls[callID]
+	s.mu.Unlock()
+
+	switch req.method {
+	case "INVITE":
+		if c != nil {
+			c.reinvite(req, from)
+			return
+		}
+		s.invite(req, from)
+
+	case "ACK":
+		if c != nil {
+			c.ack()
+		}
+
+	case "BYE":
+		if c == nil {
+			s.send(newResponse(req, 481, "Call/Transaction Does Not Exist"), from)
+			return
+		}
+		s.send(newResponse(req, 200, "OK"), from)
+		c.end(false)
+
+	case "CANCEL":
+		// calls are answered right away, there is nothing left to cancel
+		s.send(newResponse(req, 200, "OK"), from)
+
+	case "INFO":
+		if c == nil {
+			s.send(newResponse(req, 481, "Call/Transaction Does Not Exist"), from)
+			return
+		}
+		c.info(req)
+		s.send(newResponse(req, 200, "OK"), from)
+
+	case "OPTIONS":
+		res := newResponse(req, 200, "OK")
+		res.add("Allow", allowed)
+		res.add("Accept", "application/sdp")
+		s.send(res, from)
+
+	default:
+		res := newResponse(req, 405, "Method Not Allowed")
+		res.add("Allow", allowed)
+		s.send(res, from)
+	}
+}
+
+// the only requests sent are BYEs
+func (s *Server) handleResponse(res *message) {
+	if _, method := res.cseq(); method != "BYE" || res.status < 200 {
+		return
+	}
+
+	s.mu.Lock()
+	c := s.calls[res.get("Call-ID")]
+	s.mu.Unlock()
+
+	if c != nil {
+		c.byeAnswered()
+	}
+}
+
+func (s *Server) invite(req *message, from *net.UDPAddr) {
+	// an in-dialog request of a call that is gone
+	if param(req.get("To"), "tag") != "" {
+		s.send(newResponse(req, 481, "Call/Transaction Does Not Exist"), from)
+		return
+	}
+
+	s.send(newResponse(req, 100, "Trying"), from)
+
+	o, err := negotiate(req.body)
+	if err != nil {
+		s.log.Warn("rejected call", "from", from.String(), "err", err)
+		s.send(newResponse(req, 488, "Not Acceptable Here"), from)
+		return
+	}
+
+	m, err := newMedia(s.localIP(from), o)
+	if err != nil {
+		s.log.Error("unable to open RTP socket", "err", err)
+		s.send(newResponse(req, 500, "Server Internal Error"), from)
+		return
+	}
+
+	c := newCall(s, req, from, m)
+
+	s.mu.Lock()
+	s.calls[c.id] = c
+	s.mu.Unlock()
+
+	c.answer()
+	go c.run()
+}
+
+func (s *Server) remove(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.calls[c.id] == c {
+		delete(s.calls, c.id)
+	}
+}
+
+// hang up every call, their BYEs are not waited for
+func (s *Server) hangupAll() {
+	s.mu.Lock()
+	calls := make([]*call, 0, len(s.calls))
+	for _, c := range s.calls {
+		calls = append(calls, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range calls {
+		c.end(true)
+	}
+}
+
+func (s *Server) send(msg *message, to *net.UDPAddr) {
+	if _, err := s.conn.WriteToUDP(msg.bytes(), to); err != nil {
+		s.log.Warn("unable to send SIP message", "to", to.String(), "err", err)
+	}
+}
+
+// address the caller can reach us on
+func (s *Server) localIP(remote *net.UDPAddr) net.IP {
+	if s.publicIP != nil {
+		return s.publicIP
+	}
+
+	conn, err := net.DialUDP("udp", nil, remote)
+	if err != nil {
+		return net.IPv4(127, 0, 0, 1)
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP
+}
+
+func (s *Server) port() int {
+	return s.conn.LocalAddr().(*net.UDPAddr).Port
+}
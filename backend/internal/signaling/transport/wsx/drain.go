@@ -0,0 +1,86 @@
+package wsx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sfu "vidcall/api/proto"
+
+	"github.com/gorilla/websocket"
+)
+
+// sessions are checked this often while draining
+const drainPoll = time.Second
+
+// websocket sessions in progress, told to move on when the server drains
+var sessions = struct {
+	mu       sync.Mutex
+	clients  map[*client]struct{}
+	draining bool
+}{clients: make(map[*client]struct{})}
+
+// helper function to track a session, false once the server is draining
+func track(c *client) bool {
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+
+	if sessions.draining {
+		return false
+	}
+
+	sessions.clients[c] = struct{}{}
+	return true
+}
+
+func untrack(c *client) {
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+	delete(sessions.clients, c)
+}
+
+// helper function to copy the sessions in progress
+func tracked() []*client {
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+
+	clients := make([]*client, 0, len(sessions.clients))
+	for c := range sessions.clients {
+		clients = append(clients, c)
+	}
+	return clients
+}
+
+// Drain refuses new sessions and sends SERVER_DRAINING to the open ones so
+// clients can reconnect elsewhere. It returns once they left, the ones
+// still connected when ctx ends are closed with going away
+func Drain(ctx context.Context) {
+	sessions.mu.Lock()
+	sessions.draining = true
+	sessions.mu.Unlock()
+
+	drainingE := &sfu.Event{Type: sfu.EventType_SERVER_DRAINING}
+	if deadline, ok := ctx.Deadline(); ok {
+		endsAt := deadline.UnixMilli()
+		drainingE.EndsAt = &endsAt
+	}
+
+	msg := &sfu.PeerSignal{Payload: &sfu.PeerSignal_Event{Event: drainingE}}
+	for _, c := range tracked() {
+		_ = c.send(msg)
+	}
+
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+
+	for len(tracked()) > 0 {
+		select {
+		case <-ctx.Done():
+			for _, c := range tracked() {
+				CloseOne(c.conn, websocket.CloseGoingAway, "server shutting down")
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
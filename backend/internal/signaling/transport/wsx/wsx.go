@@ -1,53 +1,31 @@
 package wsx
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
 	sfu "vidcall/api/proto"
+	"vidcall/internal/signaling/metrics"
+	"vidcall/internal/signaling/protocol"
 	"vidcall/internal/signaling/security"
+	"vidcall/internal/signaling/service"
 	"vidcall/pkg/logger"
+	"vidcall/pkg/tracing"
+	"vidcall/pkg/utils"
 
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-type signal struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
-}
-
-type sdp struct {
-	Pc   string `json:"pc"`
-	Type string `json:"type"`
-	SDP  string `json:"sdp"`
-}
-
-type ice struct {
-	Pc                string `json:"pc"`
-	Candidate         string `json:"candidate"`
-	SdpMid            string `json:"sdpMid"`
-	SdpMLineIndex     uint32 `json:"sdpMLineIndex"`
-	UsernameFragmment string `json:"usernameFragment,omitempty"`
-}
-
-type action struct {
-	Type string `json:"type"`
-}
-
-type event struct {
-	Name   string `json:"name"`
-	PeerID string `json:"peerID"`
-	Type   string `json:"type"`
-}
-
 func CloseOne(c *websocket.Conn, code int, reason string) {
 	// TODO: error handling
-	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason)
+	msg := websocket.FormatCloseMessage(code, reason)
 	_ = c.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
 	defer c.Close()
 	fmt.Println("Close connection")
@@ -58,19 +36,31 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true
 	},
+	Subprotocols: []string{protocol.Subprotocol},
 }
 
 func HandleWS(w http.ResponseWriter, r *http.Request, sfuCLient sfu.SFUClient) {
-	ctx := r.Context()
-	claims := security.ClaimsFrom(ctx)
-
-	md := metadata.Pairs(
-		"name", claims.Name,
-		"peer-id", claims.PeerID,
-		"room-id", claims.RoomID,
-		"role", claims.Role,
+	claims := security.ClaimsFrom(r.Context())
+
+	// view tokens only play the room over WHEP
+	if claims.Role == "viewer" {
+		utils.Error(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	// the SFU stream continues this trace through the gRPC metadata
+	ctx, span := tracing.Start(r.Context(), "ws.session",
+		attribute.String("peer.id", claims.PeerID),
+		attribute.String("room.id", claims.RoomID),
 	)
-	ctxMD := metadata.NewOutgoingContext(ctx, md)
+	defer span.End()
+
+	ctxMD := service.PeerContext(ctx)
+
+	// low power clients take the room audio mixed by the SFU, e.g. ?mix=audio
+	if r.URL.Query().Get("mix") == "audio" {
+		ctxMD = metadata.AppendToOutgoingContext(ctxMD, "mix-audio", "true")
+	}
 
 	log := logger.GetLog(ctx).With("layer", "transport")
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -79,8 +69,28 @@ func HandleWS(w http.ResponseWriter, r *http.Request, sfuCLient sfu.SFUClient) {
 		return
 	}
 
+	closed := metrics.WSSessionOpened()
+	defer closed()
+
+	c := &client{conn: conn}
+
+	// the server is shutting down, the client reconnects later
+	if !track(c) {
+		CloseOne(conn, websocket.CloseTryAgainLater, "server is draining")
+		return
+	}
+	defer untrack(c)
+
+	// the client asks for the version it speaks as the subprotocol
+	if conn.Subprotocol() != protocol.Subprotocol {
+		log.Warn("unsupported protocol version", "offered", websocket.Subprotocols(r))
+		_ = c.send(protocol.NewError("", sfu.ErrorCode_UNSUPPORTED_VERSION, "expected subprotocol "+protocol.Subprotocol))
+		CloseOne(conn, websocket.CloseProtocolError, "unsupported protocol version")
+		return
+	}
+
 	// Checking for join/start meeting before conecting to SFU
-	intent, first, err := handleFirstMsg(conn, log)
+	intent, first, err := handleFirstMsg(c, log)
 	if err != nil || intent != IntentJoin {
 		return
 	}
@@ -103,8 +113,8 @@ func HandleWS(w http.ResponseWriter, r *http.Request, sfuCLient sfu.SFUClient) {
 
 	g, _ := errgroup.WithContext(ctx)
 
-	g.Go(func() error { return onListenClient(conn, stream, log) })
-	g.Go(func() error { return onListenSFU(conn, stream, log) })
+	g.Go(func() error { return onListenClient(c, stream, log) })
+	g.Go(func() error { return onListenSFU(c, stream, log) })
 
 	if err := g.Wait(); err != nil {
 		log.Error(fmt.Sprintf("websocket disconnected with error: %v", err))
@@ -116,115 +126,106 @@ func HandleWS(w http.ResponseWriter, r *http.Request, sfuCLient sfu.SFUClient) {
 	CloseOne(conn, websocket.CloseNormalClosure, "")
 }
 
-func onListenClient(conn *websocket.Conn, stream sfu.SFU_SignalClient, log *slog.Logger) error {
+func onListenClient(c *client, stream sfu.SFU_SignalClient, log *slog.Logger) error {
 
 	log = log.With("from", "client")
 
-	var msg signal
 	for {
-		err := conn.ReadJSON(&msg)
+		_, raw, err := c.conn.ReadMessage()
 		if err != nil {
 			return err
 		}
 
-		switch msg.Type {
-		case "sdp":
-			sdp, err := handleClientSDP(msg.Payload, log)
-
-			if err != nil {
+		msg, id, err := protocol.Decode(raw)
+		if err != nil {
+			log.Warn("invalid message from client", "err", err)
+			if err := c.send(protocol.NewError(id, protocol.Code(err), err.Error())); err != nil {
 				return err
 			}
+			continue
+		}
 
-			if err := stream.Send(sdp); err != nil {
+		switch pl := msg.Payload.(type) {
+		case *sfu.PeerSignal_Sdp:
+			if err := stream.Send(msg); err != nil {
 				log.Error("unable to send sdp to sfu")
 				return err
 			}
 
 			log.Info("sent sdp to sfu")
 
-		case "ice":
-			ice, err := handleClientIce(msg.Payload, log)
-
-			if err != nil {
-				return err
-			}
-
-			if err := stream.Send(ice); err != nil {
+		case *sfu.PeerSignal_Ice:
+			if err := stream.Send(msg); err != nil {
 				log.Error("unable to send ice to sfu")
 				return err
 			}
 
 			log.Info("sent ice to sfu")
 
-		case "action":
+		case *sfu.PeerSignal_Action:
 
 			// TODO: add permisions
-			action, err := handleClientAction(msg.Payload, log)
-
-			if err != nil {
+			if err := stream.Send(msg); err != nil {
+				log.Error("unable to send action to sfu")
 				return err
 			}
 
-			if err := stream.Send(action); err != nil {
-				log.Error("unable to send action to sfu")
+			log.Info("sent action to sfu", "action", pl.Action.Type.String())
+
+		case *sfu.PeerSignal_Key:
+			if err := stream.Send(msg); err != nil {
+				log.Error("unable to send key to sfu")
 				return err
 			}
 
-			log.Info("sent action to sfu")
+			log.Info("sent key to sfu", "type", pl.Key.Type.String())
+
+		default:
+			if err := c.send(protocol.NewError(id, sfu.ErrorCode_BAD_REQUEST, "message type not accepted from client")); err != nil {
+				return err
+			}
 		}
 
 	}
 }
 
-func onListenSFU(conn *websocket.Conn, stream sfu.SFU_SignalClient, log *slog.Logger) error {
+func onListenSFU(c *client, stream sfu.SFU_SignalClient, log *slog.Logger) error {
 
 	log = log.With("from", "SFU")
 
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
+			switch status.Code(err) {
+			// the SFU is draining, hang up so the client reconnects later
+			case codes.Unavailable:
+				CloseOne(c.conn, websocket.CloseTryAgainLater, "sfu unavailable")
+			// the join was refused, e.g. ?mix=audio in an encrypted room
+			case codes.PermissionDenied, codes.FailedPrecondition:
+				CloseOne(c.conn, websocket.ClosePolicyViolation, status.Convert(err).Message())
+			}
+			return err
+		}
+
+		if err := c.send(msg); err != nil {
+			log.Error("unable to send message to client")
 			return err
 		}
 
 		switch pl := msg.Payload.(type) {
 		case *sfu.PeerSignal_Sdp:
-			sdp, err := handleSfuSDP(pl, log)
-			if err != nil {
-				return err
-			}
-
-			if err := conn.WriteJSON(sdp); err != nil {
-				log.Error("unable to send sdp to client")
-				return err
-			}
-
 			log.Info("sent sdp to client")
 
 		case *sfu.PeerSignal_Ice:
-			ice, err := handleSfuIce(pl, log)
-			if err != nil {
-				return err
-			}
-
-			if err := conn.WriteJSON(ice); err != nil {
-				log.Error("unable to send ice to client")
-				return err
-			}
-
 			log.Info("send ice to client")
 
 		case *sfu.PeerSignal_Event:
-			event, err := handleSfuEvent(pl, log)
-			if err != nil {
-				return err
-			}
-
-			if err := conn.WriteJSON(event); err != nil {
-				log.Error("unablet to send event to client")
-				return err
+			if pl.Event.Type != sfu.EventType_QUALITY {
+				log.Info("send event to client", "event", pl.Event.Type.String())
 			}
 
-			log.Info("send event to client")
+		case *sfu.PeerSignal_Error:
+			log.Info("send error to client", "code", pl.Error.Code.String(), "request", pl.Error.RequestID)
 		}
 	}
 }
@@ -0,0 +1,89 @@
+package audio
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// RTP audio mime types, as pion names them
+const (
+	MimePCMU = "audio/PCMU"
+	MimePCMA = "audio/PCMA"
+	MimeOpus = "audio/opus"
+)
+
+var ErrUnsupported = errors.New("unsupported audio codec")
+
+// Decoder turns one RTP payload into mono 16 bit PCM at the rate it was
+// created with
+type Decoder interface {
+	Decode(payload []byte) ([]int16, error)
+}
+
+// Encoder turns one frame of mono 16 bit PCM into an RTP payload
+type Encoder interface {
+	Encode(pcm []int16) ([]byte, error)
+}
+
+type (
+	DecoderFactory func(rate int) (Decoder, error)
+	EncoderFactory func(rate int) (Encoder, error)
+)
+
+// G.711 is always there, Opus only in builds with the opus tag
+var (
+	mu       sync.RWMutex
+	decoders = map[string]DecoderFactory{}
+	encoders = map[string]EncoderFactory{}
+)
+
+func RegisterDecoder(mime string, f DecoderFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	decoders[strings.ToLower(mime)] = f
+}
+
+func RegisterEncoder(mime string, f EncoderFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	encoders[strings.ToLower(mime)] = f
+}
+
+// decoder of a codec producing PCM at rate
+func NewDecoder(mime string, rate int) (Decoder, error) {
+	mu.RLock()
+	f, ok := decoders[strings.ToLower(mime)]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return f(rate)
+}
+
+// encoder of a codec taking PCM at rate
+func NewEncoder(mime string, rate int) (Encoder, error) {
+	mu.RLock()
+	f, ok := encoders[strings.ToLower(mime)]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return f(rate)
+}
+
+func CanDecode(mime string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := decoders[strings.ToLower(mime)]
+	return ok
+}
+
+func CanEncode(mime string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := encoders[strings.ToLower(mime)]
+	return ok
+}
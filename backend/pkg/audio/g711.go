@@ -0,0 +1,139 @@
+package audio
+
+// G.711 (µ-law and A-law) at 8 kHz, one byte per sample
+const g711Rate = 8000
+
+func init() {
+	RegisterDecoder(MimePCMU, func(rate int) (Decoder, error) {
+		return &g711Decoder{decode: ulawToLinear, rate: rate}, nil
+	})
+	RegisterDecoder(MimePCMA, func(rate int) (Decoder, error) {
+		return &g711Decoder{decode: alawToLinear, rate: rate}, nil
+	})
+	RegisterEncoder(MimePCMU, func(rate int) (Encoder, error) {
+		return &g711Encoder{encode: linearToUlaw, rate: rate}, nil
+	})
+	RegisterEncoder(MimePCMA, func(rate int) (Encoder, error) {
+		return &g711Encoder{encode: linearToAlaw, rate: rate}, nil
+	})
+}
+
+type g711Decoder struct {
+	decode func(byte) int16
+	rate   int
+}
+
+func (d *g711Decoder) Decode(payload []byte) ([]int16, error) {
+	pcm := make([]int16, len(payload))
+	for i, b := range payload {
+		pcm[i] = d.decode(b)
+	}
+
+	return Resample(pcm, g711Rate, d.rate), nil
+}
+
+type g711Encoder struct {
+	encode func(int16) byte
+	rate   int
+}
+
+func (e *g711Encoder) Encode(pcm []int16) ([]byte, error) {
+	pcm = Resample(pcm, e.rate, g711Rate)
+
+	out := make([]byte, len(pcm))
+	for i, s := range pcm {
+		out[i] = e.encode(s)
+	}
+
+	return out, nil
+}
+
+const (
+	ulawBias = 0x84
+	ulawClip = 32635
+)
+
+func linearToUlaw(s int16) byte {
+	sample := int(s)
+
+	var sign int
+	if sample < 0 {
+		sample = -sample
+		sign = 0x80
+	}
+	if sample > ulawClip {
+		sample = ulawClip
+	}
+	sample += ulawBias
+
+	exponent := 7
+	for mask := 0x4000; sample&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (sample >> (exponent + 3)) & 0x0F
+
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+func ulawToLinear(u byte) int16 {
+	u = ^u
+
+	exponent := int(u>>4) & 0x07
+	mantissa := int(u) & 0x0F
+	sample := ((mantissa << 3) + ulawBias) << exponent
+
+	if u&0x80 != 0 {
+		return int16(ulawBias - sample)
+	}
+	return int16(sample - ulawBias)
+}
+
+// upper bounds of the A-law segments, on 13 bit samples
+var alawSegEnd = [8]int{0x1F, 0x3F, 0x7F, 0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF}
+
+func linearToAlaw(s int16) byte {
+	pcm := int(s) >> 3
+
+	mask := 0xD5
+	if pcm < 0 {
+		mask = 0x55
+		pcm = -pcm - 1
+	}
+
+	seg := 0
+	for seg < len(alawSegEnd) && pcm > alawSegEnd[seg] {
+		seg++
+	}
+	if seg == len(alawSegEnd) {
+		return byte(0x7F ^ mask)
+	}
+
+	aval := seg << 4
+	if seg < 2 {
+		aval |= (pcm >> 1) & 0x0F
+	} else {
+		aval |= (pcm >> seg) & 0x0F
+	}
+
+	return byte(aval ^ mask)
+}
+
+func alawToLinear(a byte) int16 {
+	a ^= 0x55
+
+	t := int(a&0x0F) << 4
+	switch seg := int(a&0x70) >> 4; seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= seg - 1
+	}
+
+	if a&0x80 != 0 {
+		return int16(t)
+	}
+	return int16(-t)
+}
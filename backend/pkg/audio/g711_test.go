@@ -0,0 +1,135 @@
+package audio
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestG711RoundTrip(t *testing.T) {
+	pcm := []int16{0, 1, -1, 100, -100, 1000, -1000, 8000, -8000, 30000, -30000, 32767, -32768}
+
+	for _, mime := range []string{MimePCMU, MimePCMA} {
+		t.Run(mime, func(t *testing.T) {
+			enc, err := NewEncoder(mime, g711Rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dec, err := NewDecoder(mime, g711Rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payload, err := enc.Encode(pcm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(payload) != len(pcm) {
+				t.Fatalf("got %d bytes for %d samples", len(payload), len(pcm))
+			}
+
+			out, err := dec.Decode(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// G.711 keeps about 4 bits of mantissa, the error grows with
+			// the sample
+			for i, s := range pcm {
+				diff := int(out[i]) - int(s)
+				if tol := abs(int(s))/8 + 16; diff > tol || diff < -tol {
+					t.Errorf("sample %d: got %d, error %d above %d", s, out[i], diff, tol)
+				}
+			}
+		})
+	}
+}
+
+func TestG711CodeWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		encode func(int16) byte
+		decode func(byte) int16
+		// code words that decode to the same sample as another one
+		skip []byte
+	}{
+		{"ulaw", linearToUlaw, ulawToLinear, []byte{0x7F}},
+		{"alaw", linearToAlaw, alawToLinear, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range 256 {
+				b := byte(i)
+				if slices.Contains(tt.skip, b) {
+					continue
+				}
+
+				if got := tt.encode(tt.decode(b)); got != b {
+					t.Errorf("code word %#02x: decoded to %d, encoded back to %#02x", b, tt.decode(b), got)
+				}
+			}
+		})
+	}
+}
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		name    string
+		frames  map[string][]int16
+		exclude string
+		n       int
+		want    []int16
+	}{
+		{
+			name:   "adds sources",
+			frames: map[string][]int16{"a": {1, 2, 3}, "b": {10, 20, 30}},
+			n:      3,
+			want:   []int16{11, 22, 33},
+		},
+		{
+			name:    "leaves out the listener",
+			frames:  map[string][]int16{"a": {1, 2, 3}, "b": {10, 20, 30}},
+			exclude: "b",
+			n:       3,
+			want:    []int16{1, 2, 3},
+		},
+		{
+			name:   "clips high",
+			frames: map[string][]int16{"a": {30000, 100}, "b": {30000, 100}},
+			n:      2,
+			want:   []int16{32767, 200},
+		},
+		{
+			name:   "clips low",
+			frames: map[string][]int16{"a": {-30000, -100}, "b": {-30000, -100}},
+			n:      2,
+			want:   []int16{-32768, -200},
+		},
+		{
+			name:   "short frames pad with silence",
+			frames: map[string][]int16{"a": {5}, "b": {1, 2, 3, 4}},
+			n:      3,
+			want:   []int16{6, 2, 3},
+		},
+		{
+			name: "no sources",
+			n:    2,
+			want: []int16{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sum(tt.frames, tt.exclude, tt.n); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,87 @@
+package audio
+
+import (
+	"math"
+	"sync"
+)
+
+// Mixer buffers the decoded audio of several sources and hands it out one
+// frame at a time, on the clock of the consumer
+type Mixer struct {
+	mu      sync.Mutex
+	frame   int
+	limit   int
+	sources map[string][]int16
+}
+
+// frames kept per source, older audio is dropped to bound the delay
+const maxBufferedFrames = 10
+
+// frame is the number of samples handed out per source on each Next
+func NewMixer(frame int) *Mixer {
+	return &Mixer{
+		frame:   frame,
+		limit:   frame * maxBufferedFrames,
+		sources: make(map[string][]int16),
+	}
+}
+
+func (m *Mixer) Push(id string, pcm []int16) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buf := append(m.sources[id], pcm...)
+	if len(buf) > m.limit {
+		buf = buf[len(buf)-m.limit:]
+	}
+	m.sources[id] = buf
+}
+
+func (m *Mixer) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sources, id)
+}
+
+// next frame of every source that has audio, short ones padded with
+// silence
+func (m *Mixer) Next() map[string][]int16 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	frames := make(map[string][]int16, len(m.sources))
+	for id, buf := range m.sources {
+		if len(buf) == 0 {
+			continue
+		}
+
+		f := make([]int16, m.frame)
+		n := copy(f, buf)
+		m.sources[id] = buf[n:]
+		frames[id] = f
+	}
+
+	return frames
+}
+
+// Sum adds the frames up, leaving out exclude, so a listener does not
+// hear itself
+func Sum(frames map[string][]int16, exclude string, n int) []int16 {
+	acc := make([]int32, n)
+	for id, f := range frames {
+		if id == exclude {
+			continue
+		}
+
+		for i := 0; i < n && i < len(f); i++ {
+			acc[i] += int32(f[i])
+		}
+	}
+
+	out := make([]int16, n)
+	for i, s := range acc {
+		out[i] = int16(max(math.MinInt16, min(math.MaxInt16, s)))
+	}
+
+	return out
+}
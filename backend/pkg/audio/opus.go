@@ -0,0 +1,100 @@
+//go:build opus && cgo
+
+package audio
+
+/*
+#cgo pkg-config: opus
+#include <opus.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// Opus through libopus, built with -tags opus. RTP Opus runs at 48 kHz,
+// libopus decodes to and encodes from 8, 12, 16, 24 or 48 kHz
+
+const (
+	// longest Opus packet, 120 ms
+	maxOpusFrameMs = 120
+	maxOpusPacket  = 4000
+)
+
+func init() {
+	RegisterDecoder(MimeOpus, newOpusDecoder)
+	RegisterEncoder(MimeOpus, newOpusEncoder)
+}
+
+type opusDecoder struct {
+	dec *C.OpusDecoder
+	pcm []int16
+}
+
+func newOpusDecoder(rate int) (Decoder, error) {
+	var cerr C.int
+	dec := C.opus_decoder_create(C.opus_int32(rate), 1, &cerr)
+	if cerr != C.OPUS_OK {
+		return nil, fmt.Errorf("opus decoder: error %d", int(cerr))
+	}
+
+	d := &opusDecoder{dec: dec, pcm: make([]int16, rate*maxOpusFrameMs/1000)}
+	runtime.SetFinalizer(d, func(d *opusDecoder) { C.opus_decoder_destroy(d.dec) })
+
+	return d, nil
+}
+
+func (d *opusDecoder) Decode(payload []byte) ([]int16, error) {
+	if len(payload) == 0 {
+		return nil, nil
+	}
+
+	n := C.opus_decode(d.dec,
+		(*C.uchar)(unsafe.Pointer(&payload[0])), C.opus_int32(len(payload)),
+		(*C.opus_int16)(unsafe.Pointer(&d.pcm[0])), C.int(len(d.pcm)), 0)
+	if n < 0 {
+		return nil, fmt.Errorf("opus decode: error %d", int(n))
+	}
+
+	out := make([]int16, int(n))
+	copy(out, d.pcm)
+	return out, nil
+}
+
+type opusEncoder struct {
+	enc *C.OpusEncoder
+	buf []byte
+}
+
+func newOpusEncoder(rate int) (Encoder, error) {
+	var cerr C.int
+	enc := C.opus_encoder_create(C.opus_int32(rate), 1, C.OPUS_APPLICATION_VOIP, &cerr)
+	if cerr != C.OPUS_OK {
+		return nil, fmt.Errorf("opus encoder: error %d", int(cerr))
+	}
+
+	e := &opusEncoder{enc: enc, buf: make([]byte, maxOpusPacket)}
+	runtime.SetFinalizer(e, func(e *opusEncoder) { C.opus_encoder_destroy(e.enc) })
+
+	return e, nil
+}
+
+// pcm must be one Opus frame, 2.5 to 60 ms
+func (e *opusEncoder) Encode(pcm []int16) ([]byte, error) {
+	if len(pcm) == 0 {
+		return nil, nil
+	}
+
+	n := C.opus_encode(e.enc,
+		(*C.opus_int16)(unsafe.Pointer(&pcm[0])), C.int(len(pcm)),
+		(*C.uchar)(unsafe.Pointer(&e.buf[0])), C.opus_int32(len(e.buf)))
+	if n < 0 {
+		return nil, fmt.Errorf("opus encode: error %d", int(n))
+	}
+
+	out := make([]byte, int(n))
+	copy(out, e.buf)
+	return out, nil
+}
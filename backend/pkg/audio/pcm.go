@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// samples in d at rate
+func Samples(rate int, d time.Duration) int {
+	return int(int64(rate) * int64(d) / int64(time.Second))
+}
+
+// linear interpolation between rates, good enough for speech
+func Resample(pcm []int16, from int, to int) []int16 {
+	if from == to || len(pcm) == 0 {
+		return pcm
+	}
+
+	n := len(pcm) * to / from
+	out := make([]int16, n)
+	step := float64(from) / float64(to)
+
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		if j >= len(pcm)-1 {
+			out[i] = pcm[len(pcm)-1]
+			continue
+		}
+
+		frac := pos - float64(j)
+		out[i] = int16(float64(pcm[j])*(1-frac) + float64(pcm[j+1])*frac)
+	}
+
+	return out
+}
+
+// Tone is a sine of freq Hz, for prompts and call progress
+func Tone(rate int, freq float64, d time.Duration) []int16 {
+	const gain = 8000
+
+	out := make([]int16, Samples(rate, d))
+	for i := range out {
+		out[i] = int16(gain * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+
+	return out
+}
+
+func Silence(rate int, d time.Duration) []int16 {
+	return make([]int16, Samples(rate, d))
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"time"
+	"vidcall/pkg/jwtx"
+	"vidcall/pkg/tlsx"
+	"vidcall/pkg/tracing"
+)
+
+// Settings are read from a JSON file, then the environment, then flags, each
+// overriding the one before. A field is set in the file under its json name,
+// in the environment under its env name, and on the command line with the env
+// name in lowercase and dashes, e.g. SFU_PORT is -sfu-port. The file is
+// given with -config or CONFIG_FILE
+
+type Redis struct {
+	Addr     string `json:"addr" env:"REDIS_URI"`
+	Password string `json:"password" env:"REDIS_PASSWORD"`
+	DB       int    `json:"db" env:"REDIS_DB"`
+}
+
+type Mongo struct {
+	URI  string `json:"uri" env:"MONGODB_URI"`
+	DB   string `json:"db" env:"DB_NAME"`
+	Pool uint64 `json:"pool" env:"MONGODB_POOL"`
+}
+
+type JWT struct {
+	// legacy HS256 secret, signs only when no private key is set
+	Secret     string `json:"secret" env:"JWT_SECRET"`
+	SigningKey string `json:"signingKey" env:"JWT_SIGNING_KEY"`
+	SigningKID string `json:"signingKID" env:"JWT_SIGNING_KID"`
+	// rotated public keys still accepted, as kid=path
+	VerifyKeys []string `json:"verifyKeys" env:"JWT_VERIFY_KEYS"`
+	// lifetime of the tokens the signaling server issues
+	TTL time.Duration `json:"ttl" env:"JWT_TTL"`
+}
+
+func (j JWT) Keys() jwtx.KeyFiles {
+	return jwtx.KeyFiles{
+		Secret:     j.Secret,
+		SigningKey: j.SigningKey,
+		SigningKID: j.SigningKID,
+		VerifyKeys: j.VerifyKeys,
+	}
+}
+
+// mutual TLS between signaling and SFU, each side uses its own cert
+type GRPCTLS struct {
+	CA   string `json:"ca" env:"GRPC_TLS_CA"`
+	Cert string `json:"cert" env:"GRPC_TLS_CERT"`
+	Key  string `json:"key" env:"GRPC_TLS_KEY"`
+}
+
+func (t GRPCTLS) Files() tlsx.Files {
+	return tlsx.Files{CA: t.CA, Cert: t.Cert, Key: t.Key}
+}
+
+type Tracing struct {
+	// none, stdout, file or otlp
+	Exporter string `json:"exporter" env:"OTEL_TRACES_EXPORTER"`
+	File     string `json:"file" env:"OTEL_TRACES_FILE"`
+}
+
+func (t Tracing) Options() tracing.Options {
+	return tracing.Options{Exporter: t.Exporter, File: t.File}
+}
+
+// ICE servers handed to every peer connection
+type ICE struct {
+	Stuns          []string `json:"stuns" env:"ICE_STUN_URLS"`
+	Turn           string   `json:"turn" env:"ICE_TURN_URL"`
+	TurnUsername   string   `json:"turnUsername" env:"ICE_TURN_USERNAME"`
+	TurnCredential string   `json:"turnCredential" env:"ICE_TURN_CREDENTIAL"`
+	// local candidates gathered this close together are sent as one burst,
+	// 0 sends each one at once
+	Debounce time.Duration `json:"debounce" env:"ICE_DEBOUNCE"`
+}
+
+// limits of one peer of the SFU
+type Peer struct {
+	// buffered messages and events on the way to the client, and SDP and
+	// ICE on the way in
+	Queue int `json:"queue" env:"SFU_PEER_QUEUE"`
+}
+
+type Room struct {
+	// peers waiting to be let in
+	JoinQueue int `json:"joinQueue" env:"SFU_ROOM_JOIN_QUEUE"`
+}
+
+type Egress struct {
+	// HLS output, temp dir when empty
+	Dir    string `json:"dir" env:"EGRESS_DIR"`
+	FFmpeg string `json:"ffmpeg" env:"FFMPEG_PATH"`
+}
+
+type STT struct {
+	// fake or command, captions are off when empty
+	Engine  string `json:"engine" env:"STT_ENGINE"`
+	Command string `json:"command" env:"STT_COMMAND"`
+}
+
+type SFU struct {
+	Port        string `json:"port" env:"SFU_PORT"`
+	MetricsPort string `json:"metricsPort" env:"SFU_METRICS_PORT"`
+	// peers get this long to leave on SIGTERM before rooms are ended
+	DrainTimeout time.Duration `json:"drainTimeout" env:"SFU_DRAIN_TIMEOUT"`
+
+	Redis   Redis   `json:"redis"`
+	Mongo   Mongo   `json:"mongo"`
+	JWT     JWT     `json:"jwt"`
+	TLS     GRPCTLS `json:"grpcTLS"`
+	Tracing Tracing `json:"tracing"`
+
+	ICE    ICE    `json:"ice"`
+	Peer   Peer   `json:"peer"`
+	Room   Room   `json:"room"`
+	Egress Egress `json:"egress"`
+	STT    STT    `json:"stt"`
+}
+
+type SIP struct {
+	// e.g. :5060, the gateway is off when empty
+	Addr     string `json:"addr" env:"SIP_ADDR"`
+	PublicIP string `json:"publicIP" env:"SIP_PUBLIC_IP"`
+}
+
+type Signaling struct {
+	Port        string `json:"port" env:"SIGNALING_PORT"`
+	MetricsPort string `json:"metricsPort" env:"SIGNALING_METRICS_PORT"`
+	// HTTPS when both are set
+	TLSCert string `json:"tlsCert" env:"TLS_CERT"`
+	TLSKey  string `json:"tlsKey" env:"TLS_KEY"`
+	// websocket clients get this long to leave on SIGTERM
+	DrainTimeout time.Duration `json:"drainTimeout" env:"SIGNALING_DRAIN_TIMEOUT"`
+	// bearer token of the admin API, off when empty
+	AdminToken string `json:"adminToken" env:"ADMIN_TOKEN"`
+
+	// SFU address, localhost and SFU_PORT when empty
+	SFUHost       string  `json:"sfuHost" env:"SFU_HOST"`
+	SFUPort       string  `json:"sfuPort" env:"SFU_PORT"`
+	TLS           GRPCTLS `json:"grpcTLS"`
+	TLSServerName string  `json:"grpcTLSServerName" env:"GRPC_TLS_SERVER_NAME"`
+
+	Redis   Redis   `json:"redis"`
+	Mongo   Mongo   `json:"mongo"`
+	JWT     JWT     `json:"jwt"`
+	Tracing Tracing `json:"tracing"`
+	SIP     SIP     `json:"sip"`
+}
+
+// SFU address to dial
+func (s *Signaling) SFUAddr() string {
+	if s.SFUHost != "" {
+		return s.SFUHost
+	}
+	return "localhost" + s.SFUPort
+}
+
+func defaultMongo() Mongo {
+	return Mongo{Pool: 10}
+}
+
+func defaultSFU() *SFU {
+	return &SFU{
+		DrainTimeout: 2 * time.Minute,
+		Mongo:        defaultMongo(),
+		ICE:          ICE{Stuns: []string{"stun:stun.l.google.com:19302"}, Debounce: 50 * time.Millisecond},
+		Peer:         Peer{Queue: 64},
+		Room:         Room{JoinQueue: 64},
+	}
+}
+
+func defaultSignaling() *Signaling {
+	return &Signaling{
+		DrainTimeout: 2 * time.Minute,
+		Mongo:        defaultMongo(),
+		JWT:          JWT{TTL: 2 * time.Hour},
+	}
+}
+
+// LoadSFU reads and validates the SFU settings, args are the command line
+// flags
+func LoadSFU(args []string) (*SFU, error) {
+	c := defaultSFU()
+	if err := load("sfu", args, c); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadSignaling reads and validates the signaling server settings, args are
+// the command line flags
+func LoadSignaling(args []string) (*Signaling, error) {
+	c := defaultSignaling()
+	if err := load("signaling", args, c); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// one setting of a settings struct
+type field struct {
+	v    reflect.Value
+	path string // in the file, e.g. redis.addr
+	env  string
+}
+
+// a flag seen on the command line, applied after the file and environment
+type flagValue struct {
+	f   *field
+	raw string
+}
+
+// helper function to fill c, a pointer to a settings struct, from the file,
+// the environment and the flags in that order
+func load(name string, args []string, c any) error {
+	fields := collect(reflect.ValueOf(c).Elem(), "")
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("CONFIG_FILE"), "JSON settings file, $CONFIG_FILE")
+
+	var flags []flagValue
+	for _, f := range fields {
+		fs.Func(flagName(f.env), "sets $"+f.env, func(raw string) error {
+			flags = append(flags, flagValue{f: f, raw: raw})
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *path != "" {
+		if err := loadFile(*path, fields); err != nil {
+			return err
+		}
+	}
+
+	// empty variables are unset, like the blank lines of env-template
+	for _, f := range fields {
+		if raw := os.Getenv(f.env); raw != "" {
+			if err := f.set(raw); err != nil {
+				return fmt.Errorf("$%s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, fv := range flags {
+		if err := fv.f.set(fv.raw); err != nil {
+			return fmt.Errorf("-%s: %w", flagName(fv.f.env), err)
+		}
+	}
+
+	return nil
+}
+
+// helper function to list the settings of a struct, nested structs are
+// objects in the file
+func collect(v reflect.Value, prefix string) []*field {
+	var fields []*field
+
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		path := prefix + sf.Tag.Get("json")
+
+		if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+			fields = append(fields, collect(v.Field(i), path+".")...)
+			continue
+		}
+
+		fields = append(fields, &field{v: v.Field(i), path: path, env: sf.Tag.Get("env")})
+	}
+
+	return fields
+}
+
+// SFU_PORT is -sfu-port
+func flagName(env string) string {
+	return strings.ToLower(strings.ReplaceAll(env, "_", "-"))
+}
+
+// helper function to apply a JSON settings file, unknown keys are errors so
+// typos do not go unnoticed
+func loadFile(path string, fields []*field) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	byPath := make(map[string]*field, len(fields))
+	for _, f := range fields {
+		byPath[f.path] = f
+	}
+
+	return applyJSON(path, doc, "", byPath)
+}
+
+func applyJSON(file string, doc map[string]any, prefix string, byPath map[string]*field) error {
+	for key, val := range doc {
+		path := prefix + key
+
+		if obj, ok := val.(map[string]any); ok {
+			if err := applyJSON(file, obj, path+".", byPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		f, ok := byPath[path]
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %q", file, path)
+		}
+
+		var err error
+		switch v := val.(type) {
+		case string:
+			err = f.set(v)
+		case json.Number:
+			err = f.set(v.String())
+		case bool:
+			err = f.set(strconv.FormatBool(v))
+		case []any:
+			err = f.setList(v)
+		default:
+			err = fmt.Errorf("unexpected %T", val)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", file, path, err)
+		}
+	}
+
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parse raw into the setting, lists are comma separated
+func (f *field) set(raw string) error {
+	switch {
+	case f.v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		f.v.SetInt(int64(d))
+
+	case f.v.Kind() == reflect.String:
+		f.v.SetString(raw)
+
+	case f.v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.v.SetBool(b)
+
+	case f.v.CanInt():
+		n, err := strconv.ParseInt(raw, 10, f.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.v.SetInt(n)
+
+	case f.v.CanUint():
+		n, err := strconv.ParseUint(raw, 10, f.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.v.SetUint(n)
+
+	case f.v.Kind() == reflect.Slice:
+		list := []string{}
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		f.v.Set(reflect.ValueOf(list))
+
+	default:
+		return fmt.Errorf("unsupported setting type %s", f.v.Type())
+	}
+
+	return nil
+}
+
+// helper function to set a list from a JSON array of strings
+func (f *field) setList(vals []any) error {
+	if f.v.Kind() != reflect.Slice {
+		return fmt.Errorf("expected a single value")
+	}
+
+	list := make([]string, 0, len(vals))
+	for _, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected a list of strings")
+		}
+		list = append(list, s)
+	}
+	f.v.Set(reflect.ValueOf(list))
+
+	return nil
+}
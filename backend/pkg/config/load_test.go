@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// helper function to unset every variable of the settings, empty ones are
+// ignored by load
+func clearEnv(t *testing.T, c any) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	for _, f := range collect(reflect.ValueOf(c).Elem(), "") {
+		t.Setenv(f.env, "")
+	}
+}
+
+func writeFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  string
+		flag string
+		want string
+	}{
+		{name: "default", want: ""},
+		{name: "file", file: ":1", want: ":1"},
+		{name: "env over file", file: ":1", env: ":2", want: ":2"},
+		{name: "flag over env", file: ":1", env: ":2", flag: ":3", want: ":3"},
+		{name: "flag over file", file: ":1", flag: ":3", want: ":3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultSFU()
+			clearEnv(t, c)
+
+			var args []string
+			if tt.file != "" {
+				args = append(args, "-config", writeFile(t, `{"port": "`+tt.file+`"}`))
+			}
+			if tt.env != "" {
+				t.Setenv("SFU_PORT", tt.env)
+			}
+			if tt.flag != "" {
+				args = append(args, "-sfu-port", tt.flag)
+			}
+
+			if err := load("sfu", args, c); err != nil {
+				t.Fatal(err)
+			}
+			if c.Port != tt.want {
+				t.Errorf("got %q, want %q", c.Port, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTypes(t *testing.T) {
+	c := defaultSFU()
+	clearEnv(t, c)
+
+	path := writeFile(t, `{
+		"drainTimeout": "30s",
+		"redis": {"addr": "redis:6379", "db": 2},
+		"ice": {"stuns": ["stun:a:3478", "stun:b:3478"]},
+		"mongo": {"pool": 5}
+	}`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("ICE_STUN_URLS", "stun:c:3478, stun:d:3478,")
+
+	if err := load("sfu", []string{"-redis-db", "3"}, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.DrainTimeout != 30*time.Second {
+		t.Errorf("drainTimeout: got %v", c.DrainTimeout)
+	}
+	if c.Redis.Addr != "redis:6379" || c.Redis.DB != 3 {
+		t.Errorf("redis: got %+v", c.Redis)
+	}
+	if c.Mongo.Pool != 5 {
+		t.Errorf("mongo pool: got %d", c.Mongo.Pool)
+	}
+	if want := []string{"stun:c:3478", "stun:d:3478"}; !slices.Equal(c.ICE.Stuns, want) {
+		t.Errorf("stuns: got %v, want %v", c.ICE.Stuns, want)
+	}
+	// untouched settings keep their defaults
+	if c.Peer.Queue != 64 {
+		t.Errorf("peer queue: got %d", c.Peer.Queue)
+	}
+	if c.ICE.Debounce != 50*time.Millisecond {
+		t.Errorf("ice debounce: got %v", c.ICE.Debounce)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{name: "unknown key", file: `{"prot": ":1"}`, want: `unknown setting "prot"`},
+		{name: "unknown nested key", file: `{"redis": {"address": "x"}}`, want: `unknown setting "redis.address"`},
+		{name: "bad duration in file", file: `{"drainTimeout": "soon"}`, want: "drainTimeout"},
+		{name: "list for a single value", file: `{"port": [":1"]}`, want: "expected a single value"},
+		{name: "bad JSON", file: `{"port": `, want: "config.json"},
+		{name: "bad env number", env: map[string]string{"REDIS_DB": "two"}, want: "$REDIS_DB"},
+		{name: "bad flag duration", args: []string{"-sfu-drain-timeout", "1x"}, want: "-sfu-drain-timeout"},
+		{name: "unknown flag", args: []string{"-nope"}, want: "nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultSFU()
+			clearEnv(t, c)
+
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.file)}, args...)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			err := load("sfu", args, c)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error with %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"vidcall/pkg/audio"
+)
+
+// collects every problem so they are fixed in one go
+type problems []error
+
+func (p *problems) add(env string, format string, args ...any) {
+	*p = append(*p, fmt.Errorf("%s: %s", env, fmt.Sprintf(format, args...)))
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(p...))
+}
+
+func (c *SFU) Validate() error {
+	var p problems
+
+	p.addr("SFU_PORT", c.Port, true)
+	p.addr("SFU_METRICS_PORT", c.MetricsPort, false)
+	p.positive("SFU_DRAIN_TIMEOUT", int64(c.DrainTimeout))
+
+	c.Redis.validate(&p)
+	c.Mongo.validate(&p, false)
+
+	// peers and the signaling server are only known by their tokens
+	c.JWT.validateKeys(&p)
+	if c.JWT.Secret == "" && c.JWT.SigningKey == "" && len(c.JWT.VerifyKeys) == 0 {
+		p.add("JWT_VERIFY_KEYS", "JWT_SECRET, JWT_SIGNING_KEY or JWT_VERIFY_KEYS is required")
+	}
+	c.TLS.validate(&p)
+	c.Tracing.validate(&p)
+
+	if len(c.ICE.Stuns) == 0 && c.ICE.Turn == "" {
+		p.add("ICE_STUN_URLS", "at least one STUN or TURN server is required")
+	}
+	for _, url := range c.ICE.Stuns {
+		if !strings.HasPrefix(url, "stun:") && !strings.HasPrefix(url, "stuns:") {
+			p.add("ICE_STUN_URLS", "%q is not a stun: URL", url)
+		}
+	}
+	if c.ICE.Turn != "" && !strings.HasPrefix(c.ICE.Turn, "turn:") && !strings.HasPrefix(c.ICE.Turn, "turns:") {
+		p.add("ICE_TURN_URL", "%q is not a turn: URL", c.ICE.Turn)
+	}
+	if c.ICE.Debounce < 0 {
+		p.add("ICE_DEBOUNCE", "must not be negative")
+	}
+
+	p.positive("SFU_PEER_QUEUE", int64(c.Peer.Queue))
+	p.positive("SFU_ROOM_JOIN_QUEUE", int64(c.Room.JoinQueue))
+
+	switch c.STT.Engine {
+	case "", "fake":
+	case "command":
+		if c.STT.Command == "" {
+			p.add("STT_COMMAND", "required with STT_ENGINE=command")
+		}
+	default:
+		p.add("STT_ENGINE", "unknown engine %q, expected fake or command", c.STT.Engine)
+	}
+
+	return p.err()
+}
+
+func (c *Signaling) Validate() error {
+	var p problems
+
+	p.addr("SIGNALING_PORT", c.Port, true)
+	p.addr("SIGNALING_METRICS_PORT", c.MetricsPort, false)
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		p.add("TLS_CERT", "TLS_CERT and TLS_KEY are set together")
+	}
+	p.positive("SIGNALING_DRAIN_TIMEOUT", int64(c.DrainTimeout))
+
+	if c.SFUHost == "" {
+		p.addr("SFU_PORT", c.SFUPort, true)
+	}
+
+	c.Redis.validate(&p)
+	c.Mongo.validate(&p, true)
+	c.TLS.validate(&p)
+	c.Tracing.validate(&p)
+
+	// tokens cannot be issued without a signing key
+	c.JWT.validateKeys(&p)
+	if c.JWT.Secret == "" && c.JWT.SigningKey == "" {
+		p.add("JWT_SIGNING_KEY", "JWT_SIGNING_KEY or JWT_SECRET is required")
+	}
+	p.positive("JWT_TTL", int64(c.JWT.TTL))
+
+	p.addr("SIP_ADDR", c.SIP.Addr, false)
+	// phone callers hear the room mixed from its Opus tracks
+	if c.SIP.Addr != "" && !audio.CanDecode(audio.MimeOpus) {
+		p.add("SIP_ADDR", "the SIP gateway needs Opus, build with -tags opus")
+	}
+	if c.SIP.PublicIP != "" && net.ParseIP(c.SIP.PublicIP) == nil {
+		p.add("SIP_PUBLIC_IP", "%q is not an IP address", c.SIP.PublicIP)
+	}
+
+	return p.err()
+}
+
+func (r Redis) validate(p *problems) {
+	if r.Addr == "" {
+		p.add("REDIS_URI", "required")
+		return
+	}
+	p.addr("REDIS_URI", r.Addr, true)
+}
+
+// the SFU runs without MongoDB, summaries and transcripts are not stored
+func (m Mongo) validate(p *problems, required bool) {
+	if required && m.URI == "" {
+		p.add("MONGODB_URI", "required")
+	}
+	if m.URI != "" && m.DB == "" {
+		p.add("DB_NAME", "required with MONGODB_URI")
+	}
+	if m.Pool == 0 {
+		p.add("MONGODB_POOL", "must be positive")
+	}
+}
+
+func (j JWT) validateKeys(p *problems) {
+	if j.SigningKey != "" && j.SigningKID == "" {
+		p.add("JWT_SIGNING_KID", "required with JWT_SIGNING_KEY")
+	}
+	for _, entry := range j.VerifyKeys {
+		if kid, path, ok := strings.Cut(entry, "="); !ok || kid == "" || path == "" {
+			p.add("JWT_VERIFY_KEYS", "%q is not kid=path", entry)
+		}
+	}
+}
+
+func (t GRPCTLS) validate(p *problems) {
+	set := 0
+	for _, v := range []string{t.CA, t.Cert, t.Key} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 0 && set != 3 {
+		p.add("GRPC_TLS_CA", "mutual TLS needs GRPC_TLS_CA, GRPC_TLS_CERT and GRPC_TLS_KEY")
+	}
+}
+
+func (t Tracing) validate(p *problems) {
+	switch t.Exporter {
+	case "", "none", "stdout", "otlp":
+	case "file":
+		if t.File == "" {
+			p.add("OTEL_TRACES_FILE", "required for the file exporter")
+		}
+	default:
+		p.add("OTEL_TRACES_EXPORTER", "unknown exporter %q", t.Exporter)
+	}
+}
+
+// helper function to check a listen or dial address, e.g. :50051
+func (p *problems) addr(env string, addr string, required bool) {
+	if addr == "" {
+		if required {
+			p.add(env, "required")
+		}
+		return
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		p.add(env, "%q is not host:port", addr)
+	}
+}
+
+func (p *problems) positive(env string, n int64) {
+	if n <= 0 {
+		p.add(env, "must be positive")
+	}
+}
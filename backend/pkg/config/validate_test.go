@@ -0,0 +1,120 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vidcall/pkg/audio"
+)
+
+func validSFU() *SFU {
+	c := defaultSFU()
+	c.Port = ":50051"
+	c.Redis.Addr = "localhost:6379"
+	c.JWT.Secret = "secret"
+	return c
+}
+
+func validSignaling() *Signaling {
+	c := defaultSignaling()
+	c.Port = ":8080"
+	c.SFUPort = ":50051"
+	c.Redis.Addr = "localhost:6379"
+	c.Mongo.URI = "mongodb://localhost:27017"
+	c.Mongo.DB = "vidcall"
+	c.JWT.Secret = "secret"
+	return c
+}
+
+func TestSFUValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *SFU)
+		// settings named in the error, none when valid
+		want []string
+	}{
+		{name: "valid", change: func(c *SFU) {}},
+		{name: "missing port", change: func(c *SFU) { c.Port = "" }, want: []string{"SFU_PORT"}},
+		{name: "bad metrics port", change: func(c *SFU) { c.MetricsPort = "9090" }, want: []string{"SFU_METRICS_PORT"}},
+		{name: "no keys", change: func(c *SFU) { c.JWT.Secret = "" }, want: []string{"JWT_VERIFY_KEYS"}},
+		{name: "verify keys only", change: func(c *SFU) { c.JWT.Secret = ""; c.JWT.VerifyKeys = []string{"k1=/keys/k1.pem"} }},
+		{name: "bad verify key", change: func(c *SFU) { c.JWT.VerifyKeys = []string{"/keys/k1.pem"} }, want: []string{"JWT_VERIFY_KEYS"}},
+		{name: "signing key without kid", change: func(c *SFU) { c.JWT.SigningKey = "/keys/k.pem" }, want: []string{"JWT_SIGNING_KID"}},
+		{name: "partial mutual TLS", change: func(c *SFU) { c.TLS.CA = "/ca.pem" }, want: []string{"GRPC_TLS_CA"}},
+		{name: "not a stun URL", change: func(c *SFU) { c.ICE.Stuns = []string{"turn:a"} }, want: []string{"ICE_STUN_URLS"}},
+		{name: "no ICE servers", change: func(c *SFU) { c.ICE.Stuns = nil }, want: []string{"ICE_STUN_URLS"}},
+		{name: "no ICE debounce", change: func(c *SFU) { c.ICE.Debounce = 0 }},
+		{name: "negative ICE debounce", change: func(c *SFU) { c.ICE.Debounce = -time.Millisecond }, want: []string{"ICE_DEBOUNCE"}},
+		{name: "unknown stt engine", change: func(c *SFU) { c.STT.Engine = "cloud" }, want: []string{"STT_ENGINE"}},
+		{name: "command engine without command", change: func(c *SFU) { c.STT.Engine = "command" }, want: []string{"STT_COMMAND"}},
+		{
+			name:   "every problem at once",
+			change: func(c *SFU) { c.Port = ""; c.Redis.Addr = ""; c.Peer.Queue = 0; c.Tracing.Exporter = "zipkin" },
+			want:   []string{"SFU_PORT", "REDIS_URI", "SFU_PEER_QUEUE", "OTEL_TRACES_EXPORTER"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validSFU()
+			tt.change(c)
+			checkProblems(t, c.Validate(), tt.want)
+		})
+	}
+}
+
+func TestSignalingValidate(t *testing.T) {
+	// the gateway needs Opus, which depends on the build tags
+	var noOpus []string
+	if !audio.CanDecode(audio.MimeOpus) {
+		noOpus = []string{"SIP_ADDR"}
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *Signaling)
+		want   []string
+	}{
+		{name: "valid", change: func(c *Signaling) {}},
+		{name: "SFU host instead of port", change: func(c *Signaling) { c.SFUPort = ""; c.SFUHost = "sfu:50051" }},
+		{name: "no SFU address", change: func(c *Signaling) { c.SFUPort = "" }, want: []string{"SFU_PORT"}},
+		{name: "bad metrics port", change: func(c *Signaling) { c.MetricsPort = "metrics" }, want: []string{"SIGNALING_METRICS_PORT"}},
+		{name: "cert without key", change: func(c *Signaling) { c.TLSCert = "/cert.pem" }, want: []string{"TLS_CERT"}},
+		{name: "verify keys cannot sign", change: func(c *Signaling) { c.JWT.Secret = ""; c.JWT.VerifyKeys = []string{"k1=/k1.pem"} }, want: []string{"JWT_SIGNING_KEY"}},
+		{name: "no mongo", change: func(c *Signaling) { c.Mongo.URI = "" }, want: []string{"MONGODB_URI"}},
+		{name: "mongo without db", change: func(c *Signaling) { c.Mongo.DB = "" }, want: []string{"DB_NAME"}},
+		{name: "bad SIP public IP", change: func(c *Signaling) { c.SIP.PublicIP = "example.com" }, want: []string{"SIP_PUBLIC_IP"}},
+		{name: "zero token lifetime", change: func(c *Signaling) { c.JWT.TTL = 0 }, want: []string{"JWT_TTL"}},
+		{name: "SIP gateway", change: func(c *Signaling) { c.SIP.Addr = ":5060" }, want: noOpus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validSignaling()
+			tt.change(c)
+			checkProblems(t, c.Validate(), tt.want)
+		})
+	}
+}
+
+// helper function to check the error names every wanted setting, or that
+// there is none
+func checkProblems(t *testing.T, err error, want []string) {
+	t.Helper()
+
+	if len(want) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+
+	if err == nil {
+		t.Fatalf("got no error, want %v", want)
+	}
+	for _, env := range want {
+		if !strings.Contains(err.Error(), env+":") {
+			t.Errorf("error does not name %s: %v", env, err)
+		}
+	}
+}
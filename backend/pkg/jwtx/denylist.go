@@ -0,0 +1,72 @@
+package jwtx
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	denyPrefix    = "jwt:deny:"
+	sessionPrefix = "jwt:session:"
+)
+
+// Denylist keeps revoked token IDs (jti) in Redis until the token would
+// have expired anyway.
+type Denylist struct {
+	rdb *goredis.Client
+}
+
+func NewDenylist(rdb *goredis.Client) *Denylist {
+	return &Denylist{rdb: rdb}
+}
+
+func (d *Denylist) Revoke(ctx context.Context, jti string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return d.rdb.Set(ctx, denyPrefix+jti, 1, ttl).Err()
+}
+
+func (d *Denylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := d.rdb.Exists(ctx, denyPrefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// remember which token a room member holds so it can be revoked by peer ID
+func (d *Denylist) Track(ctx context.Context, roomID string, peerID string, jti string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return d.rdb.Set(ctx, sessionPrefix+roomID+":"+peerID, jti, ttl).Err()
+}
+
+// revoke the token held by a room member
+func (d *Denylist) RevokePeer(ctx context.Context, roomID string, peerID string) error {
+	key := sessionPrefix + roomID + ":" + peerID
+
+	jti, err := d.rdb.Get(ctx, key).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ttl, err := d.rdb.TTL(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	return d.Revoke(ctx, jti, time.Now().Add(ttl))
+}
@@ -0,0 +1,207 @@
+package jwtx
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// fakeRedis speaks just enough RESP2 for the denylist commands
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	exp  map[string]time.Time
+}
+
+func newFakeRedis(t *testing.T) *goredis.Client {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{data: make(map[string]string), exp: make(map[string]time.Time)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	rdb := goredis.NewClient(&goredis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { rdb.Close() })
+
+	return rdb
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte(f.exec(args))); err != nil {
+			return
+		}
+	}
+}
+
+// helper function to read one RESP array of bulk strings
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+
+	return args, nil
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for k, at := range f.exp {
+		if time.Now().After(at) {
+			delete(f.data, k)
+			delete(f.exp, k)
+		}
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "set":
+		f.data[args[1]] = args[2]
+		if len(args) == 5 {
+			n, _ := strconv.Atoi(args[4])
+			unit := time.Second
+			if strings.EqualFold(args[3], "px") {
+				unit = time.Millisecond
+			}
+			f.exp[args[1]] = time.Now().Add(time.Duration(n) * unit)
+		}
+		return "+OK\r\n"
+	case "get":
+		v, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "exists":
+		if _, ok := f.data[args[1]]; ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "ttl":
+		if _, ok := f.data[args[1]]; !ok {
+			return ":-2\r\n"
+		}
+		at, ok := f.exp[args[1]]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", int(time.Until(at).Seconds()))
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func TestDenylistRevoke(t *testing.T) {
+	ctx := context.Background()
+	d := NewDenylist(newFakeRedis(t))
+
+	if err := d.Revoke(ctx, "live", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	// already expired tokens are never stored
+	if err := d.Revoke(ctx, "expired", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		jti  string
+		want bool
+	}{
+		{"live", true},
+		{"expired", false},
+		{"unknown", false},
+	}
+
+	for _, tt := range tests {
+		got, err := d.IsRevoked(ctx, tt.jti)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("IsRevoked(%q) = %v, want %v", tt.jti, got, tt.want)
+		}
+	}
+}
+
+func TestDenylistRevokePeer(t *testing.T) {
+	ctx := context.Background()
+	d := NewDenylist(newFakeRedis(t))
+
+	if err := d.Track(ctx, "room", "alice", "jti-alice", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Track(ctx, "room", "bob", "jti-bob", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.RevokePeer(ctx, "room", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	// a peer without a tracked token is not an error
+	if err := d.RevokePeer(ctx, "room", "carol"); err != nil {
+		t.Fatalf("RevokePeer() untracked = %v", err)
+	}
+
+	tests := []struct {
+		jti  string
+		want bool
+	}{
+		{"jti-alice", true},
+		{"jti-bob", false},
+	}
+
+	for _, tt := range tests {
+		got, err := d.IsRevoked(ctx, tt.jti)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("IsRevoked(%q) = %v, want %v", tt.jti, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package jwtx
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Public verification keys, shared secrets are never published
+func (k *Keyring) JWKS() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	enc := base64.RawURLEncoding
+
+	for kid, key := range k.keys {
+		switch pub := key.Verify.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   enc.EncodeToString(pub.N.Bytes()),
+				E:   enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   enc.EncodeToString(pub),
+			})
+		}
+	}
+
+	return set
+}
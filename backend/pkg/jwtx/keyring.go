@@ -0,0 +1,273 @@
+package jwtx
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no signing key configured")
+	ErrUnknownKey   = errors.New("unknown key id")
+	ErrBadKey       = errors.New("unsupported key type")
+)
+
+// Key is a single JWT key. Sign is nil for verification-only keys.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	Sign   any
+	Verify any
+}
+
+// Keyring holds one active signing key and every key still accepted for
+// verification, so old tokens stay valid while keys are rotated.
+type Keyring struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]*Key)}
+}
+
+// set the active signing key, it is also trusted for verification
+func (k *Keyring) SetSigningKey(key *Key) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.signing = key
+	k.keys[key.ID] = key
+}
+
+func (k *Keyring) AddVerifyKey(key *Key) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[key.ID] = key
+}
+
+func (k *Keyring) RemoveKey(kid string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.keys, kid)
+	if k.signing != nil && k.signing.ID == kid {
+		k.signing = nil
+	}
+}
+
+func (k *Keyring) SigningKey() (*Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+	return k.signing, nil
+}
+
+func (k *Keyring) Empty() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.keys) == 0
+}
+
+// Sign the claims with the active key and stamp its kid in the header
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	key, err := k.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Sign)
+}
+
+// Parse and verify raw into claims using the key named by the kid header
+func (k *Keyring) Parse(raw string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(raw, claims, k.keyfunc, jwt.WithValidMethods(k.methods()))
+	if err != nil {
+		return err
+	}
+
+	if !token.Valid {
+		return jwt.ErrTokenInvalidClaims
+	}
+
+	return nil
+}
+
+func (k *Keyring) keyfunc(t *jwt.Token) (any, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	kid, _ := t.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	// never let a token pick a different algorithm than its key
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	return key.Verify, nil
+}
+
+func (k *Keyring) methods() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	methods := []string{}
+	for _, key := range k.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	return methods
+}
+
+// HMAC key from a shared secret
+func SecretKey(kid string, secret string) *Key {
+	return &Key{
+		ID:     kid,
+		Method: jwt.SigningMethodHS256,
+		Sign:   []byte(secret),
+		Verify: []byte(secret),
+	}
+}
+
+// Load a PEM encoded RSA or Ed25519 private key
+func LoadPrivateKey(kid string, path string) (*Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var priv any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch pk := priv.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, Sign: pk, Verify: pk.Public()}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Sign: pk, Verify: pk.Public()}, nil
+	default:
+		return nil, ErrBadKey
+	}
+}
+
+// Load a PEM encoded RSA or Ed25519 public key
+func LoadPublicKey(kid string, path string) (*Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pub any
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return publicKey(kid, pub)
+}
+
+func publicKey(kid string, pub crypto.PublicKey) (*Key, error) {
+	switch pk := pub.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, Verify: pk}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Verify: pk}, nil
+	default:
+		return nil, ErrBadKey
+	}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", path)
+	}
+
+	return block, nil
+}
+
+// Key material of a keyring
+type KeyFiles struct {
+	// legacy HS256 secret, signs only when no private key is set
+	Secret string
+	// path to the active PEM private key (RS256 or EdDSA) and its kid
+	SigningKey string
+	SigningKID string
+	// kid=path public keys still accepted after rotation
+	VerifyKeys []string
+}
+
+// Build a keyring from its key files
+func Load(f KeyFiles) (*Keyring, error) {
+	k := NewKeyring()
+
+	if f.Secret != "" {
+		k.SetSigningKey(SecretKey("hs256", f.Secret))
+	}
+
+	if f.SigningKey != "" {
+		if f.SigningKID == "" {
+			return nil, errors.New("a kid is required with the signing key")
+		}
+
+		key, err := LoadPrivateKey(f.SigningKID, f.SigningKey)
+		if err != nil {
+			return nil, fmt.Errorf("load signing key: %w", err)
+		}
+		k.SetSigningKey(key)
+	}
+
+	for _, entry := range f.VerifyKeys {
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid verify key entry %q", entry)
+		}
+
+		key, err := LoadPublicKey(kid, path)
+		if err != nil {
+			return nil, fmt.Errorf("load verify key %s: %w", kid, err)
+		}
+		k.AddVerifyKey(key)
+	}
+
+	return k, nil
+}
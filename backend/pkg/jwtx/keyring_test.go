@@ -0,0 +1,124 @@
+package jwtx
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func edKey(t *testing.T, kid string) *Key {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Sign: priv, Verify: pub}
+}
+
+// helper function to sign claims with any key and header, bypassing the keyring
+func signRaw(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func TestKeyringParse(t *testing.T) {
+	ed := edKey(t, "ed")
+	other := edKey(t, "other")
+	hs := SecretKey("hs", "secret")
+
+	k := NewKeyring()
+	k.SetSigningKey(ed)
+	k.AddVerifyKey(hs)
+
+	tests := []struct {
+		name string
+		raw  string
+		err  error
+	}{
+		{"signing key", signRaw(t, ed.Method, "ed", ed.Sign), nil},
+		{"verify key", signRaw(t, hs.Method, "hs", hs.Sign), nil},
+		{"unknown kid", signRaw(t, other.Method, "other", other.Sign), ErrUnknownKey},
+		{"missing kid", signRaw(t, ed.Method, "", ed.Sign), ErrUnknownKey},
+		{"wrong key for kid", signRaw(t, other.Method, "ed", other.Sign), jwt.ErrTokenSignatureInvalid},
+		// HS256 signed with the public key of an EdDSA kid
+		{"alg confusion", signRaw(t, jwt.SigningMethodHS256, "ed", []byte(ed.Verify.(ed25519.PublicKey))), jwt.ErrTokenSignatureInvalid},
+		{"alg of another kid", signRaw(t, jwt.SigningMethodHS256, "ed", hs.Sign), jwt.ErrTokenSignatureInvalid},
+		{"alg none", signRaw(t, jwt.SigningMethodNone, "ed", jwt.UnsafeAllowNoneSignatureType), jwt.ErrTokenSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := k.Parse(tt.raw, &jwt.RegisteredClaims{})
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("Parse() = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestKeyringRejectsUnknownAlg(t *testing.T) {
+	ed := edKey(t, "ed")
+
+	k := NewKeyring()
+	k.SetSigningKey(ed)
+
+	// no HMAC key in the ring, so HS256 is not even considered
+	raw := signRaw(t, jwt.SigningMethodHS256, "ed", []byte("secret"))
+	if err := k.Parse(raw, &jwt.RegisteredClaims{}); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Fatalf("Parse() = %v, want %v", err, jwt.ErrTokenSignatureInvalid)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	old := edKey(t, "old")
+	next := edKey(t, "next")
+
+	k := NewKeyring()
+	k.SetSigningKey(old)
+
+	raw, err := k.Sign(jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tokens of the previous key stay valid after rotation
+	k.SetSigningKey(next)
+	if err := k.Parse(raw, &jwt.RegisteredClaims{}); err != nil {
+		t.Fatalf("Parse() after rotation = %v", err)
+	}
+
+	k.RemoveKey("old")
+	if err := k.Parse(raw, &jwt.RegisteredClaims{}); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Parse() after removal = %v, want %v", err, ErrUnknownKey)
+	}
+
+	k.RemoveKey("next")
+	if _, err := k.Sign(jwt.RegisteredClaims{}); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("Sign() without key = %v, want %v", err, ErrNoSigningKey)
+	}
+}
@@ -6,6 +6,12 @@ import (
 	"net/http"
 	"os"
 	"time"
+	"vidcall/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type logKey struct{}
@@ -19,12 +25,20 @@ func init() {
 func SlogMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		reqLog := slog.Default().With(
+		// continue the caller's trace if any
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method+" "+r.URL.Path,
+			attribute.String("http.method", r.Method),
+			attribute.String("http.path", r.URL.Path),
+		)
+		defer span.End()
+
+		reqLog := WithTrace(ctx, slog.Default().With(
 			"method", r.Method,
 			"path", r.URL.Path,
-		)
+		))
 
-		ctx := context.WithValue(r.Context(), logKey{}, reqLog)
+		ctx = context.WithValue(ctx, logKey{}, reqLog)
 		r = r.WithContext(ctx)
 
 		start := time.Now()
@@ -39,5 +53,25 @@ func GetLog(ctx context.Context) *slog.Logger {
 		return l
 	}
 
-	return slog.Default()
+	return WithTrace(ctx, slog.Default())
+}
+
+// attach the trace ID of ctx to log records
+func WithTrace(ctx context.Context, log *slog.Logger) *slog.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return log
+	}
+
+	return log.With("trace_id", sc.TraceID().String())
+}
+
+// attach the span ID of ctx, for loggers that already carry the trace ID
+func WithSpan(ctx context.Context, log *slog.Logger) *slog.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return log
+	}
+
+	return log.With("span_id", sc.SpanID().String())
 }
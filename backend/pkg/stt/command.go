@@ -0,0 +1,92 @@
+package stt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// longest an engine may take on one utterance
+const commandTimeout = 30 * time.Second
+
+// Command runs an offline engine once per utterance. The path of a 16 kHz
+// mono WAV is appended to the command line and stdout is the text, e.g.
+// whisper.cpp as "whisper-cli -m ggml-base.en.bin -nt -np -f"
+type Command struct {
+	args []string
+}
+
+func NewCommand(cmdline string) (*Command, error) {
+	args := strings.Fields(cmdline)
+	if len(args) == 0 {
+		return nil, errors.New("no speech to text command")
+	}
+
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, err
+	}
+
+	return &Command{args: args}, nil
+}
+
+func (c *Command) Transcribe(ctx context.Context, pcm <-chan []int16) <-chan Segment {
+	return transcribe(ctx, pcm, c)
+}
+
+func (c *Command) recognize(ctx context.Context, pcm []int16) (string, error) {
+	f, err := os.CreateTemp("", "vidcall-stt-*.wav")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	err = writeWAV(f, pcm, Rate)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	args := append(c.args[1:len(c.args):len(c.args)], f.Name())
+	out, err := exec.CommandContext(runCtx, c.args[0], args...).Output()
+	if err != nil {
+		return "", err
+	}
+
+	// engines print one line per chunk, captions are one line
+	return strings.Join(strings.Fields(string(out)), " "), nil
+}
+
+// helper function to write PCM as a 16 bit mono WAV file
+func writeWAV(w io.Writer, pcm []int16, rate int) error {
+	bw := bufio.NewWriter(w)
+	size := uint32(len(pcm) * 2)
+
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + size, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16),
+		uint16(1), uint16(1), uint32(rate), uint32(rate * 2), uint16(2), uint16(16),
+		[4]byte{'d', 'a', 't', 'a'}, size,
+	}
+	for _, v := range header {
+		if err := binary.Write(bw, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(bw, binary.LittleEndian, pcm); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,125 @@
+package stt
+
+import (
+	"math"
+	"time"
+)
+
+// utterances end after a pause, or at maxUtterance so captions keep up
+// with a long monologue
+const (
+	vadFrame     = 20 * time.Millisecond
+	speechRMS    = 500
+	silenceHang  = 700 * time.Millisecond
+	minUtterance = 300 * time.Millisecond
+	maxUtterance = 10 * time.Second
+)
+
+type utterance struct {
+	start time.Duration
+	end   time.Duration
+	pcm   []int16
+}
+
+// segmenter is an energy based voice activity detector, it cuts a stream
+// into the utterances worth sending to an engine
+type segmenter struct {
+	rate  int
+	frame int
+
+	// samples short of a whole frame
+	pending []int16
+	pos     time.Duration
+
+	speaking bool
+	start    time.Duration
+	silent   time.Duration
+	cur      []int16
+}
+
+func newSegmenter(rate int) *segmenter {
+	return &segmenter{
+		rate:  rate,
+		frame: int(int64(rate) * int64(vadFrame) / int64(time.Second)),
+	}
+}
+
+// feed samples, the utterances they complete are returned
+func (s *segmenter) push(pcm []int16) []utterance {
+	var done []utterance
+
+	s.pending = append(s.pending, pcm...)
+	for len(s.pending) >= s.frame {
+		frame := s.pending[:s.frame]
+
+		if u, ok := s.step(frame); ok {
+			done = append(done, u)
+		}
+		s.pending = s.pending[s.frame:]
+	}
+
+	// keep the backing array from growing with the stream
+	s.pending = append([]int16(nil), s.pending...)
+
+	return done
+}
+
+// the utterance in progress when the stream ends
+func (s *segmenter) flush() (utterance, bool) {
+	if !s.speaking {
+		return utterance{}, false
+	}
+	return s.cut()
+}
+
+func (s *segmenter) step(frame []int16) (utterance, bool) {
+	if rms(frame) >= speechRMS {
+		if !s.speaking {
+			s.speaking = true
+			s.start = s.pos
+			s.cur = nil
+		}
+		s.silent = 0
+	} else if s.speaking {
+		s.silent += vadFrame
+	}
+
+	if s.speaking {
+		s.cur = append(s.cur, frame...)
+	}
+	s.pos += vadFrame
+
+	if s.speaking && (s.silent >= silenceHang || s.pos-s.start >= maxUtterance) {
+		return s.cut()
+	}
+
+	return utterance{}, false
+}
+
+// end the utterance in progress, trailing silence left out and blips too
+// short to be words dropped
+func (s *segmenter) cut() (utterance, bool) {
+	trail := int(int64(s.rate) * int64(s.silent) / int64(time.Second))
+	u := utterance{
+		start: s.start,
+		end:   s.pos - s.silent,
+		pcm:   s.cur[:len(s.cur)-trail],
+	}
+
+	s.speaking = false
+	s.silent = 0
+	s.cur = nil
+
+	if u.end-u.start < minUtterance {
+		return utterance{}, false
+	}
+	return u, true
+}
+
+func rms(frame []int16) float64 {
+	var sum float64
+	for _, v := range frame {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum / float64(len(frame)))
+}
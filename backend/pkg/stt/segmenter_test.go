@@ -0,0 +1,118 @@
+package stt
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"vidcall/pkg/audio"
+)
+
+// a stretch of audio, speech is a tone loud enough for the detector
+type part struct {
+	speech bool
+	d      time.Duration
+}
+
+func speech(d time.Duration) part  { return part{true, d} }
+func silence(d time.Duration) part { return part{false, d} }
+
+func TestFakeSegments(t *testing.T) {
+	s := time.Second
+	ms := time.Millisecond
+
+	tests := []struct {
+		name  string
+		parts []part
+		want  []Segment
+	}{
+		{
+			name:  "silence only",
+			parts: []part{silence(3 * s)},
+		},
+		{
+			name:  "one utterance",
+			parts: []part{silence(s), speech(s), silence(s)},
+			want:  []Segment{{s, 2 * s, "utterance 1 (1.0s)"}},
+		},
+		{
+			name:  "blip too short to be words",
+			parts: []part{speech(100 * ms), silence(s)},
+		},
+		{
+			name:  "short pause stays in the utterance",
+			parts: []part{speech(500 * ms), silence(300 * ms), speech(500 * ms), silence(s)},
+			want:  []Segment{{0, 1300 * ms, "utterance 1 (1.3s)"}},
+		},
+		{
+			name:  "long pause splits",
+			parts: []part{speech(500 * ms), silence(s), speech(500 * ms), silence(s)},
+			want: []Segment{
+				{0, 500 * ms, "utterance 1 (0.5s)"},
+				{1500 * ms, 2 * s, "utterance 2 (0.5s)"},
+			},
+		},
+		{
+			name:  "monologue cut at the longest utterance",
+			parts: []part{speech(12 * s)},
+			want: []Segment{
+				{0, 10 * s, "utterance 1 (10.0s)"},
+				{10 * s, 12 * s, "utterance 2 (2.0s)"},
+			},
+		},
+		{
+			name:  "stream ends mid utterance",
+			parts: []part{silence(200 * ms), speech(s)},
+			want:  []Segment{{200 * ms, 1200 * ms, "utterance 1 (1.0s)"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pcm []int16
+			for _, p := range tt.parts {
+				if p.speech {
+					pcm = append(pcm, audio.Tone(Rate, 440, p.d)...)
+				} else {
+					pcm = append(pcm, audio.Silence(Rate, p.d)...)
+				}
+			}
+
+			// chunks that do not line up with the detector frames
+			in := make(chan []int16, len(pcm)/333+1)
+			for chunk := range slices.Chunk(pcm, 333) {
+				in <- chunk
+			}
+			close(in)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			var got []Segment
+			for seg := range NewFake().Transcribe(ctx, in) {
+				got = append(got, seg)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	in := make(chan []int16)
+	out := NewFake().Transcribe(ctx, in)
+	cancel()
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("got a segment after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("segments not closed after cancel")
+	}
+}
@@ -0,0 +1,98 @@
+package stt
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"vidcall/pkg/logger"
+)
+
+// sample rate engines are fed with, mono 16 bit PCM
+const Rate = 16000
+
+// Segment is one utterance recognized in a stream, offsets count from the
+// first sample
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// SpeechToText turns the audio of one speaker into text
+type SpeechToText interface {
+	// Transcribe reads PCM at Rate until pcm is closed or ctx is done, the
+	// returned channel is closed after the last segment
+	Transcribe(ctx context.Context, pcm <-chan []int16) <-chan Segment
+}
+
+// Engine by name, "fake" or "command" with its command line. Nil when the
+// name is empty
+func New(engine string, cmdline string) (SpeechToText, error) {
+	switch engine {
+	case "":
+		return nil, nil
+	case "fake":
+		return NewFake(), nil
+	case "command":
+		return NewCommand(cmdline)
+	default:
+		return nil, fmt.Errorf("unknown speech to text engine %q", engine)
+	}
+}
+
+// recognizes one utterance at a time
+type recognizer interface {
+	recognize(ctx context.Context, pcm []int16) (string, error)
+}
+
+// helper function to cut a stream into utterances and recognize them in
+// order, shared by the engines that only take whole utterances
+func transcribe(ctx context.Context, pcm <-chan []int16, r recognizer) <-chan Segment {
+	out := make(chan Segment, 16)
+	log := logger.GetLog(ctx).With("layer", "stt")
+
+	emit := func(u utterance) bool {
+		text, err := r.recognize(ctx, u.pcm)
+		if err != nil {
+			log.Warn("unable to recognize utterance", "err", err)
+			return ctx.Err() == nil
+		}
+		if text == "" {
+			return true
+		}
+
+		select {
+		case out <- Segment{Start: u.start, End: u.end, Text: text}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(out)
+		seg := newSegmenter(Rate)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case frame, ok := <-pcm:
+				if !ok {
+					if u, ok := seg.flush(); ok {
+						emit(u)
+					}
+					return
+				}
+
+				for _, u := range seg.push(frame) {
+					if !emit(u) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,84 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Files of one side of a mutual TLS link
+type Files struct {
+	CA   string
+	Cert string
+	Key  string
+}
+
+func (f Files) Enabled() bool {
+	return f.CA != "" || f.Cert != "" || f.Key != ""
+}
+
+func (f Files) validate() error {
+	if f.CA == "" || f.Cert == "" || f.Key == "" {
+		return errors.New("mutual TLS needs a CA, a certificate and a key")
+	}
+	return nil
+}
+
+// Server side config: peers must present a certificate signed by the CA
+func ServerConfig(f Files) (*tls.Config, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	cert, pool, err := load(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
+// Client side config: the server must present a certificate signed by the CA
+func ClientConfig(f Files, serverName string) (*tls.Config, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	cert, pool, err := load(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   serverName,
+	}, nil
+}
+
+func load(f Files) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(f.Cert, f.Key)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("load key pair: %w", err)
+	}
+
+	ca, err := os.ReadFile(f.CA)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("read CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return tls.Certificate{}, nil, errors.New("no certificates in CA file")
+	}
+
+	return cert, pool, nil
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "vidcall"
+
+// Exporter of the spans:
+//
+//	none    (default) spans are propagated but not exported
+//	stdout  pretty printed spans on stdout
+//	file    JSON spans appended to File, for offline debugging
+//	otlp    OTLP/gRPC, configured by the standard OTEL_EXPORTER_OTLP_* env
+type Options struct {
+	Exporter string
+	File     string
+}
+
+// Init installs the global tracer provider and W3C propagator.
+// The returned func flushes and stops the exporter.
+func Init(ctx context.Context, service string, o Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", service),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, o Options) (sdktrace.SpanExporter, error) {
+	switch o.Exporter {
+	case "", "none":
+		return nil, nil
+
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+
+	case "file":
+		if o.File == "" {
+			return nil, fmt.Errorf("a file is required for the file exporter")
+		}
+
+		f, err := os.OpenFile(o.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+
+		return stdouttrace.New(stdouttrace.WithWriter(f))
+
+	case "otlp":
+		return otlptracegrpc.New(ctx)
+
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", o.Exporter)
+	}
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start a span with the vidcall tracer
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End the span, recording err if any
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -34,6 +34,17 @@ func GenerateMemeberID() string {
 	return id
 }
 
+// digits a phone caller keys in to reach a room
+func GenerateDialIn() string {
+	id, err := gonanoid.Generate("0123456789", 9)
+
+	if err != nil {
+		return ""
+	}
+
+	return id
+}
+
 func GenerateHostID() string {
 
 	var b [32]byte
@@ -45,3 +56,15 @@ func GenerateHostID() string {
 
 	return hex.EncodeToString(b[:])
 }
+
+func GenerateTokenID() string {
+
+	var b [16]byte
+	_, err := rand.Read(b[:])
+
+	if err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b[:])
+}
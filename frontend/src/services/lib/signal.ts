@@ -1,4 +1,5 @@
-import type {Signal, Sdp, Ice, PeerEvent, PcType, SdpType, ActionType, PeerAction} from "../../types/signal";
+import type {Signal, Sdp, Ice, PeerEvent, PcType, SdpType, ActionType, PeerAction, SignalError} from "../../types/signal";
+import {SUBPROTOCOL} from "../../types/signal";
 import Denque from "denque"
 
 
@@ -19,6 +20,14 @@ export class SignalClient {
     private _onError?: (ev: Event) => void;
     private _onSdp?: (payload: Sdp) => void;
     private _onIce?: (payload: Ice) => void;
+    private _onSignalError?: (err: SignalError, id?: string) => void;
+
+    // actions waiting for their ack or error, by request ID
+    private pending = new Map<string, {resolve: () => void, reject: (err: SignalError) => void}>();
+    private nextID = 0;
+
+    // last room state event applied, undefined until a room_state snapshot
+    private seq?: number;
 
     // Allow multiple event callbacks
     private _onEventSubs = new Set<(e: PeerEvent) => void>()
@@ -29,7 +38,7 @@ export class SignalClient {
     connect(){
         if (this.ws && (this.ws.readyState === WebSocket.OPEN || this.ws.readyState === WebSocket.CONNECTING)) return;
 
-        const ws = new WebSocket(this.signal_url);
+        const ws = new WebSocket(this.signal_url, [SUBPROTOCOL]);
         this.ws = ws;
 
         ws.onopen = () => {
@@ -43,8 +52,11 @@ export class SignalClient {
                 case "sdp": this._onSdp?.(msg.payload); break;
                 case "ice": this._onIce?.(msg.payload); break;
                 case "event":
+                if (!this.inOrder(msg.payload)) break;
                 for (const cb of this._onEventSubs) { try { cb(msg.payload); } catch (e) { console.error(e); } }
                 break;
+                case "ack": this.settle(msg.id); break;
+                case "error": this.settle(msg.id, msg.payload); break;
             }
         };
     }
@@ -65,6 +77,7 @@ export class SignalClient {
     onError(fn: (ev: Event) => void) {this._onError = fn};
     onSdp(fn: (sdp: Sdp) => void) {this._onSdp = fn};    
     onIce(fn: (ice: Ice) => void) {this._onIce = fn};
+    onSignalError(fn: (err: SignalError, id?: string) => void) {this._onSignalError = fn};
     onEvent(fn: (e: PeerEvent) => void) {this._onEventSubs.add(fn); return () => this._onEventSubs.delete(fn)};
 
 
@@ -84,12 +97,49 @@ export class SignalClient {
         this.send({type: "ice", payload})
     }
 
-    sendAction(action: ActionType) {
+    // resolves on the server ack, rejects with the server error
+    sendAction(action: ActionType, extra: Omit<PeerAction, "type"> = {}): Promise<void> {
         const payload: PeerAction = {
             type: action,
+            ...extra,
         };
+        const id = String(++this.nextID);
+
+        const done = new Promise<void>((resolve, reject) => this.pending.set(id, {resolve, reject}));
+        done.catch(() => {});
+
+        this.send({type: "action", id, payload});
+        return done;
+    }
+
+    // drop state events already in the snapshot and resync after a gap
+    private inOrder(e: PeerEvent): boolean {
+        if (e.type === "room_state") { this.seq = e.seq; return true; }
+        // numbering restarts in the new room, wait for its snapshot
+        if (e.type === "breakout_moved") { this.seq = undefined; return true; }
+        if (!e.seq) return true;
+
+        if (this.seq === undefined || e.seq <= this.seq) return false;
+        if (e.seq > this.seq + 1) {
+            this.seq = undefined;
+            this.sendAction("sync_state");
+            return false;
+        }
+
+        this.seq = e.seq;
+        return true;
+    }
+
+    private settle(id?: string, err?: SignalError) {
+        if (err) this._onSignalError?.(err, id);
+        if (!id) return;
+
+        const req = this.pending.get(id);
+        if (!req) return;
+        this.pending.delete(id);
 
-        this.send({type: "action", payload});
+        if (err) req.reject(err);
+        else req.resolve();
     }
 
     // true  if success, false otherwise
@@ -121,6 +171,8 @@ export class SignalClient {
         this.ws.onerror = null;
 
         while (this.queue.length) this.queue.shift();
+        this.pending.clear();
+        this.seq = undefined;
 
     }
 
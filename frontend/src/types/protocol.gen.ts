@@ -0,0 +1,132 @@
+// Code generated by protoschema from sfu.proto. DO NOT EDIT.
+
+export const PROTOCOL_VERSION = 1
+export const SUBPROTOCOL = "vidcall.v1"
+
+export type PcType = "pc_unspecified" | "pub" | "sub"
+
+export type SdpType = "offer" | "answer"
+
+export type ActionType = "start_room" | "end_room" | "join" | "leave" | "audio_on" | "audio_off" | "video_on" | "video_off" | "dubbing_on" | "dubbing_off" | "pause_video" | "resume_video" | "set_max_layer" | "audio_only_on" | "audio_only_off" | "screen_share_on" | "screen_share_off" | "sync_state" | "raise_hand" | "lower_hand" | "react" | "call_next" | "get_hand_queue" | "breakout_open" | "breakout_assign" | "breakout_broadcast" | "breakout_recall" | "egress_start" | "egress_stop" | "transcription_start" | "transcription_stop"
+
+export type VideoLayer = "full" | "base"
+
+export type EgressLayout = "grid" | "speaker"
+
+export type EventType = "room_active" | "room_inactive" | "room_ended" | "join_event" | "leave_event" | "audio_enabled" | "audio_disabled" | "video_enabled" | "video_disabled" | "sub_enabled" | "sub_disabled" | "quality" | "codec_rejected" | "room_state" | "screen_share_started" | "screen_share_stopped" | "hand_raised" | "hand_lowered" | "called_on" | "reaction" | "hand_queue" | "breakouts_opened" | "breakout_moved" | "breakout_message" | "breakouts_closed" | "egress_started" | "egress_stopped" | "transcription_started" | "transcription_stopped" | "caption" | "key_rotate" | "server_draining"
+
+export type RoleType = "unspecified" | "host" | "guest" | "bot" | "viewer"
+
+export type ErrorCode = "error_unspecified" | "bad_request" | "unknown_action" | "unsupported_version" | "not_allowed" | "room_not_live" | "not_subscribed" | "not_implemented" | "internal" | "rate_limited"
+
+export type KeyType = "public_key" | "sender_key"
+
+export interface Sdp {
+    pc: PcType
+    type: SdpType
+    sdp: string
+}
+
+export interface IceCandidate {
+    pc: PcType
+    candidate: string
+    sdpMid: string
+    sdpMLineIndex: number
+    usernameFragment: string
+}
+
+export interface Action {
+    type: ActionType
+    targetID?: string
+    maxLayer?: VideoLayer
+    emoji?: string
+    count?: number
+    durationSec?: number
+    random?: boolean
+    roomID?: string
+    message?: string
+    layout?: EgressLayout
+    rtmpURL?: string
+}
+
+export interface Event {
+    type: EventType
+    name: string
+    peerID: string
+    quality?: Quality
+    codecs?: string[]
+    seq: number
+    state?: RoomState
+    peer?: PeerState
+    handQueue?: string[]
+    emoji?: string
+    breakouts?: string[]
+    roomID?: string
+    message?: string
+    endsAt?: number
+    caption?: Caption
+    keyEpoch?: number
+}
+
+export interface Quality {
+    peerID: string
+    score: number
+    tracks?: TrackQuality[]
+}
+
+export interface TrackQuality {
+    peerID: string
+    kind: string
+    pc: PcType
+    packetLoss: number
+    jitterMs: number
+    rttMs: number
+    bitrateBps: number
+}
+
+export interface RoomState {
+    peers?: PeerState[]
+    encrypted: boolean
+    keyEpoch: number
+}
+
+export interface PeerState {
+    peerID: string
+    name: string
+    role: RoleType
+    audio: boolean
+    video: boolean
+    screenShare: boolean
+    handRaised: boolean
+    joinedAt: number
+}
+
+export interface Caption {
+    peerID: string
+    name: string
+    startAt: number
+    endAt: number
+    text: string
+}
+
+export interface Error {
+    code: ErrorCode
+    message: string
+}
+
+export interface KeyExchange {
+    type: KeyType
+    fromID: string
+    toID?: string
+    blob: string
+    epoch?: number
+}
+
+export type Signal =
+    | {type: "sdp", id?: string, payload: Sdp}
+    | {type: "ice", id?: string, payload: IceCandidate}
+    | {type: "action", id?: string, payload: Action}
+    | {type: "event", id?: string, payload: Event}
+    | {type: "ack", id: string}
+    | {type: "error", id?: string, payload: Error}
+    | {type: "key", id?: string, payload: KeyExchange}
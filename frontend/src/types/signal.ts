@@ -1,39 +1,15 @@
-
-export type Signal =
-    | {type: "sdp", payload: Sdp}
-    | {type: "ice", payload: Ice}
-    | {type: "action", payload: PeerAction}
-    | {type: "event", payload: PeerEvent}
-
-export type SdpType = "offer" | "answer"
-export type PcType = "pub" | "sub" | "pc_unspecified"
-export type RoleType = "host" | "guest" | "bot" | "role_unspecified"
-export type ActionType = "start_room" | "end_room" | "join" | "leave" | "audio_on" | "audio_off" | 
-        "video_on" | "video_off" | "dubbing_on" | "dubbing_off"
-export type EventType = "room_active" | "room_inactive" | "room_ended" | "join_event" | "leave_event" |
-        "audio_enabled" | "audio_disabled" | "video_enabled" | "video_disabled"
-
-
-export interface Sdp{
-    pc: PcType
-    type: SdpType
-    sdp: string
-}
-
-export interface Ice {
-    pc: PcType
-    candidate: string
-    sdpMid: string 
-	sdpMLineIndex: number
-	usernameFragment: string
-}
-
-export interface PeerAction {
-    type: ActionType
-}
-
-export interface PeerEvent {
-    name: string
-    peerID: string
-    type: EventType
-}
\ No newline at end of file
+// wire types are generated from sfu.proto, see backend/cmd/protoschema
+export type {
+    Signal, Sdp, SdpType, PcType, ActionType, VideoLayer, EventType, ErrorCode,
+    Quality, TrackQuality, PeerState, RoomState,
+    IceCandidate as Ice,
+    Action as PeerAction,
+    Event as PeerEvent,
+    Error as SignalError,
+} from "./protocol.gen"
+export { PROTOCOL_VERSION, SUBPROTOCOL } from "./protocol.gen"
+
+export type VideoCodec = "vp8" | "vp9" | "av1" | "h264"
+
+// 0 unknown, 1 poor, 2 good, 3 excellent
+export type QualityScore = 0 | 1 | 2 | 3